@@ -0,0 +1,79 @@
+// Command filegc menjalankan file sweeper sekali dan mencetak laporannya dalam JSON.
+// Default-nya dry run, pakai -dry-run=false untuk benar-benar menghapus.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/configs"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/internalsql"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"log"
+	"os"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", true, "only report objects that would be removed")
+	gracePeriod := flag.Duration("grace", 0, "minimum age before a file can be removed (default: fileGC.gracePeriod)")
+	flag.Parse()
+
+	// Initialize configs
+	err := configs.Init(
+		configs.WithConfigFolder([]string{
+			"./configs/",
+			"./internal/configs/",
+		}),
+		configs.WithConfigFile("config"),
+		configs.WithConfigType("yaml"),
+	)
+	if err != nil {
+		log.Fatalf("error initializing configs: %+v\n", err)
+	}
+	cfg := configs.Get()
+
+	if *gracePeriod == 0 {
+		*gracePeriod = cfg.FileGC.GracePeriod
+	}
+
+	// Connect to database
+	db, err := internalsql.Connect(cfg.Database.DataSourceName)
+	if err != nil {
+		log.Fatalf("error connecting to database %+v\n", err)
+	}
+
+	signingKey := cfg.Storage.SigningKey
+	if signingKey == "" {
+		signingKey = cfg.Service.SecretJWT
+	}
+
+	ctx := context.Background()
+	storageService, err := service.NewStorageServiceFromConfig(ctx, cfg, urlsign.NewSigner(signingKey))
+	if err != nil {
+		log.Fatalf("unable to initialize storage: %+v\n", err)
+	}
+
+	sweeper, err := service.NewFileSweeper(
+		storageService,
+		repository.NewFileRepository(db),
+		repository.NewFileReferenceRepository(db),
+		repository.NewTxManager(db),
+		*gracePeriod,
+	)
+	if err != nil {
+		log.Fatalf("invalid sweeper config: %+v\n", err)
+	}
+
+	report, err := sweeper.Sweep(ctx, *dryRun)
+	if err != nil {
+		log.Fatalf("sweep failed: %+v\n", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("failed to write report: %+v\n", err)
+	}
+}
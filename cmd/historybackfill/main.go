@@ -0,0 +1,50 @@
+// Command historybackfill mengisi history awal untuk employee yang dibuat sebelum ada
+// employee history. Cukup dijalankan sekali setelah upgrade, aman dijalankan ulang.
+package main
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/configs"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/internalsql"
+	"log"
+)
+
+func main() {
+	// Initialize configs
+	err := configs.Init(
+		configs.WithConfigFolder([]string{
+			"./configs/",
+			"./internal/configs/",
+		}),
+		configs.WithConfigFile("config"),
+		configs.WithConfigType("yaml"),
+	)
+	if err != nil {
+		log.Fatalf("error initializing configs: %+v\n", err)
+	}
+	cfg := configs.Get()
+
+	// Connect to database
+	db, err := internalsql.Connect(cfg.Database.DataSourceName)
+	if err != nil {
+		log.Fatalf("error connecting to database %+v\n", err)
+	}
+	defer internalsql.CloseDatabaseConnection(db)
+
+	employeeService := service.NewEmployeeService(
+		repository.NewEmployeeRepository(db),
+		repository.NewDepartmentRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewEmployeeHistoryRepository(db),
+		repository.NewTxManager(db),
+		nil, // Backfill tidak menyentuh file
+	)
+
+	filled, err := employeeService.BackfillHistory(context.Background())
+	if err != nil {
+		log.Fatalf("backfill failed after %d employees: %+v\n", filled, err)
+	}
+	log.Printf("historybackfill: filled history for %d employees\n", filled)
+}
@@ -4,16 +4,18 @@ import (
 	"context"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/configs"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/handlers"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/jobs"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/middleware"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/internalsql"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/jwt"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 	"log"
+	"time"
 )
 
 func main() {
@@ -35,55 +37,177 @@ func main() {
 	}
 	cfg = configs.Get()
 
+	// Format DepartmentID
+	departmentIDFormat := models.DepartmentIDFormat{
+		Prefix:  cfg.Department.IDPrefix,
+		Padding: cfg.Department.IDPadding,
+	}
+	if err := departmentIDFormat.Validate(); err != nil {
+		log.Fatalf("invalid department config: %+v\n", err)
+	}
+
+	// Batasan upload per purpose
+	uploadPolicies := make(map[string]models.UploadPolicy, len(models.UploadPurposes))
+	for _, purpose := range models.UploadPurposes {
+		purposeConfig := cfg.Upload.Purposes[purpose]
+		policy := models.UploadPolicy{
+			AllowedTypes: purposeConfig.AllowedTypes,
+			MaxSize:      purposeConfig.MaxSize,
+			MaxWidth:     purposeConfig.MaxWidth,
+			MaxHeight:    purposeConfig.MaxHeight,
+		}
+		if err := policy.Validate(); err != nil {
+			log.Fatalf("invalid upload config for %s: %+v\n", purpose, err)
+		}
+		uploadPolicies[purpose] = policy
+	}
+
 	// Connect to database
 	db, err := internalsql.Connect(cfg.Database.DataSourceName)
 	if err != nil {
 		log.Fatalf("error connecting to database %+v\n", err)
 	}
 
-	// Initialize AWS S3 client
-	awsCfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(cfg.AWS.Region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			cfg.AWS.AccessKeyID,
-			cfg.AWS.SecretAccessKey,
-			"",
-		)),
-	)
-	if err != nil {
-		log.Fatal("\033[31mUnable to load AWS SDK config:\033[0m", err)
-	}
-
-	s3Client := s3.NewFromConfig(awsCfg)
-
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	employeeRepo := repository.NewEmployeeRepository(db)
 	departmentRepo := repository.NewDepartmentRepository(db)
+	employeeHistoryRepo := repository.NewEmployeeHistoryRepository(db)
+	fileRepo := repository.NewFileRepository(db)
+	fileReferenceRepo := repository.NewFileReferenceRepository(db)
+	tusUploadRepo := repository.NewTusUploadRepository(db)
+	storageQuotaRepo := repository.NewStorageQuotaRepository(db)
+	txManager := repository.NewTxManager(db)
 
 	// Initialize JWT maker
 	jwtMaker := jwt.NewJWTMaker(cfg.Service.SecretJWT)
 
+	// Initialize storage backend (s3, local, memory)
+	signingKey := cfg.Storage.SigningKey
+	if signingKey == "" {
+		signingKey = cfg.Service.SecretJWT
+	}
+	urlSigner := urlsign.NewSigner(signingKey)
+
+	storageService, err := service.NewStorageServiceFromConfig(context.Background(), cfg, urlSigner)
+	if err != nil {
+		log.Fatalf("\033[31mUnable to initialize storage:\033[0m %+v\n", err)
+	}
+
+	// Malware scanner untuk file upload (none, clamd, fake)
+	scanner, err := service.NewScannerFromConfig(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("invalid scanner config: %+v\n", err)
+	}
+
 	// Initialize services
 	authService := service.NewAuthService(userRepo, jwtMaker)
-	profileService := service.NewProfileService(userRepo)
-	storageService := service.NewS3StorageService(s3Client, cfg.AWS.Bucket)
-	fileService := service.NewFileService(storageService)
-	employeeService := service.NewEmployeeService(employeeRepo, departmentRepo)
-	departmentService := service.NewDepartmentService(departmentRepo)
+	fileReferenceService := service.NewFileReferenceService(fileRepo, fileReferenceRepo, urlSigner, cfg.Storage.BaseURL)
+	profileService := service.NewProfileService(userRepo, txManager, fileReferenceService)
+	quotaService := service.NewQuotaService(storageQuotaRepo, userRepo, txManager, cfg.Quota.DefaultBytes)
+	fileService := service.NewFileService(storageService, fileRepo, fileReferenceRepo, txManager, quotaService, scanner, urlSigner, uploadPolicies, cfg.Storage.BaseURL)
+	employeeService := service.NewEmployeeService(employeeRepo, departmentRepo, userRepo, employeeHistoryRepo, txManager, fileReferenceService)
+	departmentService := service.NewDepartmentService(departmentRepo, employeeRepo, employeeHistoryRepo, txManager, departmentIDFormat)
+	tusService := service.NewTusService(storageService, fileService, fileRepo, tusUploadRepo, txManager, quotaService, uploadPolicies)
+
+	scheduler := jobs.NewScheduler()
+
+	// Upload resumable yang melewati Upload-Expires dibersihkan berkala
+	scheduler.Add(jobs.Job{
+		Name:     "tus-expire",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			removed, err := tusService.CleanupExpired(ctx)
+			if err != nil {
+				return err
+			}
+			if removed > 0 {
+				log.Printf("tus-expire: removed %d expired uploads\n", removed)
+			}
+			return nil
+		},
+	})
+
+	// Transfer employee yang dijadwalkan diterapkan setelah tanggal berlakunya sampai
+	scheduler.Add(jobs.Job{
+		Name:     "employee-transfer",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			applied, failed, err := employeeService.ApplyDueTransfers(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+			if applied > 0 || failed > 0 {
+				log.Printf("employee-transfer: applied %d transfers, failed: %d\n", applied, failed)
+			}
+			return nil
+		},
+	})
+
+	// Employee di trash dihapus permanen setelah masa retensi
+	if cfg.Trash.Retention > 0 {
+		scheduler.Add(jobs.Job{
+			Name:     "trash-purge",
+			Interval: cfg.Trash.PurgeInterval,
+			Run: func(ctx context.Context) error {
+				purged, err := employeeService.PurgeTrash(ctx, time.Now().Add(-cfg.Trash.Retention))
+				if err != nil {
+					return err
+				}
+				if purged > 0 {
+					log.Printf("trash-purge: purged %d employees\n", purged)
+				}
+				return nil
+			},
+		})
+	}
+
+	// File sweeper terjadwal untuk object yang tidak dipakai
+	if cfg.FileGC.Enabled {
+		fileSweeper, err := service.NewFileSweeper(storageService, fileRepo, fileReferenceRepo, txManager, cfg.FileGC.GracePeriod)
+		if err != nil {
+			log.Fatalf("invalid fileGC config: %+v\n", err)
+		}
+
+		scheduler.Add(jobs.Job{
+			Name:     "file-gc",
+			Interval: cfg.FileGC.Interval,
+			Run: func(ctx context.Context) error {
+				report, err := fileSweeper.Sweep(ctx, cfg.FileGC.DryRun)
+				if err != nil {
+					return err
+				}
+				log.Printf("file-gc: removed %d files, %d objects (%d bytes), dry run: %t, errors: %d\n",
+					report.RemovedFiles, len(report.Removed), report.RemovedBytes, report.DryRun, len(report.Errors))
+				return nil
+			},
+		})
+	}
+	scheduler.Start(context.Background())
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	profileHandler := handlers.NewProfileHandler(profileService)
 	fileHandler := handlers.NewFileHandler(fileService, profileService)
+	tusHandler := handlers.NewTusHandler(tusService, profileService)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
 	employeeHandler := handlers.NewEmployeeHandler(employeeService)
 	departmentHandler := handlers.NewDepartmentHandler(departmentService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtMaker)
+	adminMiddleware := middleware.NewAdminMiddleware(cfg.Admin.APIKey)
 
-	// Initialize Fiber app
-	app := fiber.New()
+	// Initialize Fiber app. Body request dibaca streaming supaya chunk tus dan presigned PUT
+	// tidak ditampung di memori, batas body route lain ditegakkan middleware.BodyLimit.
+	app := fiber.New(fiber.Config{
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true, // Form multipart baru dibaca handler, setelah lolos BodyLimit
+	})
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit,
+		middleware.StreamedRoute{Method: fiber.MethodPatch, PathPrefix: "/v1/file/tus/"},
+		middleware.StreamedRoute{Method: fiber.MethodPut, PathPrefix: "/v1/files/"},
+	))
 
 	// Routes
 	api := app.Group("/v1")
@@ -95,21 +219,64 @@ func main() {
 	api.Get("/user", authMiddleware.AuthRequired(), profileHandler.GetProfile)
 	api.Patch("/user", authMiddleware.AuthRequired(), profileHandler.UpdateProfile)
 
-	// File upload route (protected)
+	// File routes (protected)
 	api.Post("/file", authMiddleware.AuthRequired(), fileHandler.UploadFile)
+	api.Post("/file/uploads", authMiddleware.AuthRequired(), fileHandler.CreateUpload)
+	api.Post("/file/uploads/:id/complete", authMiddleware.AuthRequired(), fileHandler.CompleteUpload)
+	api.Get("/file", authMiddleware.AuthRequired(), fileHandler.ListFiles)
+	api.Get("/file/usage", authMiddleware.AuthRequired(), quotaHandler.Usage)
+	api.Get("/file/:id", authMiddleware.AuthRequired(), fileHandler.GetFile)
+	api.Get("/file/:id/content", fileHandler.FileContent) // Link stabil, akses via signature
+	api.Delete("/file/:id", authMiddleware.AuthRequired(), fileHandler.DeleteFile)
+
+	// Resumable upload (tus 1.0)
+	api.Options("/file/tus", tusHandler.Options)
+	api.Post("/file/tus", authMiddleware.AuthRequired(), tusHandler.CreateUpload)
+	api.Options("/file/tus/:id", tusHandler.Options)
+	api.Head("/file/tus/:id", authMiddleware.AuthRequired(), tusHandler.HeadUpload)
+	api.Patch("/file/tus/:id", authMiddleware.AuthRequired(), tusHandler.PatchUpload)
+	api.Delete("/file/tus/:id", authMiddleware.AuthRequired(), tusHandler.TerminateUpload)
+
+	// Signed file route untuk storage driver local/memory (akses via signature, tanpa token)
+	api.Get("/files/*", fileHandler.ServeFile)
+	api.Put("/files/*", fileHandler.UploadSignedFile)
 
 	// Employee routes (protected)
 	api.Post("/employee", authMiddleware.AuthRequired(), employeeHandler.CreateEmployee)
-	api.Get("/employee", authMiddleware.AuthRequired(), employeeHandler.ListEmployees)
+	api.Get("/employee", authMiddleware.AuthRequired(), etag.New(etag.Config{Weak: true}), employeeHandler.ListEmployees)
+	api.Post("/employee/import", authMiddleware.AuthRequired(), employeeHandler.ImportEmployees)
+	api.Get("/employee/export", authMiddleware.AuthRequired(), employeeHandler.ExportEmployees)
+	api.Get("/employee/trash", authMiddleware.AuthRequired(), employeeHandler.ListTrash)
+	api.Post("/employee/:identityNumber/restore", authMiddleware.AuthRequired(), employeeHandler.RestoreEmployee)
+	api.Get("/employee/:identityNumber/reports", authMiddleware.AuthRequired(), employeeHandler.ListReports)
+	api.Get("/employee/:identityNumber/history", authMiddleware.AuthRequired(), employeeHandler.GetHistory)
+	api.Post("/employee/:identityNumber/transfers", authMiddleware.AuthRequired(), employeeHandler.ScheduleTransfer)
+	api.Delete("/employee/:identityNumber/transfers/:transferId", authMiddleware.AuthRequired(), employeeHandler.CancelTransfer)
+	api.Get("/orgchart", authMiddleware.AuthRequired(), employeeHandler.OrgChart)
+	api.Get("/employee/:identityNumber", authMiddleware.AuthRequired(), employeeHandler.GetEmployee)
 	api.Patch("/employee/:identityNumber", authMiddleware.AuthRequired(), employeeHandler.UpdateEmployee)
 	api.Delete("/employee/:identityNumber", authMiddleware.AuthRequired(), employeeHandler.DeleteEmployee)
 
 	// Department routes
 	api.Post("/department", authMiddleware.AuthRequired(), departmentHandler.CreateDepartment)
-	api.Get("/department", authMiddleware.AuthRequired(), departmentHandler.ListDepartments)
+	api.Get("/department", authMiddleware.AuthRequired(), etag.New(etag.Config{Weak: true}), departmentHandler.ListDepartments)
+	api.Get("/department/export", authMiddleware.AuthRequired(), departmentHandler.ExportDepartments)
+	api.Get("/department/trash", authMiddleware.AuthRequired(), departmentHandler.ListTrash)
+	api.Get("/department/tree", authMiddleware.AuthRequired(), departmentHandler.DepartmentTree)
+	api.Post("/department/:departmentId/restore", authMiddleware.AuthRequired(), departmentHandler.RestoreDepartment)
+	api.Post("/department/:departmentId/archive", authMiddleware.AuthRequired(), departmentHandler.ArchiveDepartment)
+	api.Post("/department/:departmentId/unarchive", authMiddleware.AuthRequired(), departmentHandler.UnarchiveDepartment)
+	api.Put("/department/:departmentId/heads", authMiddleware.AuthRequired(), departmentHandler.SetDepartmentHeads)
+	api.Get("/department/:departmentId", authMiddleware.AuthRequired(), departmentHandler.GetDepartment)
 	api.Patch("/department/:departmentId", authMiddleware.AuthRequired(), departmentHandler.UpdateDepartment)
 	api.Delete("/department/:departmentId", authMiddleware.AuthRequired(), departmentHandler.DeleteDepartment)
 
+	// Admin routes (X-Admin-Key)
+	admin := api.Group("/admin", adminMiddleware.AdminRequired())
+	admin.Get("/users/:userId/quota", quotaHandler.GetUserQuota)
+	admin.Put("/users/:userId/quota", quotaHandler.SetUserQuota)
+	admin.Delete("/users/:userId/quota", quotaHandler.ResetUserQuota)
+
 	// Start server
 	err = app.Listen(":" + cfg.Service.Port)
 	if err != nil {
@@ -34,6 +34,7 @@ func Init(opts ...Option) error {
 	viper.SetConfigName(opt.configFile)
 	viper.SetConfigType(opt.configType)
 	viper.AutomaticEnv()
+	setDefaults()
 
 	config = new(Config)
 
@@ -69,6 +70,42 @@ func WithConfigType(configType string) Option {
 	}
 }
 
+// setDefaults set default values for optional config keys.
+func setDefaults() {
+	viper.SetDefault("department.idPrefix", "DEP-")
+	viper.SetDefault("department.idPadding", 2)
+	viper.SetDefault("storage.driver", "s3")
+	viper.SetDefault("storage.local.directory", "./uploads")
+	viper.SetDefault("storage.signedURLTTL", "15m")
+
+	viper.SetDefault("fileGC.enabled", false)
+	viper.SetDefault("fileGC.interval", "6h")
+	viper.SetDefault("fileGC.gracePeriod", "24h")
+
+	viper.SetDefault("quota.defaultBytes", 1073741824) // 1GiB per user
+
+	viper.SetDefault("trash.retention", "720h") // 30 hari
+	viper.SetDefault("trash.purgeInterval", "1h")
+
+	viper.SetDefault("scanner.driver", "none")
+	viper.SetDefault("scanner.clamd.network", "tcp")
+	viper.SetDefault("scanner.clamd.address", "localhost:3310")
+	viper.SetDefault("scanner.clamd.timeout", "30s")
+
+	// Batasan upload per purpose, gambar default 100KiB sesuai contract API
+	imageTypes := []string{"image/jpeg", "image/png"}
+	for purpose, dimension := range map[string]int{"avatar": 2048, "company_logo": 2048, "employee_photo": 4096} {
+		viper.SetDefault("upload.purposes."+purpose+".allowedTypes", imageTypes)
+		viper.SetDefault("upload.purposes."+purpose+".maxSize", 102400)
+		viper.SetDefault("upload.purposes."+purpose+".maxWidth", dimension)
+		viper.SetDefault("upload.purposes."+purpose+".maxHeight", dimension)
+	}
+	viper.SetDefault("upload.purposes.document.allowedTypes", []string{"application/pdf", "image/jpeg", "image/png"})
+	viper.SetDefault("upload.purposes.document.maxSize", 20971520) // 20MiB, upload besar lewat /v1/file/tus
+	viper.SetDefault("upload.purposes.document.maxWidth", 8192)
+	viper.SetDefault("upload.purposes.document.maxHeight", 8192)
+}
+
 // getDefaultConfigFolder get default config folder.
 func getDefaultConfigFolder() []string {
 	return []string{"./configs/"}
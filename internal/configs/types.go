@@ -1,10 +1,20 @@
 package configs
 
+import "time"
+
 type (
 	Config struct {
-		Service  Service  `mapstructure:"service"`
-		Database Database `mapstructure:"database"`
-		AWS      AWSConfig
+		Service    Service    `mapstructure:"service"`
+		Database   Database   `mapstructure:"database"`
+		Department Department `mapstructure:"department"`
+		Storage    Storage    `mapstructure:"storage"`
+		Upload     Upload     `mapstructure:"upload"`
+		FileGC     FileGC     `mapstructure:"fileGC"`
+		Quota      Quota      `mapstructure:"quota"`
+		Admin      Admin      `mapstructure:"admin"`
+		Scanner    Scanner    `mapstructure:"scanner"`
+		Trash      Trash      `mapstructure:"trash"`
+		AWS        AWSConfig
 	}
 
 	Service struct {
@@ -16,10 +26,73 @@ type (
 		DataSourceName string `mapstructure:"dataSourceName"`
 	}
 
+	Department struct {
+		IDPrefix  string `mapstructure:"idPrefix"`  // Prefix DepartmentID, contoh: DEP- atau ENG-
+		IDPadding int    `mapstructure:"idPadding"` // Jumlah digit minimal nomor urut, contoh: 4 => ENG-0001
+	}
+
+	Storage struct {
+		Driver        string        `mapstructure:"driver"`        // s3, local atau memory
+		BaseURL       string        `mapstructure:"baseURL"`       // Base URL API untuk link file (/v1/file/:id/content, /v1/files/*)
+		SigningKey    string        `mapstructure:"signingKey"`    // Secret untuk sign URL file, default: service.secretJWT
+		PublicBaseURL string        `mapstructure:"publicBaseURL"` // CDN / bucket publik, kosong = signed URL
+		SignedURLTTL  time.Duration `mapstructure:"signedURLTTL"`  // Masa berlaku signed GET URL S3
+		Local         LocalStorage  `mapstructure:"local"`
+	}
+
+	Upload struct {
+		// Batasan per purpose: avatar, company_logo, employee_photo, document
+		Purposes map[string]UploadPurpose `mapstructure:"purposes"`
+	}
+
+	UploadPurpose struct {
+		AllowedTypes []string `mapstructure:"allowedTypes"` // MIME type: image/jpeg, image/png, application/pdf
+		MaxSize      int64    `mapstructure:"maxSize"`      // Dalam bytes
+		MaxWidth     int      `mapstructure:"maxWidth"`     // Dimensi maksimal gambar dalam px
+		MaxHeight    int      `mapstructure:"maxHeight"`
+	}
+
+	FileGC struct {
+		Enabled     bool          `mapstructure:"enabled"`     // Jalankan sweeper terjadwal di proses API
+		Interval    time.Duration `mapstructure:"interval"`    // Jarak antar sweep
+		GracePeriod time.Duration `mapstructure:"gracePeriod"` // Umur minimal file/object sebelum boleh dihapus
+		DryRun      bool          `mapstructure:"dryRun"`      // Hanya lapor, tidak menghapus
+	}
+
+	Trash struct {
+		Retention     time.Duration `mapstructure:"retention"`     // Lama data di trash sebelum dihapus permanen, 0 = tidak pernah
+		PurgeInterval time.Duration `mapstructure:"purgeInterval"` // Jarak antar purge
+	}
+
+	Quota struct {
+		DefaultBytes int64 `mapstructure:"defaultBytes"` // Kuota storage default per user, 0 = tanpa batas
+	}
+
+	Admin struct {
+		APIKey string `mapstructure:"apiKey"` // Header X-Admin-Key untuk endpoint /v1/admin, kosong = nonaktif
+	}
+
+	Scanner struct {
+		Driver string      `mapstructure:"driver"` // none, clamd atau fake (EICAR test string saja)
+		Clamd  ClamdConfig `mapstructure:"clamd"`
+	}
+
+	ClamdConfig struct {
+		Network string        `mapstructure:"network"` // tcp atau unix
+		Address string        `mapstructure:"address"` // host:port atau path socket
+		Timeout time.Duration `mapstructure:"timeout"` // Batas waktu satu kali scan
+	}
+
+	LocalStorage struct {
+		Directory string `mapstructure:"directory"`
+	}
+
 	AWSConfig struct {
 		Region          string
 		Bucket          string
 		AccessKeyID     string `mapstructure:"access_key_id"`
 		SecretAccessKey string `mapstructure:"secret_access_key"`
+		Endpoint        string `mapstructure:"endpoint"`       // Endpoint custom untuk S3-compatible (MinIO)
+		UsePathStyle    bool   `mapstructure:"use_path_style"` // Wajib true untuk kebanyakan setup MinIO
 	}
 )
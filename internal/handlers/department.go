@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
 	"github.com/gofiber/fiber/v2"
 	"strconv"
-	"strings"
+	"time"
 )
 
 type DepartmentHandler struct {
@@ -36,14 +38,48 @@ func (h *DepartmentHandler) CreateDepartment(c *fiber.Ctx) error {
 
 	response, err := h.departmentService.CreateDepartment(c.Context(), userID, &req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create department",
-		})
+		switch err.Error() {
+		case "parent department not found", "department is archived":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create department",
+			})
+		}
 	}
 
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
+// GetDepartment mengembalikan satu department beserta ETag-nya, dipakai client sebelum PATCH atau DELETE
+// (GET /v1/department/:departmentId)
+func (h *DepartmentHandler) GetDepartment(c *fiber.Ctx) error {
+	departmentId, err := h.departmentIDParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.departmentService.GetDepartment(c.Context(), userID, departmentId)
+	if err != nil {
+		return departmentErrorResponse(c, err)
+	}
+
+	// Conditional GET dengan If-None-Match
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && models.MatchesIfNoneMatch(match, response.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
 func (h *DepartmentHandler) UpdateDepartment(c *fiber.Ctx) error {
 	// Get departmentId from params
 	departmentId := c.Params("departmentId")
@@ -53,31 +89,48 @@ func (h *DepartmentHandler) UpdateDepartment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate departmentId format
-	if !strings.HasPrefix(departmentId, "DEP-") {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid department ID format",
-		})
-	}
-
 	// Get userID from context (set by auth middleware)
 	userID := c.Locals("userID").(uint)
 
+	// Get current state, patch diterapkan di atas data ini
+	current, err := h.departmentService.GetDepartment(c.Context(), userID, departmentId)
+	if err != nil {
+		switch err.Error() {
+		case "department not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "unauthorized access to department":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	// If-Match wajib supaya patch tidak diterapkan di atas versi yang belum dilihat client
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return preconditionRequired(c)
+	}
+
 	var req models.UpdateDepartmentRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	fields, err := applyPatch(c, current, &req)
+	if err != nil {
+		return patchErrorResponse(c, err)
 	}
 
-	// Validate request
-	if err := validate.Struct(req); err != nil {
+	// Validasi hanya field yang dikirim
+	if err := validate.StructPartial(req, fields...); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"errors": formatValidationErrors(err),
 		})
 	}
 
-	response, err := h.departmentService.UpdateDepartment(c.Context(), userID, departmentId, &req)
+	response, err := h.departmentService.UpdateDepartment(c.Context(), userID, departmentId, &req, ifMatch)
 	if err != nil {
 		switch err.Error() {
 		case "department not found":
@@ -88,6 +141,18 @@ func (h *DepartmentHandler) UpdateDepartment(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": err.Error(),
 			})
+		case "parent department not found", "department cannot be its own parent", "department is archived":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "parent assignment would create a cycle":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "precondition failed":
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		default:
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Internal server error",
@@ -95,6 +160,7 @@ func (h *DepartmentHandler) UpdateDepartment(c *fiber.Ctx) error {
 		}
 	}
 
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
 	return c.Status(fiber.StatusOK).JSON(response)
 }
 
@@ -107,17 +173,34 @@ func (h *DepartmentHandler) DeleteDepartment(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate departmentId format
-	if !strings.HasPrefix(departmentId, "DEP-") {
+	// reassignTo dan cascade menentukan nasib employee di department ini
+	opts := models.DeleteDepartmentOptions{
+		ReassignTo: c.Query("reassignTo"),
+	}
+	if cascade := c.Query("cascade"); cascade != "" {
+		val, err := strconv.ParseBool(cascade)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "cascade must be a boolean",
+			})
+		}
+		opts.Cascade = val
+	}
+	if opts.ReassignTo != "" && opts.Cascade {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid department ID format",
+			"error": "reassignTo and cascade cannot be used together",
 		})
 	}
 
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return preconditionRequired(c)
+	}
+
 	// Get userID from context
 	userID := c.Locals("userID").(uint)
 
-	err := h.departmentService.DeleteDepartment(c.Context(), userID, departmentId)
+	report, err := h.departmentService.DeleteDepartment(c.Context(), userID, departmentId, opts, ifMatch)
 	if err != nil {
 		switch err.Error() {
 		case "department not found":
@@ -128,10 +211,18 @@ func (h *DepartmentHandler) DeleteDepartment(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": err.Error(),
 			})
-		case "department still contains employees":
+		case "department still contains employees", "department still contains subdepartments":
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": err.Error(),
 			})
+		case "cannot reassign employees to the same department", "target department not found", "department is archived":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "precondition failed":
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		default:
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Internal server error",
@@ -141,6 +232,7 @@ func (h *DepartmentHandler) DeleteDepartment(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Department deleted successfully",
+		"report":  report,
 	})
 }
 
@@ -167,6 +259,14 @@ func (h *DepartmentHandler) ListDepartments(c *fiber.Ctx) error {
 
 	filter.Name = c.Query("name")
 
+	// Default hanya department aktif, archived/all untuk melihat yang diarsipkan
+	filter.Status = c.Query("status")
+	if !filter.ValidStatus() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be one of: active, archived, all",
+		})
+	}
+
 	departments, err := h.departmentService.ListDepartments(c.Context(), userID, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -176,3 +276,237 @@ func (h *DepartmentHandler) ListDepartments(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(departments)
 }
+
+// ExportDepartments mengirim semua department yang cocok dengan filter sebagai CSV, XLSX atau NDJSON
+// (GET /v1/department/export). Filter sama dengan GET /v1/department, tanpa limit semua department dikirim.
+func (h *DepartmentHandler) ExportDepartments(c *fiber.Ctx) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return exportErrorResponse(c, err)
+	}
+
+	filter := &models.DepartmentFilter{
+		Name:   c.Query("name"),
+		Status: c.Query("status"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if val, err := strconv.Atoi(limit); err == nil && val > 0 {
+			filter.Limit = val
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if val, err := strconv.Atoi(offset); err == nil && val >= 0 {
+			filter.Offset = val
+		}
+	}
+	if !filter.ValidStatus() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be one of: active, archived, all",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	columns := []string{"departmentId", "name", "parentDepartmentId", "archivedAt"}
+	return streamExport(c, format, "departments", columns, func(enc exportEncoder) error {
+		// Context request fasthttp tidak boleh dipakai setelah handler selesai
+		return h.departmentService.ExportDepartments(context.Background(), userID, filter, func(department *models.DepartmentResponse) error {
+			archivedAt := ""
+			if department.ArchivedAt != nil {
+				archivedAt = department.ArchivedAt.UTC().Format(time.RFC3339)
+			}
+			return enc.Encode(department, []string{department.DepartmentID, department.Name, department.ParentDepartmentID, archivedAt})
+		})
+	})
+}
+
+// DepartmentTree mengembalikan semua department sebagai pohon induk-sub department (GET /v1/department/tree).
+// Query status sama dengan GET /v1/department.
+func (h *DepartmentHandler) DepartmentTree(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	filter := &models.DepartmentFilter{Status: c.Query("status")}
+	if !filter.ValidStatus() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be one of: active, archived, all",
+		})
+	}
+
+	tree, err := h.departmentService.DepartmentTree(c.Context(), userID, filter.Status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch departments",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tree)
+}
+
+// ListTrash mengembalikan department yang sudah dihapus (GET /v1/department/trash)
+func (h *DepartmentHandler) ListTrash(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	filter := &models.DepartmentFilter{
+		Limit:  5, // default limit
+		Offset: 0, // default offset
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if val, err := strconv.Atoi(limit); err == nil && val > 0 {
+			filter.Limit = val
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if val, err := strconv.Atoi(offset); err == nil && val >= 0 {
+			filter.Offset = val
+		}
+	}
+	filter.Name = c.Query("name")
+
+	departments, err := h.departmentService.ListTrash(c.Context(), userID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch departments",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(departments)
+}
+
+// RestoreDepartment mengembalikan department dari trash (POST /v1/department/:departmentId/restore)
+func (h *DepartmentHandler) RestoreDepartment(c *fiber.Ctx) error {
+	departmentId, err := h.departmentIDParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.departmentService.RestoreDepartment(c.Context(), userID, departmentId)
+	if err != nil {
+		return departmentErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ArchiveDepartment mengarsipkan department (POST /v1/department/:departmentId/archive)
+func (h *DepartmentHandler) ArchiveDepartment(c *fiber.Ctx) error {
+	departmentId, err := h.departmentIDParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.departmentService.ArchiveDepartment(c.Context(), userID, departmentId, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return departmentErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// UnarchiveDepartment mengaktifkan lagi department yang diarsipkan (POST /v1/department/:departmentId/unarchive)
+func (h *DepartmentHandler) UnarchiveDepartment(c *fiber.Ctx) error {
+	departmentId, err := h.departmentIDParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.departmentService.UnarchiveDepartment(c.Context(), userID, departmentId, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return departmentErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// SetDepartmentHeads mengganti kepala department (PUT /v1/department/:departmentId/heads).
+// Kepala department boleh mengubah dan menghapus employee di department tersebut beserta sub-department-nya.
+func (h *DepartmentHandler) SetDepartmentHeads(c *fiber.Ctx) error {
+	departmentId, err := h.departmentIDParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var req models.SetDepartmentHeadsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return preconditionRequired(c)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.departmentService.SetDepartmentHeads(c.Context(), userID, departmentId, req.Heads, ifMatch)
+	if err != nil {
+		return departmentErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// departmentIDParam membaca :departmentId. ID diperlakukan sebagai key biasa tanpa cek format,
+// department dengan format ID lama tetap bisa diakses dan ID yang tidak ada menjadi 404.
+func (h *DepartmentHandler) departmentIDParam(c *fiber.Ctx) (string, error) {
+	departmentId := c.Params("departmentId")
+	if departmentId == "" {
+		return "", errors.New("Department ID is required")
+	}
+	return departmentId, nil
+}
+
+// departmentErrorResponse memetakan error dari DepartmentService ke HTTP status
+func departmentErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "department not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "unauthorized access to department":
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "head employee not found", "head employee is not linked to a user account":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "department id already in use", "department is already archived", "department is not archived":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "precondition failed":
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+}
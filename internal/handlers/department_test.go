@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"testing"
+)
+
+type fakeDepartmentService struct {
+	service.DepartmentService
+	departments map[string]*models.DepartmentResponse
+	owner       uint
+}
+
+func (s *fakeDepartmentService) GetDepartment(ctx context.Context, userID uint, departmentID string) (*models.DepartmentResponse, error) {
+	department, ok := s.departments[departmentID]
+	if !ok {
+		return nil, errors.New("department not found")
+	}
+	if userID != s.owner {
+		return nil, errors.New("unauthorized access to department")
+	}
+	copied := *department
+	return &copied, nil
+}
+
+func (s *fakeDepartmentService) ListDepartments(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.DepartmentResponse, error) {
+	var departments []*models.DepartmentResponse
+	for _, department := range s.departments {
+		copied := *department
+		departments = append(departments, &copied)
+	}
+	return departments, nil
+}
+
+func (s *fakeDepartmentService) UpdateDepartment(ctx context.Context, userID uint, departmentID string, req *models.UpdateDepartmentRequest, ifMatch string) (*models.DepartmentResponse, error) {
+	department, err := s.GetDepartment(ctx, userID, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.MatchesIfMatch(ifMatch, department.Version) {
+		return nil, errors.New("precondition failed")
+	}
+	stored := s.departments[departmentID]
+	stored.Name = req.Name
+	stored.Version++
+	copied := *stored
+	return &copied, nil
+}
+
+func newDepartmentTestApp(owner uint) *fiber.App {
+	svc := &fakeDepartmentService{
+		departments: map[string]*models.DepartmentResponse{
+			"ENG-0001": {DepartmentID: "ENG-0001", Name: "Engineering", Version: 1},
+		},
+		owner: owner,
+	}
+	h := NewDepartmentHandler(svc)
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/v1/department", h.ListDepartments)
+		app.Get("/v1/department/:departmentId", h.GetDepartment)
+		app.Patch("/v1/department/:departmentId", h.UpdateDepartment)
+		app.Delete("/v1/department/:departmentId", h.DeleteDepartment)
+	})
+}
+
+func TestDepartmentListGetThenPatchWithETag(t *testing.T) {
+	app := newDepartmentTestApp(1)
+
+	resp := doRequest(t, app, fiber.MethodGet, "/v1/department", "", nil)
+	var list []models.DepartmentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil || len(list) != 1 {
+		t.Fatalf("list = %v, %v, want one department", list, err)
+	}
+
+	resp = doRequest(t, app, fiber.MethodGet, "/v1/department/"+list[0].DepartmentID, "", nil)
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if resp.StatusCode != fiber.StatusOK || etag != `"1"` {
+		t.Fatalf("GET = %d with ETag %s, want 200 with \"1\"", resp.StatusCode, etag)
+	}
+
+	resp = doRequest(t, app, fiber.MethodPatch, "/v1/department/ENG-0001", `{"name":"Platform"}`, map[string]string{fiber.HeaderIfMatch: etag})
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != `"2"` {
+		t.Fatalf("PATCH = %d with ETag %s, want 200 with \"2\"", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+
+	resp = doRequest(t, app, fiber.MethodPatch, "/v1/department/ENG-0001", `{"name":"Infra"}`, map[string]string{fiber.HeaderIfMatch: etag})
+	if resp.StatusCode != fiber.StatusPreconditionFailed {
+		t.Errorf("stale PATCH status = %d, want 412", resp.StatusCode)
+	}
+
+	resp = doRequest(t, app, fiber.MethodDelete, "/v1/department/ENG-0001", "", nil)
+	if resp.StatusCode != fiber.StatusPreconditionRequired {
+		t.Errorf("DELETE without If-Match status = %d, want 428", resp.StatusCode)
+	}
+}
+
+func TestGetDepartmentOfAnotherTenant(t *testing.T) {
+	app := newDepartmentTestApp(2)
+
+	resp := doRequest(t, app, fiber.MethodGet, "/v1/department/ENG-0001", "", nil)
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
@@ -34,14 +35,19 @@ func (h *EmployeeHandler) CreateEmployee(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.employeeService.CreateEmployee(c.Context(), &req)
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.employeeService.CreateEmployee(c.Context(), userID, &req)
 	if err != nil {
 		switch err.Error() {
-		case "department not found":
+		case "department not found", "department is archived", "image must be an uploaded file", "image file not found",
+			"probation end date must not be before hire date", "date of birth must be in the past",
+			"manager not found", "employee cannot be their own manager", "user not found":
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
-		case "identity number already exists":
+		case "identity number already exists", "work email already exists", "manager assignment would create a cycle",
+			"user is already linked to another employee":
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": err.Error(),
 			})
@@ -52,10 +58,13 @@ func (h *EmployeeHandler) CreateEmployee(c *fiber.Ctx) error {
 		}
 	}
 
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
-func (h *EmployeeHandler) UpdateEmployee(c *fiber.Ctx) error {
+// GetEmployee mengembalikan satu employee beserta ETag-nya, dipakai client sebelum PATCH atau DELETE
+// (GET /v1/employee/:identityNumber)
+func (h *EmployeeHandler) GetEmployee(c *fiber.Ctx) error {
 	identityNumber := c.Params("identityNumber")
 	if identityNumber == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -63,34 +72,95 @@ func (h *EmployeeHandler) UpdateEmployee(c *fiber.Ctx) error {
 		})
 	}
 
-	var req models.UpdateEmployeeRequest
-	if err := c.BodyParser(&req); err != nil {
+	response, err := h.employeeService.GetEmployee(c.Context(), identityNumber)
+	if err != nil {
+		if err.Error() == "employee not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	// Conditional GET dengan If-None-Match
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && models.MatchesIfNoneMatch(match, response.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *EmployeeHandler) UpdateEmployee(c *fiber.Ctx) error {
+	identityNumber := c.Params("identityNumber")
+	if identityNumber == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
+			"error": "Identity number is required",
 		})
 	}
 
-	if err := validate.Struct(req); err != nil {
+	// Get current state, patch diterapkan di atas data ini
+	current, err := h.employeeService.GetEmployee(c.Context(), identityNumber)
+	if err != nil {
+		if err.Error() == "employee not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	// If-Match wajib supaya patch tidak diterapkan di atas versi yang belum dilihat client
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return preconditionRequired(c)
+	}
+
+	var req models.UpdateEmployeeRequest
+	fields, err := applyPatch(c, current, &req)
+	if err != nil {
+		return patchErrorResponse(c, err)
+	}
+
+	// Validasi hanya field yang dikirim
+	if err := validate.StructPartial(req, fields...); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"errors": formatValidationErrors(err),
 		})
 	}
 
-	response, err := h.employeeService.UpdateEmployee(c.Context(), identityNumber, &req)
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.employeeService.UpdateEmployee(c.Context(), userID, identityNumber, &req, ifMatch)
 	if err != nil {
 		switch err.Error() {
 		case "employee not found":
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": err.Error(),
 			})
-		case "department not found":
+		case "unauthorized access to employee":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "department not found", "department is archived", "image must be an uploaded file", "image file not found",
+			"probation end date must not be before hire date", "date of birth must be in the past",
+			"manager not found", "employee cannot be their own manager", "user not found":
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
-		case "identity number already exists":
+		case "identity number already exists", "work email already exists", "manager assignment would create a cycle",
+			"user is already linked to another employee":
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": err.Error(),
 			})
+		case "precondition failed":
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		default:
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Internal server error",
@@ -98,6 +168,7 @@ func (h *EmployeeHandler) UpdateEmployee(c *fiber.Ctx) error {
 		}
 	}
 
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
 	return c.Status(fiber.StatusOK).JSON(response)
 }
 
@@ -109,22 +180,164 @@ func (h *EmployeeHandler) DeleteEmployee(c *fiber.Ctx) error {
 		})
 	}
 
-	err := h.employeeService.DeleteEmployee(c.Context(), identityNumber)
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return preconditionRequired(c)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	err := h.employeeService.DeleteEmployee(c.Context(), userID, identityNumber, ifMatch)
 	if err != nil {
-		if err.Error() == "employee not found" {
+		switch err.Error() {
+		case "employee not found":
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": err.Error(),
 			})
+		case "unauthorized access to employee":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "precondition failed":
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
 		}
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *EmployeeHandler) ListEmployees(c *fiber.Ctx) error {
+	filter, err := parseEmployeeFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+
+	employees, err := h.employeeService.ListEmployees(c.Context(), filter)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Internal server error",
 		})
 	}
 
-	return c.SendStatus(fiber.StatusOK)
+	return c.Status(fiber.StatusOK).JSON(employees)
 }
 
-func (h *EmployeeHandler) ListEmployees(c *fiber.Ctx) error {
+// ListTrash mengembalikan employee yang sudah dihapus dan belum dipurge (GET /v1/employee/trash)
+func (h *EmployeeHandler) ListTrash(c *fiber.Ctx) error {
+	filter, err := parseEmployeeFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	employees, err := h.employeeService.ListTrash(c.Context(), userID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(employees)
+}
+
+// RestoreEmployee mengeluarkan employee dari trash (POST /v1/employee/:identityNumber/restore)
+func (h *EmployeeHandler) RestoreEmployee(c *fiber.Ctx) error {
+	identityNumber := c.Params("identityNumber")
+	if identityNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Identity number is required",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	response, err := h.employeeService.RestoreEmployee(c.Context(), userID, identityNumber)
+	if err != nil {
+		switch err.Error() {
+		case "employee not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "unauthorized access to employee":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "identity number already exists", "work email already exists", "department not found",
+			"user is already linked to another employee":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(response.Version))
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ExportEmployees mengirim semua employee yang cocok dengan filter sebagai CSV, XLSX atau NDJSON
+// (GET /v1/employee/export). Filter sama dengan GET /v1/employee, tanpa limit semua employee dikirim.
+func (h *EmployeeHandler) ExportEmployees(c *fiber.Ctx) error {
+	format, err := exportFormat(c)
+	if err != nil {
+		return exportErrorResponse(c, err)
+	}
+
+	filter, err := parseEmployeeFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+	if c.Query("limit") == "" {
+		filter.Limit = 0
+	}
+
+	columns := []string{
+		"identityNumber", "name", "gender", "departmentId", "employeeImageUri",
+		"workEmail", "phone", "jobTitle", "employmentType", "hireDate", "probationEndDate", "dateOfBirth", "address",
+		"managerIdentityNumber",
+	}
+	return streamExport(c, format, "employees", columns, func(enc exportEncoder) error {
+		// Context request fasthttp tidak boleh dipakai setelah handler selesai
+		return h.employeeService.ExportEmployees(context.Background(), filter, func(employee *models.EmployeeResponse) error {
+			return enc.Encode(employee, []string{
+				employee.IdentityNumber,
+				employee.Name,
+				employee.Gender,
+				employee.DepartmentID,
+				employee.EmployeeImageUri,
+				employee.WorkEmail,
+				employee.Phone,
+				employee.JobTitle,
+				employee.EmploymentType,
+				employee.HireDate,
+				employee.ProbationEndDate,
+				employee.DateOfBirth,
+				employee.Address,
+				employee.ManagerIdentityNumber,
+			})
+		})
+	})
+}
+
+// parseEmployeeFilter membaca query parameter pagination dan filter employee, error berupa
+// validator.ValidationErrors jika nilai filter tidak valid
+func parseEmployeeFilter(c *fiber.Ctx) (*models.EmployeeFilter, error) {
 	filter := &models.EmployeeFilter{
 		Limit:  5, // default limit
 		Offset: 0, // default offset
@@ -148,15 +361,23 @@ func (h *EmployeeHandler) ListEmployees(c *fiber.Ctx) error {
 	filter.Name = c.Query("name")
 	filter.Gender = c.Query("gender")
 	filter.DepartmentID = c.Query("departmentId") // Langsung assign string departmentId
+	filter.OwnerID = c.Locals("userID").(uint)    // departmentId hanya unik di tenant user yang login
+	filter.JobTitle = c.Query("jobTitle")
+	filter.EmploymentType = c.Query("employmentType")
+	filter.HiredFrom = c.Query("hiredFrom")
+	filter.HiredTo = c.Query("hiredTo")
 
-	employees, err := h.employeeService.ListEmployees(c.Context(), filter)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Internal server error",
-		})
+	// includeSubdepartments=true ikut mengambil employee di semua sub-department departmentId
+	if include := c.Query("includeSubdepartments"); include != "" {
+		if val, err := strconv.ParseBool(include); err == nil {
+			filter.IncludeSubdepartments = val
+		}
 	}
 
-	return c.Status(fiber.StatusOK).JSON(employees)
+	if err := validate.Struct(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
 }
 
 // Helper function untuk format validation errors
@@ -183,6 +404,18 @@ func formatValidationMessage(err validator.FieldError) string {
 		return fmt.Sprintf("%s must be one of: %s", err.Field(), err.Param())
 	case "uri":
 		return fmt.Sprintf("%s must be a valid URI", err.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", err.Field())
+	case "e164":
+		return fmt.Sprintf("%s must be a phone number in E.164 format, e.g. +6281234567890", err.Field())
+	case "datetime":
+		return fmt.Sprintf("%s must be a date in %s format", err.Field(), "YYYY-MM-DD")
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", err.Field(), err.Param())
+	case "required_without":
+		return fmt.Sprintf("%s is required when %s is not set", err.Field(), err.Param())
+	case "excluded_with":
+		return fmt.Sprintf("%s must not be set together with %s", err.Field(), err.Param())
 	default:
 		return fmt.Sprintf("%s is invalid", err.Field())
 	}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// GetHistory mengembalikan riwayat department, jabatan dan atasan employee beserta transfer
+// yang dijadwalkan (GET /v1/employee/:identityNumber/history)
+func (h *EmployeeHandler) GetHistory(c *fiber.Ctx) error {
+	identityNumber := c.Params("identityNumber")
+	if identityNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Identity number is required",
+		})
+	}
+
+	history, err := h.employeeService.GetHistory(c.Context(), identityNumber)
+	if err != nil {
+		if err.Error() == "employee not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(history)
+}
+
+// ScheduleTransfer menjadwalkan perpindahan employee yang diterapkan otomatis pada effectiveDate
+// (POST /v1/employee/:identityNumber/transfers)
+func (h *EmployeeHandler) ScheduleTransfer(c *fiber.Ctx) error {
+	identityNumber := c.Params("identityNumber")
+	if identityNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Identity number is required",
+		})
+	}
+
+	var req models.ScheduleTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	transfer, err := h.employeeService.ScheduleTransfer(c.Context(), userID, identityNumber, &req)
+	if err != nil {
+		return transferErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// CancelTransfer membatalkan transfer yang belum diterapkan
+// (DELETE /v1/employee/:identityNumber/transfers/:transferId)
+func (h *EmployeeHandler) CancelTransfer(c *fiber.Ctx) error {
+	identityNumber := c.Params("identityNumber")
+	if identityNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Identity number is required",
+		})
+	}
+
+	transferID, err := strconv.ParseUint(c.Params("transferId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid transfer ID",
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	transfer, err := h.employeeService.CancelTransfer(c.Context(), userID, identityNumber, uint(transferID))
+	if err != nil {
+		return transferErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(transfer)
+}
+
+// transferErrorResponse memetakan error transfer dari EmployeeService ke HTTP status
+func transferErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "employee not found", "transfer not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "unauthorized access to employee":
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "effective date must be in the future", "department not found", "department is archived",
+		"manager not found", "employee cannot be their own manager":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "transfer already scheduled for this date", "transfer is not pending", "manager assignment would create a cycle":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/xlsx"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Kolom yang wajib ada di header file import
+var requiredImportColumns = []string{"identityNumber", "name", "gender", "departmentId"}
+
+// ImportEmployees membuat banyak employee sekaligus dari file CSV atau XLSX (POST /v1/employee/import).
+//
+// Form field:
+//   - file: file CSV atau XLSX, baris pertama adalah header
+//   - format: csv atau xlsx, opsional jika ekstensi file sudah sesuai
+//   - mapping: JSON object field -> nama header, contoh {"identityNumber":"NIK","name":"Nama"}
+//
+// Query dryRun=true hanya menjalankan validasi tanpa menyimpan.
+func (h *EmployeeHandler) ImportEmployees(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File is required",
+		})
+	}
+
+	dryRun := false
+	if val := c.Query("dryRun"); val != "" {
+		if dryRun, err = strconv.ParseBool(val); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "dryRun must be a boolean",
+			})
+		}
+	}
+
+	mapping, err := parseImportMapping(c.FormValue("mapping"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	table, err := readImportTable(fileHeader, c.FormValue("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	rows, err := buildImportRows(table, mapping)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	result, err := h.employeeService.ImportEmployees(c.Context(), userID, rows, dryRun)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	switch {
+	case len(result.Errors) > 0:
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(result)
+	case dryRun:
+		return c.Status(fiber.StatusOK).JSON(result)
+	default:
+		return c.Status(fiber.StatusCreated).JSON(result)
+	}
+}
+
+// parseImportMapping membaca mapping field -> header. Field yang tidak disebut memakai
+// nama field itu sendiri sebagai nama header.
+func parseImportMapping(raw string) (map[string]string, error) {
+	mapping := make(map[string]string, len(models.EmployeeImportColumns))
+	for _, field := range models.EmployeeImportColumns {
+		mapping[field] = field
+	}
+	if raw == "" {
+		return mapping, nil
+	}
+
+	var custom map[string]string
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return nil, errors.New("mapping must be a JSON object of field to column name")
+	}
+	for field, column := range custom {
+		if _, ok := mapping[field]; !ok {
+			return nil, fmt.Errorf("unknown import field: %s", field)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// readImportTable membaca file upload menjadi baris-baris cell
+func readImportTable(fileHeader *multipart.FileHeader, format string) ([][]string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, errors.New("unsupported file format, use csv or xlsx")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	defer file.Close()
+
+	if format == "xlsx" {
+		table, err := xlsx.ReadFirstSheet(file, fileHeader.Size)
+		if err != nil {
+			return nil, errors.New("invalid xlsx file")
+		}
+		return table, nil
+	}
+
+	return readCSVTable(file)
+}
+
+// readCSVTable membaca CSV baris per baris dan berhenti begitu jumlah baris data melewati
+// batas import, file besar tidak perlu dibaca sampai habis
+func readCSVTable(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Jumlah kolom boleh berbeda, kolom kosong di akhir sering dihilangkan
+	reader.TrimLeadingSpace = true
+
+	var table [][]string
+	dataRows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv file: %v", err)
+		}
+
+		// csv.Reader melewati baris kosong, diganti record kosong supaya nomor baris di error
+		// import tetap sama dengan nomor baris di file
+		line, _ := reader.FieldPos(0)
+		for len(table) > 0 && len(table)+1 < line {
+			table = append(table, nil)
+		}
+
+		// Baris pertama adalah header, baris kosong tidak dihitung
+		if len(table) > 0 && !isBlankRecord(record) {
+			dataRows++
+			if dataRows > models.MaxEmployeeImportRows {
+				return nil, fmt.Errorf("import file must not exceed %d rows", models.MaxEmployeeImportRows)
+			}
+		}
+		table = append(table, record)
+	}
+
+	// Excel menambahkan BOM UTF-8 di awal file CSV
+	if len(table) > 0 && len(table[0]) > 0 {
+		table[0][0] = strings.TrimPrefix(table[0][0], "\ufeff")
+	}
+	return table, nil
+}
+
+// buildImportRows mengubah tabel menjadi CreateEmployeeRequest dan menjalankan validasi
+// yang sama dengan POST /v1/employee
+func buildImportRows(table [][]string, mapping map[string]string) ([]*models.EmployeeImportRow, error) {
+	if len(table) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	// Cari index kolom dari header, nama header tidak case sensitive
+	headers := make(map[string]int, len(table[0]))
+	for i, header := range table[0] {
+		headers[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	columns := make(map[string]int, len(mapping))
+	for field, column := range mapping {
+		if i, ok := headers[strings.ToLower(strings.TrimSpace(column))]; ok {
+			columns[field] = i
+		}
+	}
+	for _, field := range requiredImportColumns {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("column %q not found", mapping[field])
+		}
+	}
+
+	var rows []*models.EmployeeImportRow
+	for i, record := range table[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) >= models.MaxEmployeeImportRows {
+			return nil, fmt.Errorf("import file must not exceed %d rows", models.MaxEmployeeImportRows)
+		}
+
+		value := func(field string) string {
+			col, ok := columns[field]
+			if !ok || col >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[col])
+		}
+
+		row := &models.EmployeeImportRow{
+			Row: i + 2, // Baris 1 adalah header
+			Request: models.CreateEmployeeRequest{
+				IdentityNumber:   value("identityNumber"),
+				Name:             value("name"),
+				Gender:           value("gender"),
+				DepartmentId:     value("departmentId"),
+				EmployeeImageUri: value("employeeImageUri"),
+				WorkEmail:        value("workEmail"),
+				Phone:            value("phone"),
+				JobTitle:         value("jobTitle"),
+				EmploymentType:   value("employmentType"),
+				HireDate:         value("hireDate"),
+				ProbationEndDate: value("probationEndDate"),
+				DateOfBirth:      value("dateOfBirth"),
+				Address:          value("address"),
+
+				ManagerIdentityNumber: value("managerIdentityNumber"),
+				UserEmail:             value("userEmail"),
+			},
+		}
+
+		if raw := value("employeeImageFileId"); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				row.Errors = append(row.Errors, models.EmployeeImportError{
+					Row:            row.Row,
+					IdentityNumber: row.Request.IdentityNumber,
+					Field:          "employeeImageFileId",
+					Message:        "EmployeeImageFileId must be a number",
+				})
+			} else {
+				fileID := uint(id)
+				row.Request.EmployeeImageFileId = &fileID
+			}
+		}
+
+		if err := validate.Struct(row.Request); err != nil {
+			for _, fieldErr := range err.(validator.ValidationErrors) {
+				row.Errors = append(row.Errors, models.EmployeeImportError{
+					Row:            row.Row,
+					IdentityNumber: row.Request.IdentityNumber,
+					Field:          lowerFirst(fieldErr.Field()),
+					Message:        formatValidationMessage(fieldErr),
+				})
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("file has no data rows")
+	}
+	return rows, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerFirst mengubah nama field struct menjadi nama field JSON (IdentityNumber -> identityNumber)
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/xlsx"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeImportService mencatat baris yang diterima service, baris dengan error parsing dilaporkan
+// kembali seperti ImportEmployees asli
+type fakeImportService struct {
+	fakeEmployeeService
+	rows []*models.EmployeeImportRow
+}
+
+func (s *fakeImportService) ImportEmployees(ctx context.Context, userID uint, rows []*models.EmployeeImportRow, dryRun bool) (*models.EmployeeImportResult, error) {
+	s.rows = rows
+	result := &models.EmployeeImportResult{DryRun: dryRun, TotalRows: len(rows), Errors: []models.EmployeeImportError{}}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			result.Errors = append(result.Errors, row.Errors...)
+			continue
+		}
+		result.ValidRows++
+	}
+	if len(result.Errors) == 0 && !dryRun {
+		result.Imported = len(rows)
+	}
+	return result, nil
+}
+
+func testXLSX(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := xlsx.NewWriter(&buf, "Employees")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImportEmployees(t *testing.T) {
+	header := "identityNumber,name,gender,departmentId,jobTitle\n"
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		fields   map[string]string
+		query    string
+		status   int
+		wantRows []int  // Nomor baris yang diteruskan ke service
+		wantErr  string // Error request untuk status 400
+	}{
+		{
+			name:     "csv",
+			filename: "employees.csv",
+			content:  []byte(header + "1234567,Budi Santoso,male,ENG-0001,Engineer\n\n7654321,Siti Aminah,female,ENG-0002,\n"),
+			status:   fiber.StatusCreated,
+			wantRows: []int{2, 4},
+		},
+		{
+			name:     "dry run",
+			filename: "employees.csv",
+			content:  []byte(header + "1234567,Budi Santoso,male,ENG-0001,Engineer\n"),
+			query:    "?dryRun=true",
+			status:   fiber.StatusOK,
+			wantRows: []int{2},
+		},
+		{
+			name:     "excel csv with BOM and mapped headers",
+			filename: "export.txt",
+			content:  []byte("\ufeffNIK,Nama,Gender,Dept\n1234567,Budi Santoso,male,ENG-0001\n"),
+			fields: map[string]string{
+				"format":  "csv",
+				"mapping": `{"identityNumber":"nik","name":"NAMA","departmentId":"Dept"}`,
+			},
+			status:   fiber.StatusCreated,
+			wantRows: []int{2},
+		},
+		{
+			name:     "xlsx",
+			filename: "employees.xlsx",
+			content: testXLSX(t, [][]string{
+				{"identityNumber", "name", "gender", "departmentId"},
+				{"1234567", "Budi Santoso", "male", "ENG-0001"},
+			}),
+			status:   fiber.StatusCreated,
+			wantRows: []int{2},
+		},
+		{
+			name:     "invalid rows are reported with their row number",
+			filename: "employees.csv",
+			content:  []byte(header + "1234567,Budi Santoso,male,ENG-0001,\n7654321,Siti,unknown,ENG-0001,\n"),
+			status:   fiber.StatusUnprocessableEntity,
+			wantRows: []int{2, 3},
+		},
+		{
+			name:     "missing required column",
+			filename: "employees.csv",
+			content:  []byte("identityNumber,name,gender\n1234567,Budi Santoso,male\n"),
+			status:   fiber.StatusBadRequest,
+			wantErr:  `column "departmentId" not found`,
+		},
+		{
+			name:     "unknown mapping field",
+			filename: "employees.csv",
+			content:  []byte(header),
+			fields:   map[string]string{"mapping": `{"salary":"Gaji"}`},
+			status:   fiber.StatusBadRequest,
+			wantErr:  "unknown import field: salary",
+		},
+		{
+			name:     "unsupported format",
+			filename: "employees.json",
+			content:  []byte("[]"),
+			status:   fiber.StatusBadRequest,
+			wantErr:  "unsupported file format, use csv or xlsx",
+		},
+		{
+			name:     "header only",
+			filename: "employees.csv",
+			content:  []byte(header + "\n"),
+			status:   fiber.StatusBadRequest,
+			wantErr:  "file has no data rows",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeImportService{}
+			handler := NewEmployeeHandler(svc)
+			app := newTestApp(func(app *fiber.App) {
+				app.Post("/v1/employee/import", handler.ImportEmployees)
+			})
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", tt.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write(tt.content)
+			for key, value := range tt.fields {
+				form.WriteField(key, value)
+			}
+			form.Close()
+
+			req := httptest.NewRequest(fiber.MethodPost, "/v1/employee/import"+tt.query, &body)
+			req.Header.Set(fiber.HeaderContentType, form.FormDataContentType())
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				data, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+
+			if tt.wantErr != "" {
+				var payload struct {
+					Error string `json:"error"`
+				}
+				json.NewDecoder(resp.Body).Decode(&payload)
+				if payload.Error != tt.wantErr {
+					t.Errorf("error = %q, want %q", payload.Error, tt.wantErr)
+				}
+				if svc.rows != nil {
+					t.Error("service was called for an invalid file")
+				}
+				return
+			}
+
+			if len(svc.rows) != len(tt.wantRows) {
+				t.Fatalf("service got %d rows, want %d", len(svc.rows), len(tt.wantRows))
+			}
+			for i, row := range svc.rows {
+				if row.Row != tt.wantRows[i] {
+					t.Errorf("rows[%d].Row = %d, want %d", i, row.Row, tt.wantRows[i])
+				}
+				if !strings.HasPrefix(row.Request.DepartmentId, "ENG-") || row.Request.IdentityNumber == "" {
+					t.Errorf("rows[%d] = %+v, want identity number and department from the file", i, row.Request)
+				}
+			}
+		})
+	}
+}
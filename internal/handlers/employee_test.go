@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeEmployeeService menyimpan employee di memori, method yang tidak dipakai test panic
+// lewat interface yang di-embed
+type fakeEmployeeService struct {
+	service.EmployeeService
+	employees map[string]*models.EmployeeResponse
+}
+
+func (s *fakeEmployeeService) GetEmployee(ctx context.Context, identityNumber string) (*models.EmployeeResponse, error) {
+	employee, ok := s.employees[identityNumber]
+	if !ok {
+		return nil, errors.New("employee not found")
+	}
+	copied := *employee
+	return &copied, nil
+}
+
+func (s *fakeEmployeeService) ListEmployees(ctx context.Context, filter *models.EmployeeFilter) ([]*models.EmployeeResponse, error) {
+	var employees []*models.EmployeeResponse
+	for _, employee := range s.employees {
+		copied := *employee
+		employees = append(employees, &copied)
+	}
+	return employees, nil
+}
+
+func (s *fakeEmployeeService) UpdateEmployee(ctx context.Context, userID uint, identityNumber string, req *models.UpdateEmployeeRequest, ifMatch string) (*models.EmployeeResponse, error) {
+	employee, ok := s.employees[identityNumber]
+	if !ok {
+		return nil, errors.New("employee not found")
+	}
+	if !models.MatchesIfMatch(ifMatch, employee.Version) {
+		return nil, errors.New("precondition failed")
+	}
+	employee.Name = req.Name
+	employee.Version++
+	copied := *employee
+	return &copied, nil
+}
+
+func (s *fakeEmployeeService) DeleteEmployee(ctx context.Context, userID uint, identityNumber string, ifMatch string) error {
+	employee, ok := s.employees[identityNumber]
+	if !ok {
+		return errors.New("employee not found")
+	}
+	if !models.MatchesIfMatch(ifMatch, employee.Version) {
+		return errors.New("precondition failed")
+	}
+	delete(s.employees, identityNumber)
+	return nil
+}
+
+// newTestApp membuat app dengan user login tetap, pengganti middleware auth
+func newTestApp(register func(app *fiber.App)) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", uint(1))
+		return c.Next()
+	})
+	register(app)
+	return app
+}
+
+func doRequest(t *testing.T, app *fiber.App, method, target, body string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	return resp
+}
+
+func newEmployeeTestApp() (*fiber.App, *fakeEmployeeService) {
+	svc := &fakeEmployeeService{
+		employees: map[string]*models.EmployeeResponse{
+			"1234567": {
+				IdentityNumber: "1234567",
+				Name:           "Budi Santoso",
+				Gender:         "male",
+				DepartmentID:   "ENG-0001",
+				Version:        3,
+			},
+		},
+	}
+	h := NewEmployeeHandler(svc)
+	app := newTestApp(func(app *fiber.App) {
+		app.Get("/v1/employee", h.ListEmployees)
+		app.Get("/v1/employee/:identityNumber", h.GetEmployee)
+		app.Patch("/v1/employee/:identityNumber", h.UpdateEmployee)
+		app.Delete("/v1/employee/:identityNumber", h.DeleteEmployee)
+	})
+	return app, svc
+}
+
+func TestEmployeeListGetThenPatchWithETag(t *testing.T) {
+	app, _ := newEmployeeTestApp()
+
+	resp := doRequest(t, app, fiber.MethodGet, "/v1/employee", "", nil)
+	var list []models.EmployeeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil || len(list) != 1 {
+		t.Fatalf("list = %v, %v, want one employee", list, err)
+	}
+
+	// ETag diambil dari GET per employee, lalu dipakai untuk If-Match
+	resp = doRequest(t, app, fiber.MethodGet, "/v1/employee/"+list[0].IdentityNumber, "", nil)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET status = %d, want 200", resp.StatusCode)
+	}
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if etag != `"3"` {
+		t.Fatalf("ETag = %s, want \"3\"", etag)
+	}
+
+	resp = doRequest(t, app, fiber.MethodGet, "/v1/employee/1234567", "", map[string]string{fiber.HeaderIfNoneMatch: etag})
+	if resp.StatusCode != fiber.StatusNotModified {
+		t.Errorf("conditional GET status = %d, want 304", resp.StatusCode)
+	}
+
+	resp = doRequest(t, app, fiber.MethodPatch, "/v1/employee/1234567", `{"name":"Budi Setiawan"}`, map[string]string{fiber.HeaderIfMatch: etag})
+	if resp.StatusCode != fiber.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("PATCH status = %d, want 200: %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get(fiber.HeaderETag); got != `"4"` {
+		t.Errorf("PATCH ETag = %s, want \"4\"", got)
+	}
+
+	// ETag lama sudah tidak berlaku
+	resp = doRequest(t, app, fiber.MethodPatch, "/v1/employee/1234567", `{"name":"Budi Lagi"}`, map[string]string{fiber.HeaderIfMatch: etag})
+	if resp.StatusCode != fiber.StatusPreconditionFailed {
+		t.Errorf("stale PATCH status = %d, want 412", resp.StatusCode)
+	}
+}
+
+func TestEmployeeWritesRequireIfMatch(t *testing.T) {
+	app, svc := newEmployeeTestApp()
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		headers map[string]string
+		want    int
+	}{
+		{"patch without If-Match", fiber.MethodPatch, `{"name":"Budi Setiawan"}`, nil, fiber.StatusPreconditionRequired},
+		{"delete without If-Match", fiber.MethodDelete, "", nil, fiber.StatusPreconditionRequired},
+		{"patch with weak ETag", fiber.MethodPatch, `{"name":"Budi Setiawan"}`, map[string]string{fiber.HeaderIfMatch: `W/"3"`}, fiber.StatusPreconditionFailed},
+		{"delete with current ETag", fiber.MethodDelete, "", map[string]string{fiber.HeaderIfMatch: `"3"`}, fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, tt.method, "/v1/employee/1234567", tt.body, tt.headers)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	if _, ok := svc.employees["1234567"]; ok {
+		t.Errorf("employee still exists after DELETE with current ETag")
+	}
+}
+
+func TestGetEmployeeNotFound(t *testing.T) {
+	app, _ := newEmployeeTestApp()
+
+	resp := doRequest(t, app, fiber.MethodGet, "/v1/employee/99999", "", nil)
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/xlsx"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"log"
+	"time"
+)
+
+// Format export yang didukung dan content type-nya
+const (
+	exportFormatCSV    = "csv"
+	exportFormatXLSX   = "xlsx"
+	exportFormatNDJSON = "ndjson"
+
+	mimeCSV    = "text/csv"
+	mimeXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+var exportContentTypes = map[string]string{
+	exportFormatCSV:    mimeCSV,
+	exportFormatXLSX:   mimeXLSX,
+	exportFormatNDJSON: mimeNDJSON,
+}
+
+// Jumlah baris sebelum buffer dikirim ke client, supaya koneksi tidak idle lama
+const exportFlushEvery = 500
+
+// exportEncoder menulis satu record: NDJSON memakai record apa adanya, CSV dan XLSX memakai values
+type exportEncoder interface {
+	Encode(record interface{}, values []string) error
+	Flush() error // Kirim data yang masih di buffer encoder ke writer tujuan
+	Close() error
+}
+
+// exportFormat memilih format dari query parameter format, jika tidak ada dari header Accept.
+// Tanpa keduanya default CSV.
+func exportFormat(c *fiber.Ctx) (string, error) {
+	if format := c.Query("format"); format != "" {
+		if _, ok := exportContentTypes[format]; !ok {
+			return "", fmt.Errorf("format must be one of: %s, %s, %s", exportFormatCSV, exportFormatXLSX, exportFormatNDJSON)
+		}
+		return format, nil
+	}
+
+	switch c.Accepts(mimeCSV, mimeXLSX, mimeNDJSON) {
+	case mimeCSV:
+		return exportFormatCSV, nil
+	case mimeXLSX:
+		return exportFormatXLSX, nil
+	case mimeNDJSON:
+		return exportFormatNDJSON, nil
+	default:
+		return "", fiber.ErrNotAcceptable
+	}
+}
+
+// exportErrorResponse untuk error dari exportFormat
+func exportErrorResponse(c *fiber.Ctx, err error) error {
+	if err == fiber.ErrNotAcceptable {
+		return c.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{
+			"error": fmt.Sprintf("supported formats: %s, %s, %s", mimeCSV, mimeXLSX, mimeNDJSON),
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// streamExport mengirim response secara streaming (chunked). write dijalankan setelah handler
+// selesai, jadi error di tengah export hanya bisa dicatat di log dan response terpotong.
+func streamExport(c *fiber.Ctx, format, name string, columns []string, write func(enc exportEncoder) error) error {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), format)
+
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Status(fiber.StatusOK)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		enc, err := newExportEncoder(format, w, name, columns)
+		if err != nil {
+			log.Printf("failed to start %s export: %v\n", name, err)
+			return
+		}
+		if err := write(&flushingEncoder{exportEncoder: enc, w: w}); err != nil {
+			log.Printf("failed to export %s: %v\n", name, err)
+			return
+		}
+		if err := enc.Close(); err != nil {
+			log.Printf("failed to finish %s export: %v\n", name, err)
+			return
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+func newExportEncoder(format string, w io.Writer, sheetName string, columns []string) (exportEncoder, error) {
+	switch format {
+	case exportFormatXLSX:
+		xw, err := xlsx.NewWriter(w, sheetName)
+		if err != nil {
+			return nil, err
+		}
+		if err := xw.WriteRow(columns); err != nil {
+			return nil, err
+		}
+		return &xlsxEncoder{w: xw}, nil
+	case exportFormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	default:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(record interface{}, values []string) error {
+	return e.w.Write(values)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Flush()
+}
+
+type xlsxEncoder struct {
+	w *xlsx.Writer
+}
+
+func (e *xlsxEncoder) Encode(record interface{}, values []string) error {
+	return e.w.WriteRow(values)
+}
+
+// Flush tidak melakukan apa-apa, isi zip baru bisa dikirim setelah dikompres
+func (e *xlsxEncoder) Flush() error {
+	return nil
+}
+
+func (e *xlsxEncoder) Close() error {
+	return e.w.Close()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+// Encode menulis satu object JSON per baris, json.Encoder sudah menambahkan newline
+func (e *ndjsonEncoder) Encode(record interface{}, values []string) error {
+	return e.enc.Encode(record)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// flushingEncoder mengirim buffer ke client setiap exportFlushEvery baris. Error flush berarti
+// client sudah memutus koneksi, export dihentikan.
+type flushingEncoder struct {
+	exportEncoder
+	w     *bufio.Writer
+	count int
+}
+
+func (e *flushingEncoder) Encode(record interface{}, values []string) error {
+	if err := e.exportEncoder.Encode(record, values); err != nil {
+		return err
+	}
+	e.count++
+	if e.count%exportFlushEvery != 0 {
+		return nil
+	}
+	if err := e.exportEncoder.Flush(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
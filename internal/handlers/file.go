@@ -4,6 +4,10 @@ import (
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
 	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 )
 
 type FileHandler struct {
@@ -38,8 +42,8 @@ func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
 		})
 	}
 
-	// Upload file
-	response, err := h.fileService.UploadFile(c.Context(), file, userID, userProfile.Email)
+	// Upload file, purpose menentukan batasan tipe dan ukuran
+	response, err := h.fileService.UploadFile(c.Context(), file, c.FormValue("purpose"), userID, userProfile.Email)
 	if err != nil {
 		// Check if it's a validation error
 		if validationErrors, ok := err.(models.FileValidationErrors); ok {
@@ -47,6 +51,16 @@ func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
 				"errors": validationErrors,
 			})
 		}
+		if status, ok := quotaExceededStatus(err); ok {
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err.Error() == "malware scanner unavailable" {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to upload file",
 		})
@@ -54,3 +68,290 @@ func (h *FileHandler) UploadFile(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+// CreateUpload membuat presigned URL untuk upload langsung ke storage
+func (h *FileHandler) CreateUpload(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	var req models.CreateUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+
+	// Get user profile for email
+	userProfile, err := h.profileService.GetProfile(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get user profile",
+		})
+	}
+
+	response, err := h.fileService.CreateUpload(c.Context(), userID, userProfile.Email, &req)
+	if err != nil {
+		if validationErrors, ok := err.(models.FileValidationErrors); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": validationErrors,
+			})
+		}
+		if status, ok := quotaExceededStatus(err); ok {
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create upload",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// CompleteUpload memverifikasi object hasil presigned upload dan mencatatnya
+func (h *FileHandler) CompleteUpload(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	fileID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid upload ID",
+		})
+	}
+
+	response, err := h.fileService.CompleteUpload(c.Context(), userID, uint(fileID))
+	if err != nil {
+		if validationErrors, ok := err.(models.FileValidationErrors); ok {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"errors": validationErrors,
+			})
+		}
+		return fileErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *FileHandler) ListFiles(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	filter := &models.FileFilter{
+		Limit:  5, // default limit
+		Offset: 0, // default offset
+	}
+
+	// Parse query parameters
+	if limit := c.Query("limit"); limit != "" {
+		if val, err := strconv.Atoi(limit); err == nil && val > 0 {
+			filter.Limit = val
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if val, err := strconv.Atoi(offset); err == nil && val >= 0 {
+			filter.Offset = val
+		}
+	}
+
+	files, err := h.fileService.ListFiles(c.Context(), userID, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch files",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(files)
+}
+
+func (h *FileHandler) GetFile(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	fileID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID",
+		})
+	}
+
+	file, err := h.fileService.GetFile(c.Context(), userID, uint(fileID))
+	if err != nil {
+		return fileErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(file)
+}
+
+func (h *FileHandler) DeleteFile(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	fileID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID",
+		})
+	}
+
+	if err := h.fileService.DeleteFile(c.Context(), userID, uint(fileID)); err != nil {
+		return fileErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "File deleted successfully",
+	})
+}
+
+// FileContent redirect dari link stabil /v1/file/:id/content ke URL storage saat ini
+func (h *FileHandler) FileContent(c *fiber.Ctx) error {
+	fileID, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID",
+		})
+	}
+
+	target, err := h.fileService.ResolveContentURL(c.Context(), uint(fileID), c.Query("variant"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid signature", "signature expired":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return fileErrorResponse(c, err)
+	}
+
+	// URL tujuan bisa berupa signed URL berumur pendek, jangan di-cache lama
+	c.Set(fiber.HeaderCacheControl, "private, max-age=60")
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// fileErrorResponse memetakan error dari FileService ke HTTP status
+func fileErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "file not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "unauthorized access to file":
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "upload already completed", "file is still referenced":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "upload expired":
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "uploaded object not found", "uploaded object does not match":
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case "malware scanner unavailable":
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+}
+
+// ServeFile menyajikan file dari backend local/memory lewat URL yang di-sign
+func (h *FileHandler) ServeFile(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil || key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file path",
+		})
+	}
+
+	body, info, err := h.fileService.OpenSignedFile(c.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid signature", "signature expired":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "file not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to read file",
+			})
+		}
+	}
+
+	if info.ContentType != "" {
+		c.Set(fiber.HeaderContentType, info.ContentType)
+	}
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	c.Set(fiber.HeaderLastModified, info.LastModified.UTC().Format(http.TimeFormat))
+
+	// Body ditutup otomatis oleh fasthttp setelah selesai dikirim
+	return c.SendStream(body, int(info.Size))
+}
+
+// UploadSignedFile menerima upload dari presigned URL backend local/memory
+func (h *FileHandler) UploadSignedFile(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil || key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file path",
+		})
+	}
+
+	// Content-Type request harus sama dengan yang di-sign
+	contentType := c.Query("type")
+	if c.Get(fiber.HeaderContentType) != contentType {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Content-Type does not match signed upload",
+		})
+	}
+
+	// Body dibaca streaming, ukurannya dari Content-Length dan harus sama dengan size yang di-sign
+	contentLength := c.Request().Header.ContentLength()
+	if contentLength < 0 {
+		return c.Status(fiber.StatusLengthRequired).JSON(fiber.Map{
+			"error": "Content-Length is required",
+		})
+	}
+
+	err = h.fileService.WriteSignedFile(c.Context(), key, c.Query("expires"), c.Query("size"), contentType, c.Query("signature"), io.LimitReader(requestBody(c), int64(contentLength)), int64(contentLength))
+	if err != nil {
+		switch err.Error() {
+		case "invalid signature", "signature expired":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "uploaded object does not match":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "file not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "upload already completed":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to upload file",
+			})
+		}
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"strings"
+)
+
+// Format org chart selain JSON, untuk dirender di wiki
+const (
+	orgChartFormatJSON    = "json"
+	orgChartFormatDOT     = "dot"
+	orgChartFormatMermaid = "mermaid"
+
+	mimeGraphviz = "text/vnd.graphviz"
+	mimeMermaid  = "text/vnd.mermaid"
+)
+
+// ListReports mengembalikan bawahan employee (GET /v1/employee/:identityNumber/reports).
+// Query depth membatasi jumlah tingkat, 1 = bawahan langsung, 0 atau kosong = semua tingkat.
+func (h *EmployeeHandler) ListReports(c *fiber.Ctx) error {
+	identityNumber := c.Params("identityNumber")
+	if identityNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Identity number is required",
+		})
+	}
+
+	depth := 0
+	if val := c.Query("depth"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "depth must be a non-negative integer",
+			})
+		}
+		depth = parsed
+	}
+
+	reports, err := h.employeeService.ListReports(c.Context(), identityNumber, depth)
+	if err != nil {
+		if err.Error() == "employee not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(reports)
+}
+
+// OrgChart mengembalikan struktur organisasi (GET /v1/orgchart) sebagai JSON tree,
+// Graphviz DOT (format=dot) atau Mermaid flowchart (format=mermaid)
+func (h *EmployeeHandler) OrgChart(c *fiber.Ctx) error {
+	format := c.Query("format", orgChartFormatJSON)
+	if format != orgChartFormatJSON && format != orgChartFormatDOT && format != orgChartFormatMermaid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be one of: json, dot, mermaid",
+		})
+	}
+
+	filter := &models.OrgChartFilter{
+		Root:         c.Query("root"),
+		DepartmentID: c.Query("departmentId"),
+		OwnerID:      c.Locals("userID").(uint),
+	}
+
+	roots, err := h.employeeService.OrgChart(c.Context(), filter)
+	if err != nil {
+		if err.Error() == "employee not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	switch format {
+	case orgChartFormatDOT:
+		c.Set(fiber.HeaderContentType, mimeGraphviz+"; charset=utf-8")
+		return c.Status(fiber.StatusOK).SendString(renderOrgChartDOT(roots))
+	case orgChartFormatMermaid:
+		c.Set(fiber.HeaderContentType, mimeMermaid+"; charset=utf-8")
+		return c.Status(fiber.StatusOK).SendString(renderOrgChartMermaid(roots))
+	default:
+		return c.Status(fiber.StatusOK).JSON(roots)
+	}
+}
+
+// renderOrgChartDOT membuat digraph Graphviz, node memakai identity number sebagai ID
+func renderOrgChartDOT(roots []*models.OrgChartNode) string {
+	var b strings.Builder
+	b.WriteString("digraph orgchart {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	walkOrgChart(roots, func(node, manager *models.OrgChartNode) {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", dotQuote(node.IdentityNumber), dotQuote(orgChartLabel(node, "\n", nil)))
+		if manager != nil {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(manager.IdentityNumber), dotQuote(node.IdentityNumber))
+		}
+	})
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// renderOrgChartMermaid membuat flowchart Mermaid. ID node Mermaid hanya boleh alfanumerik,
+// jadi dipakai nomor urut dan identity number masuk ke label.
+func renderOrgChartMermaid(roots []*models.OrgChartNode) string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+
+	ids := make(map[*models.OrgChartNode]string)
+	walkOrgChart(roots, func(node, manager *models.OrgChartNode) {
+		id := "e" + strconv.Itoa(len(ids)+1)
+		ids[node] = id
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, orgChartLabel(node, "<br/>", mermaidEscape))
+		if manager != nil {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[manager], id)
+		}
+	})
+
+	return b.String()
+}
+
+// walkOrgChart mengunjungi node secara depth-first, atasan selalu dikunjungi sebelum bawahannya
+func walkOrgChart(roots []*models.OrgChartNode, visit func(node, manager *models.OrgChartNode)) {
+	var walk func(node, manager *models.OrgChartNode)
+	walk = func(node, manager *models.OrgChartNode) {
+		visit(node, manager)
+		for _, report := range node.Reports {
+			walk(report, node)
+		}
+	}
+	for _, root := range roots {
+		walk(root, nil)
+	}
+}
+
+// orgChartLabel berisi nama, identity number dan jabatan, escape diterapkan per bagian
+// supaya pemisah baris tidak ikut di-escape
+func orgChartLabel(node *models.OrgChartNode, lineBreak string, escape func(string) string) string {
+	parts := []string{node.Name, node.IdentityNumber}
+	if node.JobTitle != "" {
+		parts = append(parts, node.JobTitle)
+	}
+	if escape != nil {
+		for i := range parts {
+			parts[i] = escape(parts[i])
+		}
+	}
+	return strings.Join(parts, lineBreak)
+}
+
+// dotQuote membuat string DOT yang di-quote, newline menjadi \n
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// mermaidEscape mengganti karakter yang merusak label Mermaid dengan entity code
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/jsonpatch"
+	"github.com/gofiber/fiber/v2"
+	"reflect"
+	"strings"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+var errUnsupportedPatchType = errors.New("unsupported patch media type")
+
+// applyPatch menerapkan body PATCH ke resource saat ini lalu men-decode hasilnya ke target.
+// Mendukung JSON Merge Patch (RFC 7396, default untuk application/json) dan
+// JSON Patch (RFC 6902) via Content-Type application/json-patch+json.
+// Return value berisi nama field struct target yang disentuh oleh patch.
+func applyPatch(c *fiber.Ctx, current interface{}, target interface{}) ([]string, error) {
+	original, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		patched []byte
+		fields  []string
+	)
+
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0]))
+	switch contentType {
+	case mimeJSONPatch:
+		ops, err := jsonpatch.DecodePatch(c.Body())
+		if err != nil {
+			return nil, err
+		}
+		if patched, err = jsonpatch.Apply(original, ops); err != nil {
+			return nil, err
+		}
+		fields = jsonpatch.PatchFields(ops)
+	case mimeMergePatch, fiber.MIMEApplicationJSON, "":
+		if fields, err = jsonpatch.MergePatchFields(c.Body()); err != nil {
+			return nil, err
+		}
+		if patched, err = jsonpatch.MergePatch(original, c.Body()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errUnsupportedPatchType
+	}
+
+	if err := json.Unmarshal(patched, target); err != nil {
+		return nil, err
+	}
+
+	return structFieldNames(target, fields), nil
+}
+
+// structFieldNames memetakan nama field JSON ke nama field struct untuk validate.StructPartial
+func structFieldNames(target interface{}, jsonFields []string) []string {
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	byJSONName := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		byJSONName[name] = field.Name
+	}
+
+	var names []string
+	for _, jsonField := range jsonFields {
+		if name, ok := byJSONName[jsonField]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// patchErrorResponse mengirim response error yang sesuai untuk kegagalan applyPatch
+func patchErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errUnsupportedPatchType) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Content-Type must be application/merge-patch+json or application/json-patch+json",
+		})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": "Invalid patch document",
+	})
+}
+
+// preconditionRequired menolak PUT/PATCH/DELETE tanpa If-Match (428) supaya client tidak
+// menimpa perubahan orang lain tanpa sadar
+func preconditionRequired(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{
+		"error": "If-Match header is required",
+	})
+}
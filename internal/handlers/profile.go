@@ -29,6 +29,12 @@ func (h *ProfileHandler) GetProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	// Conditional GET dengan If-None-Match
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && models.MatchesIfNoneMatch(match, user.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderETag, models.ETag(user.Version))
 	return c.Status(fiber.StatusOK).JSON(user.ToProfileResponse())
 }
 
@@ -36,28 +42,49 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 	// Get userID from context (set by auth middleware)
 	userID := c.Locals("userID").(uint)
 
-	var req models.UpdateProfileRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
+	// Get current profile, patch diterapkan di atas data ini
+	current, err := h.profileService.GetProfile(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
-	// Validate request
-	if errors := validateUpdateProfileRequest(&req); len(errors) > 0 {
+	// If-Match wajib supaya patch tidak diterapkan di atas versi yang belum dilihat client
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return preconditionRequired(c)
+	}
+
+	var req models.UpdateProfileRequest
+	fields, err := applyPatch(c, current.ToProfileResponse(), &req)
+	if err != nil {
+		return patchErrorResponse(c, err)
+	}
+
+	// Validate request (hanya field yang dikirim)
+	if errors := validateUpdateProfileRequest(&req, fields); len(errors) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"errors": errors,
 		})
 	}
 
 	// Update profile
-	user, err := h.profileService.UpdateProfile(c.Context(), userID, &req)
+	user, err := h.profileService.UpdateProfile(c.Context(), userID, &req, ifMatch)
 	if err != nil {
 		switch err.Error() {
 		case "email is already used by another user":
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": err.Error(),
 			})
+		case "precondition failed":
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "image must be an uploaded file", "image file not found":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		default:
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
@@ -65,13 +92,14 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 		}
 	}
 
+	c.Set(fiber.HeaderETag, models.ETag(user.Version))
 	return c.Status(fiber.StatusOK).JSON(user.ToProfileResponse())
 }
 
-func validateUpdateProfileRequest(req *models.UpdateProfileRequest) []ValidationError {
+func validateUpdateProfileRequest(req *models.UpdateProfileRequest, fields []string) []ValidationError {
 	var validationErrors []ValidationError
 
-	err := validate.Struct(req)
+	err := validate.StructPartial(req, fields...)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
 			var element ValidationError
@@ -88,6 +116,8 @@ func validateUpdateProfileRequest(req *models.UpdateProfileRequest) []Validation
 				element.Message = fmt.Sprintf("%s must not exceed %s characters", err.Field(), err.Param())
 			case "uri":
 				element.Message = fmt.Sprintf("%s must be a valid URI", err.Field())
+			case "gt":
+				element.Message = fmt.Sprintf("%s must be greater than %s", err.Field(), err.Param())
 			}
 
 			validationErrors = append(validationErrors, element)
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+type QuotaHandler struct {
+	quotaService service.QuotaService
+}
+
+func NewQuotaHandler(quotaService service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+	}
+}
+
+// Usage mengembalikan pemakaian dan kuota storage user yang login (GET /v1/file/usage)
+func (h *QuotaHandler) Usage(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uint)
+
+	usage, err := h.quotaService.Usage(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usage)
+}
+
+// GetUserQuota untuk admin (GET /v1/admin/users/:userId/quota)
+func (h *QuotaHandler) GetUserQuota(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	usage, err := h.quotaService.Usage(c.Context(), uint(userID))
+	if err != nil {
+		return quotaErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usage)
+}
+
+// SetUserQuota mengatur override kuota user (PUT /v1/admin/users/:userId/quota)
+func (h *QuotaHandler) SetUserQuota(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req models.UpdateStorageQuotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": formatValidationErrors(err),
+		})
+	}
+
+	usage, err := h.quotaService.SetQuota(c.Context(), uint(userID), &req)
+	if err != nil {
+		return quotaErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usage)
+}
+
+// ResetUserQuota menghapus override, user kembali ke kuota default (DELETE /v1/admin/users/:userId/quota)
+func (h *QuotaHandler) ResetUserQuota(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	usage, err := h.quotaService.ResetQuota(c.Context(), uint(userID))
+	if err != nil {
+		return quotaErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(usage)
+}
+
+func quotaErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "user not found":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+}
+
+// quotaExceededStatus memetakan error kuota dari upload: file lebih besar dari total kuota
+// (413) atau sisa kuota tidak cukup (507)
+func quotaExceededStatus(err error) (int, bool) {
+	switch err.Error() {
+	case "file exceeds storage quota":
+		return fiber.StatusRequestEntityTooLarge, true
+	case "storage quota exceeded":
+		return fiber.StatusInsufficientStorage, true
+	default:
+		return 0, false
+	}
+}
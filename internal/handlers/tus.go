@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Header dan nilai protokol tus 1.0
+const (
+	tusVersion          = "1.0.0"
+	tusExtensions       = "creation,termination,expiration"
+	mimeOffsetOctetStrm = "application/offset+octet-stream"
+)
+
+// Ukuran maksimal satu PATCH, upload yang lebih besar dikirim dalam beberapa chunk
+const maxTusChunkSize = 32 << 20
+
+type TusHandler struct {
+	tusService     service.TusService
+	profileService service.ProfileService
+}
+
+func NewTusHandler(tusService service.TusService, profileService service.ProfileService) *TusHandler {
+	return &TusHandler{
+		tusService:     tusService,
+		profileService: profileService,
+	}
+}
+
+// Options mengembalikan kemampuan server (OPTIONS /v1/file/tus)
+func (h *TusHandler) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(h.tusService.MaxSize(), 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateUpload membuat upload baru (POST /v1/file/tus, extension creation)
+func (h *TusHandler) CreateUpload(c *fiber.Ctx) error {
+	if !checkTusResumable(c) {
+		return unsupportedTusVersion(c)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid Upload-Length header",
+		})
+	}
+
+	metadata, err := parseTusMetadata(c.Get("Upload-Metadata"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid Upload-Metadata header",
+		})
+	}
+
+	// Get user profile for email
+	userProfile, err := h.profileService.GetProfile(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get user profile",
+		})
+	}
+
+	upload, err := h.tusService.CreateUpload(c.Context(), userID, userProfile.Email, &models.TusCreateRequest{
+		Length:   length,
+		Filename: metadata["filename"],
+		FileType: metadata["filetype"],
+		Purpose:  metadata["purpose"],
+	})
+	if err != nil {
+		if validationErrors, ok := err.(models.FileValidationErrors); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": validationErrors,
+			})
+		}
+		return tusErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderLocation, c.BaseURL()+c.Path()+"/"+upload.ID)
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Set("Upload-File-Id", strconv.FormatUint(uint64(upload.FileID), 10))
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// HeadUpload mengembalikan offset saat ini (HEAD /v1/file/tus/:id)
+func (h *TusHandler) HeadUpload(c *fiber.Ctx) error {
+	if !checkTusResumable(c) {
+		return unsupportedTusVersion(c)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	upload, err := h.tusService.GetUpload(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		// Response HEAD tidak punya body
+		return c.SendStatus(tusErrorStatus(err))
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Set("Upload-File-Id", strconv.FormatUint(uint64(upload.FileID), 10))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// PatchUpload menambahkan chunk mulai dari Upload-Offset (PATCH /v1/file/tus/:id)
+func (h *TusHandler) PatchUpload(c *fiber.Ctx) error {
+	if !checkTusResumable(c) {
+		return unsupportedTusVersion(c)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	if !strings.EqualFold(c.Get(fiber.HeaderContentType), mimeOffsetOctetStrm) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Content-Type must be " + mimeOffsetOctetStrm,
+		})
+	}
+
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid Upload-Offset header",
+		})
+	}
+
+	if c.Request().Header.ContentLength() > maxTusChunkSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Chunk must not exceed %d bytes", maxTusChunkSize),
+		})
+	}
+
+	// Body dibaca streaming langsung ke storage. Tanpa Content-Length (chunked) hanya maxTusChunkSize
+	// byte pertama yang diterima, client melanjutkan dari Upload-Offset di response.
+	upload, err := h.tusService.WriteChunk(c.Context(), userID, c.Params("id"), offset, io.LimitReader(requestBody(c), maxTusChunkSize))
+	if err != nil {
+		// File selesai diupload tapi isinya tidak lolos validasi
+		if validationErrors, ok := err.(models.FileValidationErrors); ok {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"errors": validationErrors,
+			})
+		}
+		return tusErrorResponse(c, err)
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Set("Upload-File-Id", strconv.FormatUint(uint64(upload.FileID), 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TerminateUpload membatalkan upload (DELETE /v1/file/tus/:id, extension termination)
+func (h *TusHandler) TerminateUpload(c *fiber.Ctx) error {
+	if !checkTusResumable(c) {
+		return unsupportedTusVersion(c)
+	}
+
+	userID := c.Locals("userID").(uint)
+
+	if err := h.tusService.TerminateUpload(c.Context(), userID, c.Params("id")); err != nil {
+		return tusErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// requestBody mengembalikan body request sebagai stream, atau dari buffer jika server tidak
+// berjalan dengan StreamRequestBody
+func requestBody(c *fiber.Ctx) io.Reader {
+	if stream := c.Context().RequestBodyStream(); stream != nil {
+		return stream
+	}
+	return bytes.NewReader(c.Body())
+}
+
+// checkTusResumable memastikan client memakai versi protokol yang didukung
+func checkTusResumable(c *fiber.Ctx) bool {
+	c.Set("Tus-Resumable", tusVersion)
+	return c.Get("Tus-Resumable") == tusVersion
+}
+
+// unsupportedTusVersion adalah response untuk request dengan Tus-Resumable yang tidak didukung
+func unsupportedTusVersion(c *fiber.Ctx) error {
+	c.Set("Tus-Version", tusVersion)
+	return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+		"error": "Unsupported tus version",
+	})
+}
+
+// parseTusMetadata membaca header Upload-Metadata: "key base64value,key2 base64value2"
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if parts[0] == "" {
+			continue
+		}
+
+		value := ""
+		if len(parts) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			value = string(decoded)
+		}
+		metadata[parts[0]] = value
+	}
+
+	return metadata, nil
+}
+
+func tusErrorStatus(err error) int {
+	switch err.Error() {
+	case "upload not found":
+		return fiber.StatusNotFound
+	case "upload expired":
+		return fiber.StatusGone
+	case "upload offset mismatch", "upload already completed":
+		return fiber.StatusConflict
+	case "upload too large", "file exceeds storage quota":
+		return fiber.StatusRequestEntityTooLarge
+	case "storage quota exceeded":
+		return fiber.StatusInsufficientStorage
+	case "malware scanner unavailable":
+		return fiber.StatusServiceUnavailable
+	case "uploaded object not found", "uploaded object does not match":
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// tusErrorResponse memetakan error dari TusService ke HTTP status
+func tusErrorResponse(c *fiber.Ctx, err error) error {
+	status := tusErrorStatus(err)
+	if status == fiber.StatusInternalServerError {
+		return c.Status(status).JSON(fiber.Map{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,81 @@
+// Package jobs menjalankan pekerjaan background secara berkala di dalam proses API.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job adalah pekerjaan yang dijalankan setiap Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler menjalankan setiap job di goroutine sendiri sampai context dibatalkan.
+// Satu job tidak pernah berjalan paralel dengan dirinya sendiri.
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start menjalankan semua job, masing-masing langsung jalan sekali lalu mengikuti interval
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			log.Printf("job %s skipped: interval must be greater than 0\n", job.Name)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Wait menunggu semua job berhenti setelah context dibatalkan
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	// Panic di satu job tidak boleh mematikan server
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("job %s panicked: %v\n", job.Name, r)
+		}
+	}()
+
+	started := time.Now()
+	if err := job.Run(ctx); err != nil {
+		log.Printf("job %s failed after %s: %v\n", job.Name, time.Since(started), err)
+	}
+}
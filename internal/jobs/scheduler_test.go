@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor menunggu sampai cond terpenuhi atau gagal setelah satu detik
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsJobsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failing, panicking, skipped atomic.Int32
+	scheduler := NewScheduler()
+	scheduler.Add(Job{
+		Name:     "failing",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			failing.Add(1)
+			return errors.New("temporary failure")
+		},
+	})
+	scheduler.Add(Job{
+		Name:     "panicking",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			panicking.Add(1)
+			panic("boom")
+		},
+	})
+	scheduler.Add(Job{
+		Name: "no interval",
+		Run: func(ctx context.Context) error {
+			skipped.Add(1)
+			return nil
+		},
+	})
+	scheduler.Start(ctx)
+
+	// Error dan panic tidak menghentikan job, job tetap dijalankan lagi di interval berikutnya
+	waitFor(t, "jobs to run repeatedly", func() bool {
+		return failing.Load() >= 3 && panicking.Load() >= 3
+	})
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		scheduler.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait() did not return after cancel")
+	}
+
+	if skipped.Load() != 0 {
+		t.Errorf("job without interval ran %d times, want 0", skipped.Load())
+	}
+}
+
+func TestSchedulerDoesNotOverlapJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var running, overlapped, runs atomic.Int32
+	scheduler := NewScheduler()
+	scheduler.Add(Job{
+		Name:     "slow",
+		Interval: time.Millisecond,
+		Run: func(ctx context.Context) error {
+			if running.Add(1) > 1 {
+				overlapped.Add(1)
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			runs.Add(1)
+			return nil
+		},
+	})
+	scheduler.Start(ctx)
+
+	// Interval jauh lebih pendek dari durasi job, run berikutnya tetap menunggu run sebelumnya selesai
+	waitFor(t, "slow job to run", func() bool { return runs.Load() >= 3 })
+	cancel()
+	scheduler.Wait()
+
+	if overlapped.Load() > 0 {
+		t.Errorf("job overlapped itself %d times", overlapped.Load())
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminMiddleware struct {
+	apiKey string
+}
+
+func NewAdminMiddleware(apiKey string) *AdminMiddleware {
+	return &AdminMiddleware{
+		apiKey: apiKey,
+	}
+}
+
+// AdminRequired memvalidasi header X-Admin-Key, endpoint admin nonaktif jika api key kosong
+func (m *AdminMiddleware) AdminRequired() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if m.apiKey == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "not found",
+			})
+		}
+
+		key := c.Get("X-Admin-Key")
+		if key == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "missing admin key",
+			})
+		}
+
+		// Bandingkan dengan waktu konstan supaya key tidak bisa ditebak lewat timing
+		if subtle.ConstantTimeCompare([]byte(key), []byte(m.apiKey)) != 1 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "invalid admin key",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminRequired(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		header string
+		status int
+	}{
+		{name: "admin endpoints disabled", apiKey: "", header: "anything", status: fiber.StatusNotFound},
+		{name: "missing key", apiKey: "secret", status: fiber.StatusUnauthorized},
+		{name: "wrong key", apiKey: "secret", header: "secreT", status: fiber.StatusForbidden},
+		{name: "key prefix", apiKey: "secret", header: "sec", status: fiber.StatusForbidden},
+		{name: "valid key", apiKey: "secret", header: "secret", status: fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/admin", NewAdminMiddleware(tt.apiKey).AdminRequired(), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Admin-Key", tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"strings"
+)
+
+// StreamedRoute adalah route yang membaca body request sendiri secara streaming dan tidak dibatasi BodyLimit
+type StreamedRoute struct {
+	Method     string
+	PathPrefix string
+}
+
+// BodyLimit menolak request dengan body lebih dari limit byte. Server berjalan dengan StreamRequestBody
+// supaya route upload bisa membaca body tanpa menampungnya di memori, akibatnya fiber.Config.BodyLimit
+// hanya menjadi ukuran buffer awal dan body yang lebih besar tetap diteruskan. Batas untuk route
+// lain (auth, JSON, form upload) ditegakkan di sini.
+func BodyLimit(limit int, streamed ...StreamedRoute) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, route := range streamed {
+			if c.Method() == route.Method && strings.HasPrefix(c.Path(), route.PathPrefix) {
+				return c.Next()
+			}
+		}
+
+		req := c.Request()
+		if req.Header.ContentLength() > limit {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error": "Request body too large",
+			})
+		}
+
+		// Chunked transfer encoding: panjang body belum diketahui, baca maksimal limit+1 byte
+		if req.Header.ContentLength() < 0 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Failed to read request body",
+				})
+			}
+			if len(body) > limit {
+				// Sisa body tidak dibaca, koneksi ditutup supaya sisanya tidak diparse sebagai request berikutnya
+				c.Context().SetConnectionClose()
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+					"error": "Request body too large",
+				})
+			}
+			req.SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// unsizedReader menyembunyikan panjang body supaya request dikirim dengan chunked transfer encoding
+type unsizedReader struct {
+	io.Reader
+}
+
+func TestBodyLimit(t *testing.T) {
+	const limit = 16
+
+	app := fiber.New(fiber.Config{StreamRequestBody: true})
+	app.Use(BodyLimit(limit, StreamedRoute{Method: fiber.MethodPatch, PathPrefix: "/upload/"}))
+	echo := func(c *fiber.Ctx) error {
+		body, err := io.ReadAll(requestBodyReader(c))
+		if err != nil {
+			return err
+		}
+		return c.Send(body)
+	}
+	app.Post("/json", echo)
+	app.Patch("/upload/:id", echo)
+
+	small := strings.Repeat("a", limit)
+	large := strings.Repeat("b", 4*limit)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{name: "within limit", method: fiber.MethodPost, target: "/json", body: small, status: fiber.StatusOK},
+		{name: "content length over limit", method: fiber.MethodPost, target: "/json", body: large, status: fiber.StatusRequestEntityTooLarge},
+		{name: "chunked within limit", method: fiber.MethodPost, target: "/json", body: small, chunked: true, status: fiber.StatusOK},
+		{name: "chunked over limit", method: fiber.MethodPost, target: "/json", body: large, chunked: true, status: fiber.StatusRequestEntityTooLarge},
+		{name: "streamed route", method: fiber.MethodPatch, target: "/upload/1", body: large, status: fiber.StatusOK},
+		{name: "streamed prefix with other method", method: fiber.MethodPost, target: "/upload/1", body: large, status: fiber.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				body = unsizedReader{body}
+			}
+			req := httptest.NewRequest(tt.method, tt.target, body)
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status == fiber.StatusOK {
+				got, _ := io.ReadAll(resp.Body)
+				if string(got) != tt.body {
+					t.Errorf("handler read %d bytes, want %d", len(got), len(tt.body))
+				}
+			}
+		})
+	}
+}
+
+// requestBodyReader membaca body dari stream jika ada, seperti handler upload
+func requestBodyReader(c *fiber.Ctx) io.Reader {
+	if stream := c.Context().RequestBodyStream(); stream != nil {
+		return stream
+	}
+	return bytes.NewReader(c.Body())
+}
@@ -1,68 +1,193 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"gorm.io/gorm"
 	"time"
 )
 
+// Panjang maksimal kolom department_id
+const maxDepartmentIDLength = 32
+
 type Department struct {
-	ID           uint           `gorm:"primaryKey" json:"-"`                                                               // ID untuk auto increment
-	DepartmentID string         `gorm:"size:10;not null;uniqueIndex:idx_department_id,where:deleted_at IS NULL" json:"id"` // Format: DEP-XX
-	UserID       uint           `gorm:"not null" json:"-"`                                                                 // FK ke User
-	Name         string         `gorm:"size:33;not null" json:"name"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uint            `gorm:"primaryKey" json:"-"`                                                                                           // ID untuk auto increment
+	DepartmentID       string          `gorm:"size:32;not null;uniqueIndex:idx_departments_user_department_id,priority:2,where:deleted_at IS NULL" json:"id"` // Format: DEP-XX, unik per tenant (lihat DepartmentIDFormat)
+	UserID             uint            `gorm:"not null;uniqueIndex:idx_departments_user_department_id,priority:1" json:"-"`                                   // FK ke User, tenant pemilik department
+	Name               string          `gorm:"size:33;not null" json:"name"`
+	Version            uint            `gorm:"not null;default:1" json:"-"` // Naik setiap update, dipakai untuk ETag
+	ArchivedAt         *time.Time      `gorm:"index" json:"-"`              // Diarsipkan: tidak muncul di pilihan, employee dan histori tetap ada
+	ParentID           *uint           `gorm:"index" json:"-"`              // Department induk (FK ke Department.ID), nil untuk level teratas
+	ParentDepartmentID string          `gorm:"->;-:migration" json:"-"`     // Dibaca lewat subquery (lihat repository), kosong jika induk sudah dihapus
+	Heads              DepartmentHeads `gorm:"->;-:migration" json:"-"`     // Identity number kepala department, dibaca lewat subquery
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt  `gorm:"index" json:"-"`
 
 	// Relations
 	User      User       `gorm:"foreignKey:UserID" json:"-"`
-	Employees []Employee `gorm:"foreignKey:DepartmentID;references:DepartmentID" json:"-"`
+	Employees []Employee `gorm:"foreignKey:OwnerID,DepartmentID;references:UserID,DepartmentID" json:"-"`
 }
 
 type DepartmentResponse struct {
-	DepartmentID string `json:"departmentId"`
-	Name         string `json:"name"`
+	DepartmentID       string     `json:"departmentId"`
+	Name               string     `json:"name"`
+	ParentDepartmentID string     `json:"parentDepartmentId"`
+	Heads              []string   `json:"heads"` // Identity number employee kepala department
+	ArchivedAt         *time.Time `json:"archivedAt,omitempty"`
+	Version            uint       `json:"-"` // Dikirim lewat header ETag
+}
+
+// DepartmentHeads adalah daftar identity number kepala department, dibaca dari subquery JSON
+type DepartmentHeads []string
+
+func (h DepartmentHeads) Value() (driver.Value, error) {
+	if h == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (h *DepartmentHeads) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*h = DepartmentHeads{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported department heads type %T", value)
+	}
+	return json.Unmarshal(data, h)
 }
 
+// DepartmentHead menandai employee sebagai kepala department. Kepala boleh mengubah dan menghapus
+// employee di department tersebut beserta semua sub-department-nya.
+type DepartmentHead struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	DepartmentID uint      `gorm:"not null" json:"-"` // FK ke Department.ID
+	EmployeeID   uint      `gorm:"not null" json:"-"` // FK ke Employee.ID
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SetDepartmentHeadsRequest untuk PUT /v1/department/:departmentId/heads, list kosong menghapus semua kepala
+type SetDepartmentHeadsRequest struct {
+	Heads []string `json:"heads" validate:"max=10,dive,min=5,max=33"`
+}
+
+// TrashedDepartmentResponse untuk GET /v1/department/trash
+type TrashedDepartmentResponse struct {
+	DepartmentResponse
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// DepartmentTreeNode untuk GET /v1/department/tree
+type DepartmentTreeNode struct {
+	DepartmentID   string                `json:"departmentId"`
+	Name           string                `json:"name"`
+	ArchivedAt     *time.Time            `json:"archivedAt,omitempty"`
+	Subdepartments []*DepartmentTreeNode `json:"subdepartments"`
+}
+
+// Nilai DepartmentFilter.Status
+const (
+	DepartmentStatusActive   = "active"   // Default, department yang bisa dipilih
+	DepartmentStatusArchived = "archived" // Hanya department yang diarsipkan
+	DepartmentStatusAll      = "all"
+)
+
 type DepartmentFilter struct {
 	Limit  int    `query:"limit"`
 	Offset int    `query:"offset"`
 	Name   string `query:"name"`
+	Status string `query:"status"` // active, archived atau all
+}
+
+// DeleteDepartmentOptions mengatur nasib employee saat department dihapus. Tanpa opsi,
+// department yang masih punya employee tidak bisa dihapus.
+type DeleteDepartmentOptions struct {
+	ReassignTo string // Pindahkan semua employee ke department ini
+	Cascade    bool   // Ikut hapus (soft delete) semua employee
+}
+
+// DeleteDepartmentReport adalah hasil DELETE /v1/department/:departmentId
+type DeleteDepartmentReport struct {
+	DepartmentID        string   `json:"departmentId"`
+	ReassignedTo        string   `json:"reassignedTo,omitempty"`
+	ReassignedEmployees []string `json:"reassignedEmployees"` // Identity number employee yang dipindahkan
+	DeletedEmployees    []string `json:"deletedEmployees"`    // Identity number employee yang masuk trash
 }
 
 // Request structs
 type CreateDepartmentRequest struct {
-	Name string `json:"name" validate:"required,min=4,max=33"`
+	Name               string `json:"name" validate:"required,min=4,max=33"`
+	ParentDepartmentId string `json:"parentDepartmentId" validate:"omitempty,max=32"` // Kosong = level teratas
 }
 
+// UpdateDepartmentRequest adalah hasil merge patch, validasi hanya untuk field yang dikirim
 type UpdateDepartmentRequest struct {
-	Name string `json:"name" validate:"required,min=4,max=33"`
+	Name               string `json:"name" validate:"required,min=4,max=33"`
+	ParentDepartmentId string `json:"parentDepartmentId" validate:"omitempty,max=32"`
 }
 
-// Hook untuk generate DepartmentID
-func (d *Department) BeforeCreate(tx *gorm.DB) error {
-	// Generate ID baru
-	var lastID uint
-	err := tx.Model(&Department{}).
-		Order("id DESC").
-		Limit(1).
-		Pluck("id", &lastID).Error
-	if err != nil {
-		return err
-	}
+// DepartmentIDFormat mengatur format DepartmentID. Nomor urut dialokasikan per tenant (user pemilik
+// department), contoh Prefix "ENG-" dan Padding 4 menghasilkan ENG-0001, ENG-0002, dst. ID yang sama
+// bisa dipakai tenant lain, department_id hanya unik bersama user_id.
+type DepartmentIDFormat struct {
+	Prefix  string
+	Padding int
+}
 
-	// ID baru adalah lastID + 1
-	newID := lastID + 1
-	d.DepartmentID = fmt.Sprintf("DEP-%02d", newID)
+// Jumlah digit maksimal nomor urut
+const maxSequenceDigits = 9
 
+// Validate memastikan format menghasilkan ID yang muat di kolom department_id
+func (f DepartmentIDFormat) Validate() error {
+	if f.Prefix == "" {
+		return errors.New("department id prefix is required")
+	}
+	if f.Padding < 1 || f.Padding > maxSequenceDigits {
+		return fmt.Errorf("department id padding must be between 1 and %d", maxSequenceDigits)
+	}
+	if maxPrefix := maxDepartmentIDLength - maxSequenceDigits; len(f.Prefix) > maxPrefix {
+		return fmt.Errorf("department id prefix must not exceed %d characters", maxPrefix)
+	}
 	return nil
 }
 
+// Format membuat DepartmentID dari nomor urut tenant
+func (f DepartmentIDFormat) Format(seq int64) (string, error) {
+	digits := fmt.Sprintf("%0*d", f.Padding, seq)
+	if seq < 1 || len(digits) > maxSequenceDigits {
+		return "", errors.New("department id sequence exhausted")
+	}
+	return f.Prefix + digits, nil
+}
+
 func (d *Department) ToResponse() *DepartmentResponse {
 	return &DepartmentResponse{
-		DepartmentID: d.DepartmentID,
-		Name:         d.Name,
+		DepartmentID:       d.DepartmentID,
+		Name:               d.Name,
+		ParentDepartmentID: d.ParentDepartmentID,
+		Heads:              append([]string{}, d.Heads...),
+		ArchivedAt:         d.ArchivedAt,
+		Version:            d.Version,
+	}
+}
+
+func (d *Department) ToTrashedResponse() *TrashedDepartmentResponse {
+	return &TrashedDepartmentResponse{
+		DepartmentResponse: *d.ToResponse(),
+		DeletedAt:          d.DeletedAt.Time,
 	}
 }
 
@@ -73,4 +198,17 @@ func (f *DepartmentFilter) Normalize() {
 	if f.Offset < 0 {
 		f.Offset = 0
 	}
+	if f.Status == "" {
+		f.Status = DepartmentStatusActive
+	}
+}
+
+// ValidStatus mengecek nilai query parameter status
+func (f *DepartmentFilter) ValidStatus() bool {
+	switch f.Status {
+	case "", DepartmentStatusActive, DepartmentStatusArchived, DepartmentStatusAll:
+		return true
+	default:
+		return false
+	}
 }
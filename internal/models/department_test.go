@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestDepartmentIDFormatFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  DepartmentIDFormat
+		seq     int64
+		want    string
+		wantErr bool
+	}{
+		{name: "default format", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 2}, seq: 1, want: "DEP-01"},
+		{name: "padding grows past width", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 2}, seq: 100, want: "DEP-100"},
+		{name: "custom prefix", format: DepartmentIDFormat{Prefix: "ENG-", Padding: 4}, seq: 42, want: "ENG-0042"},
+		{name: "largest sequence", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 1}, seq: 999999999, want: "DEP-999999999"},
+		{name: "sequence exhausted", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 1}, seq: 1000000000, wantErr: true},
+		{name: "zero sequence", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 2}, seq: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format.Format(tt.seq)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Format() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDepartmentIDFormatValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  DepartmentIDFormat
+		wantErr bool
+	}{
+		{name: "valid", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 2}},
+		{name: "longest prefix", format: DepartmentIDFormat{Prefix: "ABCDEFGHIJKLMNOPQRSTUV-", Padding: 9}},
+		{name: "prefix too long", format: DepartmentIDFormat{Prefix: "ABCDEFGHIJKLMNOPQRSTUVW-", Padding: 2}, wantErr: true},
+		{name: "empty prefix", format: DepartmentIDFormat{Padding: 2}, wantErr: true},
+		{name: "zero padding", format: DepartmentIDFormat{Prefix: "DEP-"}, wantErr: true},
+		{name: "padding too wide", format: DepartmentIDFormat{Prefix: "DEP-", Padding: 10}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.format.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// ID terpanjang harus muat di kolom department_id
+			id, err := tt.format.Format(999999999)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if len(id) > maxDepartmentIDLength {
+				t.Errorf("longest id %q has %d characters, column holds %d", id, len(id), maxDepartmentIDLength)
+			}
+		})
+	}
+}
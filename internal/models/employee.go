@@ -1,38 +1,145 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
 	"time"
 )
 
+// Format tanggal (tanpa jam) untuk hireDate, probationEndDate dan dateOfBirth
+const DateLayout = "2006-01-02"
+
+// Jenis hubungan kerja employee
+const (
+	EmploymentTypeFullTime = "full_time"
+	EmploymentTypePartTime = "part_time"
+	EmploymentTypeContract = "contract"
+	EmploymentTypeIntern   = "intern"
+)
+
+// EmergencyContact adalah kontak darurat employee
+type EmergencyContact struct {
+	Name         string `json:"name" validate:"required,max=64"`
+	Relationship string `json:"relationship" validate:"required,max=32"`
+	Phone        string `json:"phone" validate:"required,e164"`
+}
+
+// EmergencyContacts disimpan sebagai JSONB di kolom employees.emergency_contacts
+type EmergencyContacts []EmergencyContact
+
+func (c EmergencyContacts) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (c *EmergencyContacts) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = EmergencyContacts{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported emergency contacts type %T", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
 type Employee struct {
-	ID               uint       `gorm:"primaryKey" json:"-"`
-	DepartmentID     string     `gorm:"size:10;not null" json:"-"` // FK ke Department.DepartmentID
-	IdentityNumber   string     `gorm:"uniqueIndex;size:33;not null" json:"identity_number"`
-	Name             string     `gorm:"size:33;not null" json:"name"`
-	EmployeeImageUri string     `gorm:"size:255" json:"employee_image_uri"`
-	Gender           string     `gorm:"size:6;not null" json:"gender"`
-	CreatedAt        time.Time  `json:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at"`
-	DeletedAt        *time.Time `gorm:"index" json:"-"`
+	ID               uint   `gorm:"primaryKey" json:"-"`
+	DepartmentID     string `gorm:"size:32;not null" json:"-"` // Department.DepartmentID milik tenant OwnerID
+	OwnerID          uint   `gorm:"not null" json:"-"`         // Tenant (User.ID pemilik department), bersama DepartmentID menentukan department
+	IdentityNumber   string `gorm:"size:33;not null;uniqueIndex:idx_employees_identity_number,where:deleted_at IS NULL" json:"identity_number"`
+	Name             string `gorm:"size:33;not null" json:"name"`
+	EmployeeImageUri string `gorm:"size:255" json:"employee_image_uri"`
+	Gender           string `gorm:"size:6;not null" json:"gender"`
+
+	// Data kontak dan kepegawaian, semuanya opsional untuk employee lama
+	WorkEmail         string            `gorm:"size:255;not null;default:''" json:"work_email"` // Unik (case insensitive) di antara employee aktif
+	Phone             string            `gorm:"size:20;not null;default:''" json:"phone"`
+	JobTitle          string            `gorm:"size:64;not null;default:''" json:"job_title"`
+	EmploymentType    string            `gorm:"size:16;not null;default:full_time" json:"employment_type"`
+	HireDate          *time.Time        `gorm:"type:date" json:"hire_date"`
+	ProbationEndDate  *time.Time        `gorm:"type:date" json:"probation_end_date"`
+	DateOfBirth       *time.Time        `gorm:"type:date" json:"date_of_birth"`
+	Address           string            `gorm:"size:255;not null;default:''" json:"address"`
+	EmergencyContacts EmergencyContacts `gorm:"type:jsonb;not null;default:'[]'" json:"emergency_contacts"`
+
+	// Atasan langsung (FK ke Employee.ID), nil untuk puncak struktur organisasi
+	ManagerID *uint `gorm:"index" json:"-"`
+	// Identity number atasan, hanya dibaca lewat subquery (lihat repository), kosong jika atasan di trash
+	ManagerIdentityNumber string `gorm:"->;-:migration" json:"-"`
+
+	// Akun user milik employee (FK ke User.ID), wajib untuk employee yang menjadi kepala department
+	UserID *uint `gorm:"index" json:"-"`
+	// Email akun user, hanya dibaca lewat subquery (lihat repository)
+	UserEmail string `gorm:"->;-:migration" json:"-"`
+
+	Version   uint           `gorm:"not null;default:1" json:"-"` // Naik setiap update, dipakai untuk ETag
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete, employee masuk trash sampai dipurge
 
 	// Relasi ke Department (Many-to-One)
-	Department Department `gorm:"foreignKey:DepartmentID;references:DepartmentID" json:"-"`
+	Department Department `gorm:"foreignKey:OwnerID,DepartmentID;references:UserID,DepartmentID" json:"-"`
 }
 
 type CreateEmployeeRequest struct {
-	IdentityNumber   string `json:"identityNumber" validate:"required,min=5,max=33"`
-	Name             string `json:"name" validate:"required,min=4,max=33"`
-	EmployeeImageUri string `json:"employeeImageUri" validate:"omitempty,uri"`
-	Gender           string `json:"gender" validate:"required,oneof=male female"`
-	DepartmentId     string `json:"departmentId" validate:"required"` // Perhatikan nama field ini
+	IdentityNumber      string `json:"identityNumber" validate:"required,min=5,max=33"`
+	Name                string `json:"name" validate:"required,min=4,max=33"`
+	EmployeeImageUri    string `json:"employeeImageUri" validate:"omitempty,uri"`
+	EmployeeImageFileId *uint  `json:"employeeImageFileId" validate:"omitempty,gt=0"` // Alternatif dari employeeImageUri
+	Gender              string `json:"gender" validate:"required,oneof=male female"`
+	DepartmentId        string `json:"departmentId" validate:"required"` // Perhatikan nama field ini
+
+	WorkEmail         string             `json:"workEmail" validate:"omitempty,email,max=255"`
+	Phone             string             `json:"phone" validate:"omitempty,e164"`
+	JobTitle          string             `json:"jobTitle" validate:"omitempty,max=64"`
+	EmploymentType    string             `json:"employmentType" validate:"omitempty,oneof=full_time part_time contract intern"`
+	HireDate          string             `json:"hireDate" validate:"omitempty,datetime=2006-01-02"`
+	ProbationEndDate  string             `json:"probationEndDate" validate:"omitempty,datetime=2006-01-02"`
+	DateOfBirth       string             `json:"dateOfBirth" validate:"omitempty,datetime=2006-01-02"`
+	Address           string             `json:"address" validate:"omitempty,max=255"`
+	EmergencyContacts []EmergencyContact `json:"emergencyContacts" validate:"omitempty,max=5,dive"`
+
+	ManagerIdentityNumber string `json:"managerIdentityNumber" validate:"omitempty,min=5,max=33"` // Kosong = tanpa atasan
+	UserEmail             string `json:"userEmail" validate:"omitempty,email,max=255"`            // Email akun user, kosong = tanpa akun
 }
 
+// UpdateEmployeeRequest adalah hasil merge patch terhadap data employee saat ini,
+// validasi hanya diterapkan ke field yang dikirim client
 type UpdateEmployeeRequest struct {
-	IdentityNumber   string `json:"identityNumber" validate:"required,min=5,max=33"`
-	Name             string `json:"name" validate:"required,min=4,max=33"`
-	EmployeeImageUri string `json:"employeeImageUri" validate:"omitempty,uri"`
-	Gender           string `json:"gender" validate:"required,oneof=male female"`
-	DepartmentId     string `json:"departmentId" validate:"required"`
+	IdentityNumber      string `json:"identityNumber" validate:"required,min=5,max=33"`
+	Name                string `json:"name" validate:"required,min=4,max=33"`
+	EmployeeImageUri    string `json:"employeeImageUri" validate:"omitempty,uri"`
+	EmployeeImageFileId *uint  `json:"employeeImageFileId" validate:"omitempty,gt=0"`
+	Gender              string `json:"gender" validate:"required,oneof=male female"`
+	DepartmentId        string `json:"departmentId" validate:"required"`
+
+	WorkEmail         string             `json:"workEmail" validate:"omitempty,email,max=255"`
+	Phone             string             `json:"phone" validate:"omitempty,e164"`
+	JobTitle          string             `json:"jobTitle" validate:"omitempty,max=64"`
+	EmploymentType    string             `json:"employmentType" validate:"omitempty,oneof=full_time part_time contract intern"`
+	HireDate          string             `json:"hireDate" validate:"omitempty,datetime=2006-01-02"`
+	ProbationEndDate  string             `json:"probationEndDate" validate:"omitempty,datetime=2006-01-02"`
+	DateOfBirth       string             `json:"dateOfBirth" validate:"omitempty,datetime=2006-01-02"`
+	Address           string             `json:"address" validate:"omitempty,max=255"`
+	EmergencyContacts []EmergencyContact `json:"emergencyContacts" validate:"omitempty,max=5,dive"`
+
+	ManagerIdentityNumber string `json:"managerIdentityNumber" validate:"omitempty,min=5,max=33"` // Kosong = tanpa atasan
+	UserEmail             string `json:"userEmail" validate:"omitempty,email,max=255"`            // Email akun user, kosong = tanpa akun
 }
 
 type EmployeeResponse struct {
@@ -41,6 +148,45 @@ type EmployeeResponse struct {
 	EmployeeImageUri string `json:"employeeImageUri"`
 	Gender           string `json:"gender"`
 	DepartmentID     string `json:"departmentId"` // Format: DEP-XX
+
+	WorkEmail         string             `json:"workEmail"`
+	Phone             string             `json:"phone"`
+	JobTitle          string             `json:"jobTitle"`
+	EmploymentType    string             `json:"employmentType"`
+	HireDate          string             `json:"hireDate"` // Format YYYY-MM-DD, kosong jika belum diisi
+	ProbationEndDate  string             `json:"probationEndDate"`
+	DateOfBirth       string             `json:"dateOfBirth"`
+	Address           string             `json:"address"`
+	EmergencyContacts []EmergencyContact `json:"emergencyContacts"`
+
+	ManagerIdentityNumber string `json:"managerIdentityNumber"`
+	UserEmail             string `json:"userEmail"`
+
+	Version uint `json:"-"` // Dikirim lewat header ETag
+}
+
+// EmployeeReport untuk GET /v1/employee/:identityNumber/reports, Level 1 = bawahan langsung
+type EmployeeReport struct {
+	Employee `gorm:"embedded"`
+	Level    int
+}
+
+type EmployeeReportResponse struct {
+	EmployeeResponse
+	Level int `json:"level"`
+}
+
+func (r *EmployeeReport) ToResponse() *EmployeeReportResponse {
+	return &EmployeeReportResponse{
+		EmployeeResponse: *r.Employee.ToResponse(),
+		Level:            r.Level,
+	}
+}
+
+// TrashedEmployeeResponse untuk GET /v1/employee/trash
+type TrashedEmployeeResponse struct {
+	EmployeeResponse
+	DeletedAt time.Time `json:"deletedAt"`
 }
 
 func (e *Employee) ToResponse() *EmployeeResponse {
@@ -50,6 +196,128 @@ func (e *Employee) ToResponse() *EmployeeResponse {
 		EmployeeImageUri: e.EmployeeImageUri,
 		Gender:           e.Gender,
 		DepartmentID:     e.DepartmentID, // Format: DEP-XX
+
+		WorkEmail:         e.WorkEmail,
+		Phone:             e.Phone,
+		JobTitle:          e.JobTitle,
+		EmploymentType:    e.EmploymentType,
+		HireDate:          formatDate(e.HireDate),
+		ProbationEndDate:  formatDate(e.ProbationEndDate),
+		DateOfBirth:       formatDate(e.DateOfBirth),
+		Address:           e.Address,
+		EmergencyContacts: append([]EmergencyContact{}, e.EmergencyContacts...),
+
+		ManagerIdentityNumber: e.ManagerIdentityNumber,
+		UserEmail:             e.UserEmail,
+
+		Version: e.Version,
+	}
+}
+
+// EmployeeDetails adalah data kontak dan kepegawaian dari request create/update
+type EmployeeDetails struct {
+	WorkEmail         string
+	Phone             string
+	JobTitle          string
+	EmploymentType    string
+	HireDate          string
+	ProbationEndDate  string
+	DateOfBirth       string
+	Address           string
+	EmergencyContacts []EmergencyContact
+}
+
+func (r *CreateEmployeeRequest) Details() EmployeeDetails {
+	return EmployeeDetails{
+		WorkEmail:         r.WorkEmail,
+		Phone:             r.Phone,
+		JobTitle:          r.JobTitle,
+		EmploymentType:    r.EmploymentType,
+		HireDate:          r.HireDate,
+		ProbationEndDate:  r.ProbationEndDate,
+		DateOfBirth:       r.DateOfBirth,
+		Address:           r.Address,
+		EmergencyContacts: r.EmergencyContacts,
+	}
+}
+
+func (r *UpdateEmployeeRequest) Details() EmployeeDetails {
+	return EmployeeDetails{
+		WorkEmail:         r.WorkEmail,
+		Phone:             r.Phone,
+		JobTitle:          r.JobTitle,
+		EmploymentType:    r.EmploymentType,
+		HireDate:          r.HireDate,
+		ProbationEndDate:  r.ProbationEndDate,
+		DateOfBirth:       r.DateOfBirth,
+		Address:           r.Address,
+		EmergencyContacts: r.EmergencyContacts,
+	}
+}
+
+// ApplyDetails mengisi data kontak dan kepegawaian employee. Format field sudah divalidasi
+// di handler, di sini hanya aturan yang melibatkan lebih dari satu field atau tanggal hari ini.
+func (e *Employee) ApplyDetails(d EmployeeDetails, today time.Time) error {
+	hireDate, err := parseDate(d.HireDate)
+	if err != nil {
+		return err
+	}
+	probationEndDate, err := parseDate(d.ProbationEndDate)
+	if err != nil {
+		return err
+	}
+	dateOfBirth, err := parseDate(d.DateOfBirth)
+	if err != nil {
+		return err
+	}
+
+	if probationEndDate != nil && hireDate != nil && probationEndDate.Before(*hireDate) {
+		return errors.New("probation end date must not be before hire date")
+	}
+	if dateOfBirth != nil && !dateOfBirth.Before(today) {
+		return errors.New("date of birth must be in the past")
+	}
+
+	employmentType := d.EmploymentType
+	if employmentType == "" {
+		employmentType = EmploymentTypeFullTime
+	}
+
+	e.WorkEmail = d.WorkEmail
+	e.Phone = d.Phone
+	e.JobTitle = d.JobTitle
+	e.EmploymentType = employmentType
+	e.HireDate = hireDate
+	e.ProbationEndDate = probationEndDate
+	e.DateOfBirth = dateOfBirth
+	e.Address = d.Address
+	e.EmergencyContacts = append(EmergencyContacts{}, d.EmergencyContacts...)
+	return nil
+}
+
+// parseDate membaca tanggal format YYYY-MM-DD, string kosong berarti tidak diisi
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %s", value)
+	}
+	return &t, nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(DateLayout)
+}
+
+func (e *Employee) ToTrashedResponse() *TrashedEmployeeResponse {
+	return &TrashedEmployeeResponse{
+		EmployeeResponse: *e.ToResponse(),
+		DeletedAt:        e.DeletedAt.Time,
 	}
 }
 
@@ -61,6 +329,13 @@ type EmployeeFilter struct {
 	Name           string `query:"name"`
 	Gender         string `query:"gender"`
 	DepartmentID   string `query:"departmentId"` // Menggunakan string karena departmentId adalah string
+	OwnerID        uint   `query:"-"`            // Tenant tempat departmentId dicari, diisi dari user yang login
+	// Jika true, filter departmentId juga mencakup semua sub-department di bawahnya
+	IncludeSubdepartments bool   `query:"includeSubdepartments"`
+	JobTitle              string `query:"jobTitle"`
+	EmploymentType        string `query:"employmentType" validate:"omitempty,oneof=full_time part_time contract intern"`
+	HiredFrom             string `query:"hiredFrom" validate:"omitempty,datetime=2006-01-02"` // hire_date >= hiredFrom
+	HiredTo               string `query:"hiredTo" validate:"omitempty,datetime=2006-01-02"`   // hire_date <= hiredTo
 }
 
 // Normalize untuk set default values dan validasi
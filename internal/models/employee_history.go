@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+)
+
+// Status EmployeeTransfer
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusApplied   = "applied"
+	TransferStatusCancelled = "cancelled"
+	TransferStatusFailed    = "failed" // Tidak bisa diterapkan, alasannya di FailureReason
+)
+
+// EmployeeHistory adalah satu periode department, jabatan dan atasan employee. Periode berlaku
+// mulai EffectiveFrom sampai sehari sebelum EffectiveTo, EffectiveTo nil untuk periode saat ini.
+type EmployeeHistory struct {
+	ID                    uint       `gorm:"primaryKey" json:"-"`
+	EmployeeID            uint       `gorm:"not null;index" json:"-"`   // FK ke Employee.ID
+	DepartmentID          string     `gorm:"size:32;not null" json:"-"` // Bukan FK, department boleh sudah dihapus
+	JobTitle              string     `gorm:"size:64;not null;default:''" json:"-"`
+	ManagerID             *uint      `json:"-"`
+	ManagerIdentityNumber string     `gorm:"->;-:migration" json:"-"` // Dibaca lewat subquery, termasuk atasan yang sudah di trash
+	EffectiveFrom         time.Time  `gorm:"type:date;not null" json:"-"`
+	EffectiveTo           *time.Time `gorm:"type:date" json:"-"`
+	CreatedAt             time.Time  `json:"-"`
+}
+
+// EmployeeTransfer adalah perpindahan department (opsional dengan jabatan dan atasan baru) yang
+// dijadwalkan dan diterapkan otomatis oleh job employee-transfer pada EffectiveDate
+type EmployeeTransfer struct {
+	ID                    uint       `gorm:"primaryKey" json:"-"`
+	EmployeeID            uint       `gorm:"not null;index" json:"-"` // FK ke Employee.ID
+	DepartmentID          string     `gorm:"size:32;not null" json:"-"`
+	JobTitle              string     `gorm:"size:64;not null;default:''" json:"-"` // Kosong = jabatan tidak berubah
+	ManagerID             *uint      `json:"-"`                                    // Nil = atasan tidak berubah, kecuali ClearManager
+	ManagerIdentityNumber string     `gorm:"->;-:migration" json:"-"`
+	ClearManager          bool       `gorm:"not null;default:false" json:"-"` // Atasan dikosongkan saat transfer diterapkan
+	EffectiveDate         time.Time  `gorm:"type:date;not null" json:"-"`
+	Status                string     `gorm:"size:16;not null;default:pending" json:"-"`
+	FailureReason         string     `gorm:"size:255;not null;default:''" json:"-"`
+	CreatedAt             time.Time  `json:"-"`
+	UpdatedAt             time.Time  `json:"-"`
+	AppliedAt             *time.Time `json:"-"`
+}
+
+// ScheduleTransferRequest untuk POST /v1/employee/:identityNumber/transfers
+type ScheduleTransferRequest struct {
+	DepartmentId          string `json:"departmentId" validate:"required,max=32"`
+	JobTitle              string `json:"jobTitle" validate:"omitempty,max=64"`                        // Kosong = jabatan tidak berubah
+	ManagerIdentityNumber string `json:"managerIdentityNumber" validate:"omitempty,min=5,max=33"`     // Kosong = atasan tidak berubah
+	ClearManager          bool   `json:"clearManager" validate:"excluded_with=ManagerIdentityNumber"` // true = employee tidak lagi punya atasan
+	EffectiveDate         string `json:"effectiveDate" validate:"required,datetime=2006-01-02"`
+}
+
+type EmployeeHistoryEntry struct {
+	DepartmentID          string `json:"departmentId"`
+	JobTitle              string `json:"jobTitle"`
+	ManagerIdentityNumber string `json:"managerIdentityNumber"`
+	EffectiveFrom         string `json:"effectiveFrom"` // Format YYYY-MM-DD
+	EffectiveTo           string `json:"effectiveTo"`   // Kosong untuk periode saat ini
+}
+
+type EmployeeTransferResponse struct {
+	ID                    uint       `json:"id"`
+	DepartmentID          string     `json:"departmentId"`
+	JobTitle              string     `json:"jobTitle"`
+	ManagerIdentityNumber string     `json:"managerIdentityNumber"`
+	ClearManager          bool       `json:"clearManager"`
+	EffectiveDate         string     `json:"effectiveDate"`
+	Status                string     `json:"status"`
+	FailureReason         string     `json:"failureReason,omitempty"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	AppliedAt             *time.Time `json:"appliedAt,omitempty"`
+}
+
+// EmployeeHistoryResponse untuk GET /v1/employee/:identityNumber/history, terbaru lebih dulu
+type EmployeeHistoryResponse struct {
+	IdentityNumber string                      `json:"identityNumber"`
+	History        []*EmployeeHistoryEntry     `json:"history"`
+	Transfers      []*EmployeeTransferResponse `json:"transfers"`
+}
+
+// DateOf membuang jam dari t, dipakai untuk kolom bertipe date
+func DateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// HistoryEntry membuat periode history dari data employee saat ini, mulai berlaku pada effectiveFrom
+func (e *Employee) HistoryEntry(effectiveFrom time.Time) *EmployeeHistory {
+	return &EmployeeHistory{
+		EmployeeID:            e.ID,
+		DepartmentID:          e.DepartmentID,
+		JobTitle:              e.JobTitle,
+		ManagerID:             e.ManagerID,
+		ManagerIdentityNumber: e.ManagerIdentityNumber,
+		EffectiveFrom:         DateOf(effectiveFrom),
+	}
+}
+
+// SameAssignment mengecek apakah department, jabatan dan atasan di periode h sama dengan employee
+func (h *EmployeeHistory) SameAssignment(e *Employee) bool {
+	if h.DepartmentID != e.DepartmentID || h.JobTitle != e.JobTitle {
+		return false
+	}
+	if h.ManagerID == nil || e.ManagerID == nil {
+		return h.ManagerID == nil && e.ManagerID == nil
+	}
+	return *h.ManagerID == *e.ManagerID
+}
+
+func (h *EmployeeHistory) ToEntry() *EmployeeHistoryEntry {
+	return &EmployeeHistoryEntry{
+		DepartmentID:          h.DepartmentID,
+		JobTitle:              h.JobTitle,
+		ManagerIdentityNumber: h.ManagerIdentityNumber,
+		EffectiveFrom:         formatDate(&h.EffectiveFrom),
+		EffectiveTo:           formatDate(h.EffectiveTo),
+	}
+}
+
+func (t *EmployeeTransfer) ToResponse() *EmployeeTransferResponse {
+	return &EmployeeTransferResponse{
+		ID:                    t.ID,
+		DepartmentID:          t.DepartmentID,
+		JobTitle:              t.JobTitle,
+		ManagerIdentityNumber: t.ManagerIdentityNumber,
+		ClearManager:          t.ClearManager,
+		EffectiveDate:         formatDate(&t.EffectiveDate),
+		Status:                t.Status,
+		FailureReason:         t.FailureReason,
+		CreatedAt:             t.CreatedAt,
+		AppliedAt:             t.AppliedAt,
+	}
+}
+
+// EffectiveDateValue membaca EffectiveDate yang sudah divalidasi format YYYY-MM-DD
+func (r *ScheduleTransferRequest) EffectiveDateValue() (time.Time, error) {
+	t, err := parseDate(r.EffectiveDate)
+	if err != nil || t == nil {
+		return time.Time{}, err
+	}
+	return *t, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+// Maksimal jumlah baris data dalam satu file import
+const MaxEmployeeImportRows = 5000
+
+// Kolom file import, default nama header sama dengan nama field JSON CreateEmployeeRequest
+var EmployeeImportColumns = []string{
+	"identityNumber",
+	"name",
+	"gender",
+	"departmentId",
+	"employeeImageUri",
+	"employeeImageFileId",
+	"workEmail",
+	"phone",
+	"jobTitle",
+	"employmentType",
+	"hireDate",
+	"probationEndDate",
+	"dateOfBirth",
+	"address",
+	"managerIdentityNumber",
+	"userEmail",
+}
+
+// EmployeeImportRow adalah satu baris data file import, Row adalah nomor baris di file (header = 1)
+type EmployeeImportRow struct {
+	Row     int
+	Request CreateEmployeeRequest
+	Errors  []EmployeeImportError // Error parsing/validasi request, baris ini tidak dicek ke database
+}
+
+// EmployeeImportError adalah error validasi untuk satu baris
+type EmployeeImportError struct {
+	Row            int    `json:"row"`
+	IdentityNumber string `json:"identityNumber,omitempty"`
+	Field          string `json:"field,omitempty"`
+	Message        string `json:"message"`
+}
+
+// EmployeeImportResult untuk POST /v1/employee/import. Import bersifat semua atau tidak sama
+// sekali: jika ada error, Imported selalu 0.
+type EmployeeImportResult struct {
+	DryRun    bool                  `json:"dryRun"`
+	TotalRows int                   `json:"totalRows"`
+	ValidRows int                   `json:"validRows"`
+	Imported  int                   `json:"imported"`
+	Errors    []EmployeeImportError `json:"errors"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag membuat strong entity tag dari versi resource, contoh: "3"
+func ETag(version uint) string {
+	return strconv.Quote(strconv.FormatUint(uint64(version), 10))
+}
+
+// MatchesIfMatch mengecek header If-Match dengan strong comparison (RFC 9110): weak tag
+// (W/"3") tidak pernah cocok. Header kosong tidak cocok, "*" selalu cocok.
+func MatchesIfMatch(header string, version uint) bool {
+	return matchesETag(header, version, false)
+}
+
+// MatchesIfNoneMatch mengecek header If-None-Match dengan weak comparison untuk conditional GET.
+// Header kosong tidak cocok, "*" selalu cocok.
+func MatchesIfNoneMatch(header string, version uint) bool {
+	return matchesETag(header, version, true)
+}
+
+func matchesETag(header string, version uint, weak bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	current := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
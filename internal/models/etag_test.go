@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		ifMatch     bool
+		ifNoneMatch bool
+	}{
+		{name: "empty header", header: ""},
+		{name: "wildcard", header: "*", ifMatch: true, ifNoneMatch: true},
+		{name: "current version", header: `"3"`, ifMatch: true, ifNoneMatch: true},
+		{name: "weak tag", header: `W/"3"`, ifNoneMatch: true},
+		{name: "old version", header: `"2"`},
+		{name: "list with current version", header: `"1", "3"`, ifMatch: true, ifNoneMatch: true},
+		{name: "unquoted", header: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesIfMatch(tt.header, 3); got != tt.ifMatch {
+				t.Errorf("MatchesIfMatch(%q) = %v, want %v", tt.header, got, tt.ifMatch)
+			}
+			if got := MatchesIfNoneMatch(tt.header, 3); got != tt.ifNoneMatch {
+				t.Errorf("MatchesIfNoneMatch(%q) = %v, want %v", tt.header, got, tt.ifNoneMatch)
+			}
+		})
+	}
+}
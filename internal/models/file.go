@@ -1,26 +1,163 @@
 package models
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"mime/multipart"
+	"image"
+	_ "image/jpeg" // Registrasi decoder untuk image.DecodeConfig
+	_ "image/png"
+	"net/http"
+	"strings"
 	"time"
 )
 
 type File struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"not null" json:"user_id"`
-	Filename  string    `gorm:"size:255;not null" json:"filename"`
-	URI       string    `gorm:"size:255;not null" json:"uri"`
-	FileType  string    `gorm:"size:50;not null" json:"file_type"`
-	FileSize  int64     `gorm:"not null" json:"file_size"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null" json:"user_id"`           // Pemilik file
+	Filename   string     `gorm:"size:255;not null" json:"filename"` // Nama file asli dari client
+	URI        string     `gorm:"column:file_uri;size:1024;not null" json:"uri"`
+	StorageKey string     `gorm:"size:512;not null" json:"-"`        // Key object di StorageService
+	FileType   string     `gorm:"size:50;not null" json:"file_type"` // MIME type
+	FileSize   int64      `gorm:"not null" json:"file_size"`         // Ukuran dalam bytes
+	Checksum   string     `gorm:"size:64;not null" json:"checksum"`  // SHA-256 hex
+	Status     string     `gorm:"size:20;not null;default:ready" json:"status"`
+	Purpose    string     `gorm:"size:32;not null;default:employee_photo" json:"purpose"`
+	ScanResult string     `gorm:"size:255;not null" json:"-"` // Nama malware jika file dikarantina
+	ScannedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Variants []FileVariant `gorm:"foreignKey:FileID" json:"variants,omitempty"` // Hasil resize untuk file gambar
+}
+
+// FileVariant adalah versi resize/format lain dari file gambar, disimpan di samping file aslinya
+type FileVariant struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FileID     uint      `gorm:"not null" json:"file_id"`
+	Width      int       `gorm:"not null" json:"width"`
+	Height     int       `gorm:"not null" json:"height"`
+	Format     string    `gorm:"size:10;not null" json:"format"` // jpeg, png, webp
+	URI        string    `gorm:"column:file_uri;size:1024;not null" json:"uri"`
+	StorageKey string    `gorm:"size:512;not null" json:"-"`
+	FileType   string    `gorm:"size:50;not null" json:"file_type"`
+	FileSize   int64     `gorm:"not null" json:"file_size"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Status file
+const (
+	FileStatusPending = "pending" // Presigned upload dibuat, menunggu complete
+	FileStatusReady   = "ready"   // File sudah tersimpan, lolos scan dan bisa dipakai
+	// Scanner menemukan malware, file tidak bisa diakses dan dihapus oleh file sweeper
+	FileStatusQuarantined = "quarantined"
+)
+
+// Name mengidentifikasi variant di URL, contoh: 256.webp
+func (v FileVariant) Name() string {
+	return fmt.Sprintf("%d.%s", max(v.Width, v.Height), v.Format)
 }
 
 // FileUploadResponse sesuai dengan contract API
 type FileUploadResponse struct {
-	URI string `json:"uri"`
+	ID       uint                  `json:"id"`
+	URI      string                `json:"uri"`
+	Variants []FileVariantResponse `json:"variants,omitempty"`
+}
+
+// FileVariantResponse berisi URI untuk tiap ukuran/format variant gambar
+type FileVariantResponse struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+	URI    string `json:"uri"`
+}
+
+// CreateUploadRequest untuk POST /v1/file/uploads (presigned upload)
+type CreateUploadRequest struct {
+	Filename    string `json:"filename" validate:"required,max=255"`
+	ContentType string `json:"contentType" validate:"required"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+	Purpose     string `json:"purpose" validate:"omitempty,oneof=avatar company_logo employee_photo document"`
+}
+
+// PresignedUploadResponse berisi URL untuk upload langsung ke storage
+type PresignedUploadResponse struct {
+	ID        uint              `json:"id"`
+	UploadURL string            `json:"uploadUrl"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// FileResponse untuk GET /v1/file dan GET /v1/file/:id
+type FileResponse struct {
+	ID        uint                  `json:"id"`
+	Filename  string                `json:"filename"`
+	URI       string                `json:"uri"`
+	FileType  string                `json:"fileType"`
+	FileSize  int64                 `json:"fileSize"`
+	Checksum  string                `json:"checksum"`
+	Status    string                `json:"status"`
+	Purpose   string                `json:"purpose"`
+	Variants  []FileVariantResponse `json:"variants,omitempty"`
+	CreatedAt time.Time             `json:"createdAt"`
+}
+
+// FileFilter untuk query parameters GET /v1/file
+type FileFilter struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+func (f *File) ToResponse() *FileResponse {
+	return &FileResponse{
+		ID:        f.ID,
+		Filename:  f.Filename,
+		URI:       f.URI,
+		FileType:  f.FileType,
+		FileSize:  f.FileSize,
+		Checksum:  f.Checksum,
+		Status:    f.Status,
+		Purpose:   f.Purpose,
+		Variants:  f.VariantResponses(),
+		CreatedAt: f.CreatedAt,
+	}
+}
+
+func (f *File) ToUploadResponse() *FileUploadResponse {
+	return &FileUploadResponse{
+		ID:       f.ID,
+		URI:      f.URI,
+		Variants: f.VariantResponses(),
+	}
+}
+
+func (f *File) VariantResponses() []FileVariantResponse {
+	if len(f.Variants) == 0 {
+		return nil
+	}
+
+	variants := make([]FileVariantResponse, 0, len(f.Variants))
+	for _, v := range f.Variants {
+		variants = append(variants, FileVariantResponse{
+			Width:  v.Width,
+			Height: v.Height,
+			Format: v.Format,
+			URI:    v.URI,
+		})
+	}
+	return variants
+}
+
+func (f *FileFilter) Normalize() {
+	if f.Limit <= 0 {
+		f.Limit = 5
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
 }
 
 // FileValidationError dengan implementasi interface error
@@ -43,43 +180,285 @@ func (e FileValidationErrors) Error() string {
 	return string(errBytes)
 }
 
-// FileValidator untuk validasi file upload
+// Tujuan upload, masing-masing punya batasan tipe dan ukuran sendiri
+const (
+	UploadPurposeAvatar        = "avatar"
+	UploadPurposeCompanyLogo   = "company_logo"
+	UploadPurposeEmployeePhoto = "employee_photo"
+	UploadPurposeDocument      = "document"
+
+	// Dipakai jika client tidak mengirim purpose
+	DefaultUploadPurpose = UploadPurposeEmployeePhoto
+)
+
+var UploadPurposes = []string{
+	UploadPurposeAvatar,
+	UploadPurposeCompanyLogo,
+	UploadPurposeEmployeePhoto,
+	UploadPurposeDocument,
+}
+
+// UploadPolicy berisi batasan upload untuk satu purpose
+type UploadPolicy struct {
+	AllowedTypes []string // MIME type hasil deteksi dari isi file
+	MaxSize      int64    // Ukuran maksimal dalam bytes
+	MaxWidth     int      // Dimensi maksimal gambar dalam px
+	MaxHeight    int
+}
+
+func (p UploadPolicy) Validate() error {
+	if len(p.AllowedTypes) == 0 {
+		return fmt.Errorf("allowed types must not be empty")
+	}
+	for _, contentType := range p.AllowedTypes {
+		if _, ok := uploadTypeExtensions[contentType]; !ok {
+			return fmt.Errorf("unsupported upload type %q", contentType)
+		}
+	}
+	if p.MaxSize <= 0 {
+		return fmt.Errorf("max size must be greater than 0")
+	}
+	if p.MaxWidth <= 0 || p.MaxHeight <= 0 {
+		return fmt.Errorf("max width and height must be greater than 0")
+	}
+	return nil
+}
+
+// Allows mengecek apakah MIME type diizinkan
+func (p UploadPolicy) Allows(contentType string) bool {
+	for _, allowed := range p.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Ekstensi file yang disimpan, ditentukan dari tipe hasil deteksi bukan dari nama file client.
+// WebP sengaja tidak diterima sebagai input: gambar upload selalu didecode dan diencode ulang,
+// sedangkan imaging hanya bisa decode JPEG/PNG. WebP hanya dipakai sebagai format variant.
+var uploadTypeExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// UploadExtension mengembalikan ekstensi file untuk MIME type yang didukung
+func UploadExtension(contentType string) string {
+	return uploadTypeExtensions[contentType]
+}
+
+// DetectedFile adalah hasil inspeksi isi file
+type DetectedFile struct {
+	ContentType string
+	Extension   string
+	IsImage     bool
+	Width       int
+	Height      int
+}
+
+// Signature yang menandakan ada payload lain yang ditempel di file (polyglot)
+var embeddedPayloadSignatures = [][]byte{
+	[]byte("PK\x03\x04"), // ZIP / JAR / Office
+	[]byte("PK\x05\x06"),
+	[]byte("<script"),
+	[]byte("<?php"),
+	[]byte("<html"),
+	[]byte("<svg"),
+}
+
+// Jumlah byte awal file yang dipakai untuk deteksi tipe (sama dengan http.DetectContentType)
+const SniffLength = 512
+
+// FileValidator untuk validasi file upload berdasarkan isi file, bukan header dari client.
+// Detect cukup membaca header file, Validate butuh seluruh isi file untuk gambar.
 type FileValidator struct {
-	File      *multipart.FileHeader
-	MaxSize   int64
-	MimeTypes []string
+	Data   []byte // Header file (minimal SniffLength byte) atau seluruh isi file
+	Size   int64  // Ukuran seluruh file
+	Policy UploadPolicy
 }
 
-func (v *FileValidator) Validate() error {
+// Detect memeriksa ukuran dan tipe file dari header, dipakai sebelum sisa file di-stream
+func (v *FileValidator) Detect() (*DetectedFile, error) {
 	var errors FileValidationErrors
 
-	// Validate file size (max 100KiB)
-	if v.File.Size > v.MaxSize {
+	if v.Size > v.Policy.MaxSize {
 		errors = append(errors, FileValidationError{
 			Field:   "file",
-			Message: fmt.Sprintf("File size exceeds maximum limit of %d KiB", v.MaxSize/1024),
+			Message: fmt.Sprintf("File size exceeds maximum limit of %d KiB", v.Policy.MaxSize/1024),
 		})
+		return nil, errors
 	}
 
-	// Validate mime type
-	contentType := v.File.Header.Get("Content-Type")
-	isValidType := false
-	for _, mimeType := range v.MimeTypes {
-		if contentType == mimeType {
-			isValidType = true
-			break
-		}
+	// Deteksi tipe dari magic bytes
+	contentType := http.DetectContentType(v.Data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	if !v.Policy.Allows(contentType) {
+		errors = append(errors, FileValidationError{
+			Field:   "file",
+			Message: fmt.Sprintf("File type must be one of: %s", strings.Join(v.Policy.AllowedTypes, ", ")),
+		})
+		return nil, errors
+	}
+
+	return &DetectedFile{
+		ContentType: contentType,
+		Extension:   uploadTypeExtensions[contentType],
+		IsImage:     strings.HasPrefix(contentType, "image/"),
+	}, nil
+}
+
+// Validate menjalankan Detect lalu memeriksa gambar secara utuh (dimensi dan polyglot),
+// untuk gambar Data harus berisi seluruh file
+func (v *FileValidator) Validate() (*DetectedFile, error) {
+	detected, err := v.Detect()
+	if err != nil || !detected.IsImage {
+		return detected, err
 	}
-	if !isValidType {
+
+	var errors FileValidationErrors
+
+	if int64(len(v.Data)) != v.Size {
 		errors = append(errors, FileValidationError{
 			Field:   "file",
-			Message: "File type must be jpeg, jpg, or png",
+			Message: "File is not a valid image",
+		})
+		return nil, errors
+	}
+
+	// Baca header gambar saja, belum decode pixel (cegah decompression bomb)
+	config, format, err := image.DecodeConfig(bytes.NewReader(v.Data))
+	if err != nil || "image/"+format != detected.ContentType {
+		errors = append(errors, FileValidationError{
+			Field:   "file",
+			Message: "File is not a valid image",
+		})
+		return nil, errors
+	}
+	if config.Width > v.Policy.MaxWidth || config.Height > v.Policy.MaxHeight {
+		errors = append(errors, FileValidationError{
+			Field:   "file",
+			Message: fmt.Sprintf("Image dimensions exceed maximum of %dx%d px", v.Policy.MaxWidth, v.Policy.MaxHeight),
+		})
+	}
+	detected.Width, detected.Height = config.Width, config.Height
+
+	// Tolak polyglot, contoh: JPEG valid yang ditempeli ZIP atau HTML
+	if hasEmbeddedPayload(v.Data, detected.ContentType) {
+		errors = append(errors, FileValidationError{
+			Field:   "file",
+			Message: "File contains embedded content",
 		})
 	}
 
 	if len(errors) > 0 {
-		return errors
+		return nil, errors
 	}
 
-	return nil
+	return detected, nil
+}
+
+// hasEmbeddedPayload mencari embeddedPayloadSignatures di bagian gambar yang bisa ditumpangi payload.
+// Data pixel tidak diperiksa karena isinya terkompresi dan bisa kebetulan mengandung signature.
+func hasEmbeddedPayload(data []byte, contentType string) bool {
+	for _, region := range payloadRegions(data, contentType) {
+		lower := bytes.ToLower(region)
+		for _, signature := range embeddedPayloadSignatures {
+			if bytes.Contains(lower, bytes.ToLower(signature)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// payloadRegions mengembalikan chunk/segment selain data pixel dan byte setelah akhir gambar.
+// Jika struktur file tidak bisa dibaca, sisa file mulai dari bagian yang rusak ikut dikembalikan.
+func payloadRegions(data []byte, contentType string) [][]byte {
+	switch contentType {
+	case "image/png":
+		return pngPayloadRegions(data)
+	case "image/jpeg":
+		return jpegPayloadRegions(data)
+	default:
+		return [][]byte{data}
+	}
+}
+
+// pngPayloadRegions mengembalikan isi semua chunk selain IDAT (tEXt, iTXt, eXIf, dll.) dan sisa file setelah IEND
+func pngPayloadRegions(data []byte) [][]byte {
+	const signatureLength = 8
+	if len(data) < signatureLength {
+		return [][]byte{data}
+	}
+
+	var regions [][]byte
+	offset := signatureLength
+	for offset+8 <= len(data) {
+		// Chunk: length (4), type (4), data (length), CRC (4)
+		length := int64(binary.BigEndian.Uint32(data[offset : offset+4]))
+		chunkType := string(data[offset+4 : offset+8])
+		if int64(offset)+12+length > int64(len(data)) {
+			break
+		}
+		end := offset + 12 + int(length)
+		if chunkType != "IDAT" {
+			regions = append(regions, data[offset+8:end-4])
+		}
+		offset = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return append(regions, data[offset:])
+}
+
+// jpegPayloadRegions mengembalikan isi semua segment marker (APPn, COM, tabel) dan sisa file setelah EOI.
+// Data scan setelah setiap SOS dilewati, termasuk di antara scan JPEG progressive.
+func jpegPayloadRegions(data []byte) [][]byte {
+	var regions [][]byte
+	offset := 2 // Lewati SOI
+	for offset+2 <= len(data) && data[offset] == 0xFF {
+		marker := data[offset+1]
+		if marker == 0xD9 { // EOI
+			offset += 2
+			break
+		}
+
+		if offset+4 > len(data) {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if length < 2 || offset+2+length > len(data) {
+			break
+		}
+		regions = append(regions, data[offset+4:offset+2+length])
+		offset += 2 + length
+
+		if marker == 0xDA { // SOS
+			next := nextJPEGMarker(data, offset)
+			if next < 0 {
+				break
+			}
+			offset = next
+		}
+	}
+	return append(regions, data[offset:])
+}
+
+// nextJPEGMarker mencari marker setelah data scan mulai dari offset, -1 jika tidak ada. Di data scan
+// 0xFF selalu diikuti 0x00 (byte stuffing) atau RSTn, 0xFF berulang adalah fill byte sebelum marker.
+func nextJPEGMarker(data []byte, offset int) int {
+	for i := offset; i+1 < len(data); i++ {
+		if data[i] != 0xFF {
+			continue
+		}
+		next := data[i+1]
+		if next != 0x00 && next != 0xFF && (next < 0xD0 || next > 0xD7) {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Entity yang bisa mereferensikan file
+const (
+	FileReferenceEmployee = "employee"
+	FileReferenceUser     = "user"
+)
+
+// Field yang berisi referensi file
+const (
+	FileFieldEmployeeImage = "employee_image"
+	FileFieldUserImage     = "user_image"
+	FileFieldCompanyImage  = "company_image"
+)
+
+// FileReference mencatat entity yang memakai file, file yang masih direferensikan tidak bisa dihapus
+type FileReference struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FileID     uint      `gorm:"not null" json:"file_id"`
+	EntityType string    `gorm:"size:32;not null" json:"entity_type"` // employee, user
+	EntityID   uint      `gorm:"not null" json:"entity_id"`           // Primary key entity (bukan identityNumber yang bisa berubah)
+	Field      string    `gorm:"size:32;not null" json:"field"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ImageLink adalah hasil resolve input gambar dari client (file ID atau URI file milik sendiri)
+type ImageLink struct {
+	FileID uint   // 0 jika gambar dikosongkan
+	URI    string // URI yang disimpan di entity
+}
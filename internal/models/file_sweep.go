@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Alasan object dihapus oleh file sweeper
+const (
+	SweepReasonExpiredUpload   = "expired_upload"   // Presigned upload tidak pernah di-complete
+	SweepReasonUnreferenced    = "unreferenced"     // File tidak dipakai employee, user atau company
+	SweepReasonUntrackedObject = "untracked_object" // Object di storage tanpa record di database
+)
+
+// FileSweepReport berisi hasil satu kali jalan file sweeper
+type FileSweepReport struct {
+	DryRun         bool          `json:"dryRun"`
+	GracePeriod    string        `json:"gracePeriod"`
+	StartedAt      time.Time     `json:"startedAt"`
+	FinishedAt     time.Time     `json:"finishedAt"`
+	ScannedObjects int           `json:"scannedObjects"`
+	RemovedFiles   int           `json:"removedFiles"`
+	RemovedBytes   int64         `json:"removedBytes"`
+	Removed        []SweptObject `json:"removed"`
+	Errors         []string      `json:"errors,omitempty"`
+}
+
+// SweptObject adalah object yang dihapus (atau akan dihapus saat dry run)
+type SweptObject struct {
+	Key    string `json:"key"`
+	FileID uint   `json:"fileId,omitempty"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
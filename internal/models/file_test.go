@@ -0,0 +1,114 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var testImagePolicy = UploadPolicy{
+	AllowedTypes: []string{"image/jpeg", "image/png"},
+	MaxSize:      1 << 20,
+	MaxWidth:     1024,
+	MaxHeight:    1024,
+}
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for x := 0; x < 32; x++ {
+		img.Set(x, x, color.RGBA{R: 200, A: 255})
+	}
+	return img
+}
+
+// pngChunk membuat satu chunk PNG lengkap dengan CRC
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// testPNGWith menyisipkan chunk tepat sebelum IEND lalu menambahkan trailing setelah akhir file
+func testPNGWith(t *testing.T, chunk []byte, trailing []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	iend := len(data) - 12
+
+	result := append([]byte{}, data[:iend]...)
+	result = append(result, chunk...)
+	result = append(result, data[iend:]...)
+	return append(result, trailing...)
+}
+
+// testJPEGWith menyisipkan segment setelah SOI, scanData sebelum EOI, dan trailing setelah EOI
+func testJPEGWith(t *testing.T, segment []byte, scanData []byte, trailing []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	eoi := len(data) - 2
+
+	result := append([]byte{}, data[:2]...)
+	result = append(result, segment...)
+	result = append(result, data[2:eoi]...)
+	result = append(result, scanData...)
+	result = append(result, data[eoi:]...)
+	return append(result, trailing...)
+}
+
+// jpegComment membuat segment COM berisi text
+func jpegComment(text string) []byte {
+	segment := []byte{0xFF, 0xFE}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(text)+2))
+	return append(segment, text...)
+}
+
+func TestFileValidatorEmbeddedPayload(t *testing.T) {
+	zip := []byte("PK\x03\x04payload")
+
+	tests := []struct {
+		name     string
+		data     []byte
+		rejected bool
+	}{
+		{name: "clean png", data: testPNGWith(t, nil, nil)},
+		{name: "png text chunk", data: testPNGWith(t, pngChunk("tEXt", []byte("Comment\x00<script>alert(1)</script>")), nil), rejected: true},
+		{name: "png unknown ancillary chunk", data: testPNGWith(t, pngChunk("prVt", zip), nil), rejected: true},
+		{name: "zip after png IEND", data: testPNGWith(t, nil, zip), rejected: true},
+		{name: "signature inside png pixel data", data: testPNGWith(t, pngChunk("IDAT", []byte("<SVG PK\x03\x04")), nil)},
+		{name: "clean jpeg", data: testJPEGWith(t, nil, nil, nil)},
+		{name: "jpeg comment", data: testJPEGWith(t, jpegComment("<?php system($_GET[0]);"), nil, nil), rejected: true},
+		{name: "html after jpeg EOI", data: testJPEGWith(t, nil, nil, []byte("<html><body></body></html>")), rejected: true},
+		{name: "signature inside jpeg scan data", data: testJPEGWith(t, nil, []byte("<html PK\x05\x06"), nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &FileValidator{Data: tt.data, Size: int64(len(tt.data)), Policy: testImagePolicy}
+			_, err := validator.Validate()
+
+			if tt.rejected {
+				errs, ok := err.(FileValidationErrors)
+				if !ok || len(errs) == 0 || errs[0].Message != "File contains embedded content" {
+					t.Errorf("Validate() error = %v, want embedded content rejected", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
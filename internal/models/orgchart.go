@@ -0,0 +1,17 @@
+package models
+
+// OrgChartFilter untuk GET /v1/orgchart
+type OrgChartFilter struct {
+	Root         string `query:"root"`         // Identity number employee puncak, kosong = seluruh organisasi
+	DepartmentID string `query:"departmentId"` // Hanya employee di department ini
+	OwnerID      uint   `query:"-"`            // Tenant tempat departmentId dicari, diisi dari user yang login
+}
+
+// OrgChartNode adalah satu employee di struktur organisasi beserta bawahan langsungnya
+type OrgChartNode struct {
+	IdentityNumber string          `json:"identityNumber"`
+	Name           string          `json:"name"`
+	JobTitle       string          `json:"jobTitle"`
+	DepartmentID   string          `json:"departmentId"`
+	Reports        []*OrgChartNode `json:"reports"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// StorageQuota adalah override kuota storage per user (satu user = satu organisasi).
+// QuotaBytes nil berarti tanpa batas, tanpa row berarti memakai kuota default dari config.
+type StorageQuota struct {
+	UserID     uint      `gorm:"primaryKey" json:"userId"`
+	QuotaBytes *int64    `json:"quotaBytes"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// StorageUsage dihitung dari ukuran file yang tercatat (termasuk variant gambar).
+// Upload yang masih pending ikut dihitung supaya upload paralel tidak melewati kuota.
+type StorageUsage struct {
+	UsedBytes    int64
+	PendingBytes int64
+	FileCount    int64
+}
+
+// UpdateStorageQuotaRequest untuk PUT /v1/admin/users/:userId/quota
+type UpdateStorageQuotaRequest struct {
+	QuotaBytes *int64 `json:"quotaBytes" validate:"required_without=Unlimited,omitempty,gte=0"`
+	Unlimited  bool   `json:"unlimited"`
+}
+
+// StorageUsageResponse untuk GET /v1/file/usage, QuotaBytes dan RemainingBytes null jika tanpa batas
+type StorageUsageResponse struct {
+	UsedBytes      int64  `json:"usedBytes"`
+	PendingBytes   int64  `json:"pendingBytes"`
+	FileCount      int64  `json:"fileCount"`
+	QuotaBytes     *int64 `json:"quotaBytes"`
+	RemainingBytes *int64 `json:"remainingBytes"`
+	Override       bool   `json:"override"` // true jika kuota diatur admin
+}
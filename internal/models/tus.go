@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// TusUpload menyimpan state upload resumable (protokol tus 1.0)
+type TusUpload struct {
+	ID          string       `gorm:"primaryKey;size:36" json:"id"` // UUID, bagian dari URL upload
+	UserID      uint         `gorm:"not null" json:"user_id"`
+	FileID      uint         `gorm:"not null" json:"file_id"` // Record file pending, jadi ready saat upload selesai
+	Offset      int64        `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Length      int64        `gorm:"column:upload_length;not null" json:"length"`
+	MultipartID string       `gorm:"size:1024;not null" json:"-"` // Upload ID multipart di storage
+	Parts       []UploadPart `gorm:"type:jsonb;serializer:json;not null" json:"-"`
+	StagingKey  string       `gorm:"size:512;not null" json:"-"` // Object sisa data yang belum cukup untuk satu part
+	StagedSize  int64        `gorm:"not null;default:0" json:"-"`
+	ExpiresAt   time.Time    `gorm:"not null" json:"expires_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// UploadPart adalah part multipart yang sudah tersimpan di storage
+type UploadPart struct {
+	Number int32  `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// TusCreateRequest berisi header Upload-Length dan Upload-Metadata dari POST creation
+type TusCreateRequest struct {
+	Length   int64
+	Filename string // metadata "filename"
+	FileType string // metadata "filetype"
+	Purpose  string // metadata "purpose", default: document
+}
+
+// Expired mengecek apakah upload sudah melewati batas waktu
+func (u *TusUpload) Expired() bool {
+	return time.Now().After(u.ExpiresAt)
+}
+
+// Completed mengecek apakah semua byte sudah diterima
+func (u *TusUpload) Completed() bool {
+	return u.Offset == u.Length
+}
@@ -14,6 +14,7 @@ type User struct {
 	UserImageUri    string    `gorm:"size:255" json:"user_image_uri"`
 	CompanyName     string    `gorm:"size:52" json:"company_name"`
 	CompanyImageUri string    `gorm:"size:255" json:"company_image_uri"`
+	Version         uint      `gorm:"not null;default:1" json:"-"` // Naik setiap update, dipakai untuk ETag
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 
@@ -34,13 +35,16 @@ type AuthResponse struct {
 	Token string `json:"token"`
 }
 
-// UpdateProfileRequest untuk PATCH /v1/user
+// UpdateProfileRequest untuk PATCH /v1/user (merge patch, validasi hanya untuk field yang dikirim)
+// Gambar bisa diisi lewat URI file milik sendiri atau langsung dengan file ID.
 type UpdateProfileRequest struct {
-	Email           string `json:"email" validate:"required,email"`
-	Name            string `json:"name" validate:"required,min=4,max=52"`
-	UserImageUri    string `json:"userImageUri" validate:"omitempty,uri"`
-	CompanyName     string `json:"companyName" validate:"required,min=4,max=52"`
-	CompanyImageUri string `json:"companyImageUri" validate:"omitempty,uri"`
+	Email              string `json:"email" validate:"required,email"`
+	Name               string `json:"name" validate:"required,min=4,max=52"`
+	UserImageUri       string `json:"userImageUri" validate:"omitempty,uri"`
+	UserImageFileId    *uint  `json:"userImageFileId" validate:"omitempty,gt=0"`
+	CompanyName        string `json:"companyName" validate:"required,min=4,max=52"`
+	CompanyImageUri    string `json:"companyImageUri" validate:"omitempty,uri"`
+	CompanyImageFileId *uint  `json:"companyImageFileId" validate:"omitempty,gt=0"`
 }
 
 // ProfileResponse untuk GET & PATCH /v1/user response
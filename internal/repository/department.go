@@ -2,19 +2,43 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type DepartmentRepository interface {
+	NextSequence(ctx context.Context, userID uint, prefix string) (int64, error)
 	Create(ctx context.Context, department *models.Department) error
 	Update(ctx context.Context, department *models.Department) error
-	Delete(ctx context.Context, departmentID string) error
-	FindByDepartmentID(ctx context.Context, departmentID string) (*models.Department, error)
+	Delete(ctx context.Context, userID uint, departmentID string) error
+	FindByDepartmentID(ctx context.Context, userID uint, departmentID string) (*models.Department, error)
+	FindByDepartmentIDForUpdate(ctx context.Context, userID uint, departmentID string) (*models.Department, error)
+	FindByDepartmentIDForShare(ctx context.Context, userID uint, departmentID string) (*models.Department, error)
 	List(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.Department, error)
-	HasEmployees(ctx context.Context, departmentID string) (bool, error)
+	HasEmployees(ctx context.Context, userID uint, departmentID string) (bool, error)
+	ListDeleted(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.Department, error)
+	FindDeletedByDepartmentIDForUpdate(ctx context.Context, userID uint, departmentID string) (*models.Department, error)
+	Restore(ctx context.Context, department *models.Department) error
+	Stream(ctx context.Context, userID uint, filter *models.DepartmentFilter, fn func(*models.Department) error) error
+	IsInDepartmentChain(ctx context.Context, departmentID, parentID uint) (bool, error)
+	HasSubdepartments(ctx context.Context, id uint) (bool, error)
+	ReplaceHeads(ctx context.Context, id uint, employeeIDs []uint) error
+	IsHead(ctx context.Context, userID, ownerID uint, departmentID string) (bool, error)
+}
+
+// parentDepartmentColumn membaca DepartmentID induk yang masih aktif untuk Department.ParentDepartmentID
+const parentDepartmentColumn = `(SELECT p.department_id FROM departments p WHERE p.id = departments.parent_id AND p.deleted_at IS NULL) AS parent_department_id`
+
+// departmentHeadsColumn membaca identity number kepala department yang masih aktif untuk Department.Heads
+const departmentHeadsColumn = `(SELECT COALESCE(json_agg(e.identity_number ORDER BY e.identity_number), '[]') FROM department_heads h JOIN employees e ON e.id = h.employee_id WHERE h.department_id = departments.id AND e.deleted_at IS NULL) AS heads`
+
+// selectDepartment memilih semua kolom department beserta DepartmentID induk dan kepala department
+func selectDepartment(query *gorm.DB) *gorm.DB {
+	return query.Select("departments.*, " + parentDepartmentColumn + ", " + departmentHeadsColumn)
 }
 
 type departmentRepository struct {
@@ -27,42 +51,58 @@ func NewDepartmentRepository(db *gorm.DB) DepartmentRepository {
 	}
 }
 
+// NextSequence mengambil nomor urut DepartmentID berikutnya untuk tenant userID dengan prefix ID
+// tersebut. Counter dibuat saat pertama dipakai, dimulai dari nomor terbesar di department_id yang
+// sudah ada (termasuk yang di trash). Harus dipanggil di transaksi yang sama dengan Create: row
+// counter terkunci sampai commit, jadi request paralel antre dan rollback tidak meninggalkan celah.
+func (r *departmentRepository) NextSequence(ctx context.Context, userID uint, prefix string) (int64, error) {
+	var seq int64
+	err := conn(ctx, r.db).Raw(`
+        INSERT INTO department_id_counters (user_id, prefix, last_value)
+        SELECT CAST(@user AS INTEGER), CAST(@prefix AS VARCHAR), COALESCE(MAX(CAST(SUBSTRING(department_id FROM @offset) AS BIGINT)), 0) + 1
+        FROM departments
+        WHERE user_id = @user AND LEFT(department_id, @length) = @prefix
+            AND SUBSTRING(department_id FROM @offset) ~ '^[0-9]{1,18}$'
+        ON CONFLICT (user_id, prefix) DO UPDATE SET last_value = department_id_counters.last_value + 1
+        RETURNING last_value
+    `, sql.Named("user", userID), sql.Named("prefix", prefix),
+		sql.Named("length", len(prefix)), sql.Named("offset", len(prefix)+1)).Scan(&seq).Error
+	return seq, err
+}
+
 func (r *departmentRepository) Create(ctx context.Context, department *models.Department) error {
-	// Cek apakah department_id sudah ada (termasuk yang soft deleted)
-	var count int64
-	if err := r.db.WithContext(ctx).Unscoped().
-		Model(&models.Department{}).
-		Where("department_id = ?", department.DepartmentID).
-		Count(&count).Error; err != nil {
-		return err
-	}
+	return conn(ctx, r.db).Create(department).Error
+}
 
-	// Jika ditemukan, update ID increment untuk mencegah duplikasi
-	if count > 0 {
-		// Ambil ID terakhir
-		var lastDepartment models.Department
-		if err := r.db.WithContext(ctx).Unscoped().
-			Order("id desc").
-			First(&lastDepartment).Error; err != nil && err != gorm.ErrRecordNotFound {
-			return err
-		}
+// Update menyimpan perubahan dengan optimistic locking berdasarkan kolom version
+func (r *departmentRepository) Update(ctx context.Context, department *models.Department) error {
+	currentVersion := department.Version
+	department.Version++
 
-		// Set ID baru
-		department.DepartmentID = fmt.Sprintf("DEP-%02d", lastDepartment.ID+1)
+	result := conn(ctx, r.db).
+		Model(department).
+		Where("version = ?", currentVersion).
+		Select("*").
+		Omit(clause.Associations, "CreatedAt").
+		Updates(department)
+	if result.Error != nil {
+		department.Version = currentVersion
+		return result.Error
 	}
 
-	// Create department baru
-	return r.db.WithContext(ctx).Create(department).Error
-}
+	// Versi sudah berubah, berarti ada yang mengupdate lebih dulu
+	if result.RowsAffected == 0 {
+		department.Version = currentVersion
+		return errors.New("precondition failed")
+	}
 
-func (r *departmentRepository) Update(ctx context.Context, department *models.Department) error {
-	return r.db.WithContext(ctx).Save(department).Error
+	return nil
 }
 
-func (r *departmentRepository) Delete(ctx context.Context, departmentID string) error {
+func (r *departmentRepository) Delete(ctx context.Context, userID uint, departmentID string) error {
 	// Gunakan Unscoped() jika ingin melihat semua data termasuk yang soft deleted
-	result := r.db.WithContext(ctx).
-		Where("department_id = ?", departmentID).
+	result := conn(ctx, r.db).
+		Where("user_id = ? AND department_id = ?", userID, departmentID).
 		Delete(&models.Department{}) // GORM akan otomatis mengisi deleted_at
 
 	if result.Error != nil {
@@ -76,12 +116,13 @@ func (r *departmentRepository) Delete(ctx context.Context, departmentID string)
 	return nil
 }
 
-func (r *departmentRepository) FindByDepartmentID(ctx context.Context, departmentID string) (*models.Department, error) {
+// FindByDepartmentID mencari department aktif milik tenant userID, DepartmentID hanya unik per tenant
+func (r *departmentRepository) FindByDepartmentID(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
 	var department models.Department
 
 	// Tambahkan Unscoped() jika ingin melihat semua data termasuk yang soft deleted
-	err := r.db.WithContext(ctx).
-		Where("department_id = ? AND deleted_at IS NULL", departmentID). // Tambahkan pengecekan deleted_at
+	err := selectDepartment(conn(ctx, r.db)).
+		Where("user_id = ? AND department_id = ? AND deleted_at IS NULL", userID, departmentID). // Tambahkan pengecekan deleted_at
 		First(&department).Error
 
 	if err == gorm.ErrRecordNotFound {
@@ -90,14 +131,33 @@ func (r *departmentRepository) FindByDepartmentID(ctx context.Context, departmen
 	return &department, err
 }
 
-func (r *departmentRepository) List(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.Department, error) {
-	var departments []*models.Department
-	query := r.db.WithContext(ctx).Where("user_id = ? AND deleted_at IS NULL", userID)
+// FindByDepartmentIDForUpdate mengunci row department (SELECT ... FOR UPDATE), dipakai di dalam transaksi
+func (r *departmentRepository) FindByDepartmentIDForUpdate(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
+	return r.findLocked(ctx, userID, departmentID, "UPDATE")
+}
 
-	// Filter by name (prefix-suffix search, case insensitive)
-	if filter.Name != "" {
-		query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
+// FindByDepartmentIDForShare mengunci row department (SELECT ... FOR SHARE) agar tidak dihapus
+// selama transaksi berjalan, tanpa memblokir pembaca lain
+func (r *departmentRepository) FindByDepartmentIDForShare(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
+	return r.findLocked(ctx, userID, departmentID, "SHARE")
+}
+
+func (r *departmentRepository) findLocked(ctx context.Context, userID uint, departmentID string, strength string) (*models.Department, error) {
+	var department models.Department
+	err := selectDepartment(conn(ctx, r.db)).
+		Clauses(clause.Locking{Strength: strength}).
+		Where("user_id = ? AND department_id = ?", userID, departmentID).
+		First(&department).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
 	}
+	return &department, err
+}
+
+func (r *departmentRepository) List(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.Department, error) {
+	var departments []*models.Department
+	query := applyDepartmentFilter(selectDepartment(conn(ctx, r.db)), userID, filter)
 
 	// Apply pagination
 	query = query.Limit(filter.Limit).Offset(filter.Offset)
@@ -109,10 +169,179 @@ func (r *departmentRepository) List(ctx context.Context, userID uint, filter *mo
 	return departments, err
 }
 
-func (r *departmentRepository) HasEmployees(ctx context.Context, departmentID string) (bool, error) {
+// Stream memanggil fn untuk setiap department milik user yang cocok dengan filter tanpa
+// memuat semuanya ke memori. Limit dan Offset hanya diterapkan jika lebih dari 0.
+func (r *departmentRepository) Stream(ctx context.Context, userID uint, filter *models.DepartmentFilter, fn func(*models.Department) error) error {
+	query := applyDepartmentFilter(selectDepartment(conn(ctx, r.db).Model(&models.Department{})), userID, filter)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	rows, err := query.Order("id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var department models.Department
+		if err := query.ScanRows(rows, &department); err != nil {
+			return err
+		}
+		if err := fn(&department); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// IsInDepartmentChain mengecek apakah departmentID ada di rantai induk parentID (termasuk parentID
+// sendiri). Dipakai sebelum mengubah induk supaya tidak terbentuk siklus.
+func (r *departmentRepository) IsInDepartmentChain(ctx context.Context, departmentID, parentID uint) (bool, error) {
+	var found bool
+	err := conn(ctx, r.db).Raw(`
+        WITH RECURSIVE chain AS (
+            SELECT id, parent_id FROM departments WHERE id = ?
+            UNION
+            SELECT d.id, d.parent_id FROM departments d JOIN chain c ON d.id = c.parent_id
+        )
+        SELECT EXISTS (SELECT 1 FROM chain WHERE id = ?)
+    `, parentID, departmentID).Scan(&found).Error
+	return found, err
+}
+
+// HasSubdepartments mengecek apakah department masih punya sub-department aktif
+func (r *departmentRepository) HasSubdepartments(ctx context.Context, id uint) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.Employee{}).
-		Where("department_id = ?", departmentID).
+	err := conn(ctx, r.db).Model(&models.Department{}).
+		Where("parent_id = ?", id).
 		Count(&count).Error
 	return count > 0, err
 }
+
+// ReplaceHeads mengganti semua kepala department dengan employeeIDs
+func (r *departmentRepository) ReplaceHeads(ctx context.Context, id uint, employeeIDs []uint) error {
+	db := conn(ctx, r.db)
+	if err := db.Where("department_id = ?", id).Delete(&models.DepartmentHead{}).Error; err != nil {
+		return err
+	}
+	if len(employeeIDs) == 0 {
+		return nil
+	}
+
+	heads := make([]models.DepartmentHead, 0, len(employeeIDs))
+	for _, employeeID := range employeeIDs {
+		heads = append(heads, models.DepartmentHead{DepartmentID: id, EmployeeID: employeeID})
+	}
+	return db.Create(&heads).Error
+}
+
+// IsHead mengecek apakah user terhubung ke employee aktif yang menjadi kepala department
+// milik tenant ownerID tersebut atau salah satu department induknya
+func (r *departmentRepository) IsHead(ctx context.Context, userID, ownerID uint, departmentID string) (bool, error) {
+	var found bool
+	err := conn(ctx, r.db).Raw(`
+        WITH RECURSIVE chain AS (
+            SELECT id, parent_id FROM departments WHERE user_id = ? AND department_id = ? AND deleted_at IS NULL
+            UNION
+            SELECT d.id, d.parent_id FROM departments d JOIN chain c ON d.id = c.parent_id WHERE d.deleted_at IS NULL
+        )
+        SELECT EXISTS (
+            SELECT 1 FROM department_heads h
+            JOIN chain c ON c.id = h.department_id
+            JOIN employees e ON e.id = h.employee_id
+            WHERE e.user_id = ? AND e.deleted_at IS NULL
+        )
+    `, ownerID, departmentID, userID).Scan(&found).Error
+	return found, err
+}
+
+// applyDepartmentFilter menerapkan filter query parameter GET /v1/department, pagination diatur pemanggil
+func applyDepartmentFilter(query *gorm.DB, userID uint, filter *models.DepartmentFilter) *gorm.DB {
+	query = query.Where("user_id = ? AND deleted_at IS NULL", userID)
+
+	// Filter by name (prefix-suffix search, case insensitive)
+	if filter.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
+	}
+
+	// Department yang diarsipkan disembunyikan kecuali diminta
+	switch filter.Status {
+	case models.DepartmentStatusArchived:
+		query = query.Where("archived_at IS NOT NULL")
+	case models.DepartmentStatusAll:
+	default:
+		query = query.Where("archived_at IS NULL")
+	}
+
+	return query
+}
+
+func (r *departmentRepository) HasEmployees(ctx context.Context, userID uint, departmentID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.Employee{}).
+		Where("owner_id = ? AND department_id = ?", userID, departmentID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListDeleted mengembalikan department milik user yang sudah dihapus, yang terakhir dihapus lebih dulu
+func (r *departmentRepository) ListDeleted(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.Department, error) {
+	var departments []*models.Department
+	query := selectDepartment(conn(ctx, r.db)).Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+
+	if filter.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
+	}
+
+	err := query.
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Order("deleted_at DESC").
+		Order("id DESC").
+		Find(&departments).Error
+	return departments, err
+}
+
+// FindDeletedByDepartmentIDForUpdate mengunci department terhapus dengan DepartmentID tersebut,
+// yang terakhir dihapus diambil jika ada lebih dari satu
+func (r *departmentRepository) FindDeletedByDepartmentIDForUpdate(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
+	var department models.Department
+	err := selectDepartment(conn(ctx, r.db)).
+		Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND department_id = ? AND deleted_at IS NOT NULL", userID, departmentID).
+		Order("deleted_at DESC").
+		First(&department).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &department, err
+}
+
+// Restore mengembalikan department yang terhapus, version ikut naik supaya ETag lama tidak berlaku
+func (r *departmentRepository) Restore(ctx context.Context, department *models.Department) error {
+	result := conn(ctx, r.db).
+		Unscoped().
+		Model(&models.Department{}).
+		Where("id = ? AND deleted_at IS NOT NULL", department.ID).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("department not found")
+	}
+
+	department.DeletedAt = gorm.DeletedAt{}
+	department.Version++
+	return nil
+}
@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type EmployeeRepository interface {
@@ -11,8 +15,37 @@ type EmployeeRepository interface {
 	Update(ctx context.Context, employee *models.Employee) error
 	Delete(ctx context.Context, identityNumber string) error
 	FindByIdentityNumber(ctx context.Context, identityNumber string) (*models.Employee, error)
+	FindByIdentityNumberForUpdate(ctx context.Context, identityNumber string) (*models.Employee, error)
+	FindByWorkEmail(ctx context.Context, workEmail string) (*models.Employee, error)
 	List(ctx context.Context, filter *models.EmployeeFilter) ([]*models.Employee, error)
 	CheckIdentityExists(ctx context.Context, identityNumber string, excludeID uint) (bool, error)
+	ListDeleted(ctx context.Context, userID uint, filter *models.EmployeeFilter) ([]*models.Employee, error)
+	FindDeletedByIdentityNumberForUpdate(ctx context.Context, identityNumber string) (*models.Employee, error)
+	Restore(ctx context.Context, employee *models.Employee) error
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.Employee, error)
+	Purge(ctx context.Context, id uint) error
+	ListIdentityNumbersByDepartmentForUpdate(ctx context.Context, ownerID uint, departmentID string) ([]string, error)
+	ReassignDepartment(ctx context.Context, ownerID uint, fromDepartmentID, toDepartmentID string) error
+	DeleteByDepartment(ctx context.Context, ownerID uint, departmentID string) error
+	Stream(ctx context.Context, filter *models.EmployeeFilter, fn func(*models.Employee) error) error
+	IsInManagementChain(ctx context.Context, employeeID, managerID uint) (bool, error)
+	ListReports(ctx context.Context, managerID uint, maxLevel int) ([]*models.EmployeeReport, error)
+	ListOrgChart(ctx context.Context, ownerID uint, departmentID string) ([]*models.Employee, error)
+	FindByUserID(ctx context.Context, userID uint) (*models.Employee, error)
+	FindByIDForUpdate(ctx context.Context, id uint) (*models.Employee, error)
+}
+
+// managerIdentityColumn membaca identity number atasan aktif untuk Employee.ManagerIdentityNumber.
+// Subquery dipakai (bukan join) supaya kondisi tanpa nama tabel tetap tidak ambigu dan FOR UPDATE
+// hanya mengunci row employee.
+const managerIdentityColumn = `(SELECT m.identity_number FROM employees m WHERE m.id = employees.manager_id AND m.deleted_at IS NULL) AS manager_identity_number`
+
+// userEmailColumn membaca email akun user untuk Employee.UserEmail
+const userEmailColumn = `(SELECT u.email FROM users u WHERE u.id = employees.user_id) AS user_email`
+
+// selectEmployee memilih semua kolom employee beserta identity number atasan dan email akun user
+func selectEmployee(query *gorm.DB) *gorm.DB {
+	return query.Select("employees.*, " + managerIdentityColumn + ", " + userEmailColumn)
 }
 
 type employeeRepository struct {
@@ -26,20 +59,88 @@ func NewEmployeeRepository(db *gorm.DB) EmployeeRepository {
 }
 
 func (r *employeeRepository) Create(ctx context.Context, employee *models.Employee) error {
-	return r.db.WithContext(ctx).Create(employee).Error
+	return conn(ctx, r.db).Create(employee).Error
 }
 
+// Update menyimpan perubahan dengan optimistic locking berdasarkan kolom version
 func (r *employeeRepository) Update(ctx context.Context, employee *models.Employee) error {
-	return r.db.WithContext(ctx).Save(employee).Error
+	currentVersion := employee.Version
+	employee.Version++
+
+	result := conn(ctx, r.db).
+		Model(employee).
+		Where("version = ?", currentVersion).
+		Select("*").
+		Omit(clause.Associations, "CreatedAt").
+		Updates(employee)
+	if result.Error != nil {
+		employee.Version = currentVersion
+		return result.Error
+	}
+
+	// Versi sudah berubah, berarti ada yang mengupdate lebih dulu
+	if result.RowsAffected == 0 {
+		employee.Version = currentVersion
+		return errors.New("precondition failed")
+	}
+
+	return nil
 }
 
+// Delete memindahkan employee ke trash (soft delete, GORM mengisi deleted_at)
 func (r *employeeRepository) Delete(ctx context.Context, identityNumber string) error {
-	return r.db.WithContext(ctx).Where("identity_number = ?", identityNumber).Delete(&models.Employee{}).Error
+	return conn(ctx, r.db).Where("identity_number = ?", identityNumber).Delete(&models.Employee{}).Error
 }
 
 func (r *employeeRepository) FindByIdentityNumber(ctx context.Context, identityNumber string) (*models.Employee, error) {
 	var employee models.Employee
-	err := r.db.WithContext(ctx).Where("identity_number = ?", identityNumber).First(&employee).Error
+	err := selectEmployee(conn(ctx, r.db)).Where("identity_number = ?", identityNumber).First(&employee).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &employee, err
+}
+
+// FindByIdentityNumberForUpdate mengunci row employee (SELECT ... FOR UPDATE), dipakai di dalam transaksi
+func (r *employeeRepository) FindByIdentityNumberForUpdate(ctx context.Context, identityNumber string) (*models.Employee, error) {
+	var employee models.Employee
+	err := selectEmployee(conn(ctx, r.db)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("identity_number = ?", identityNumber).
+		First(&employee).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &employee, err
+}
+
+// FindByIDForUpdate mencari employee aktif berdasarkan primary key dan mengunci row-nya
+func (r *employeeRepository) FindByIDForUpdate(ctx context.Context, id uint) (*models.Employee, error) {
+	var employee models.Employee
+	err := selectEmployee(conn(ctx, r.db)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&employee).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &employee, err
+}
+
+// FindByWorkEmail mencari employee aktif dengan work email tersebut (case insensitive)
+func (r *employeeRepository) FindByWorkEmail(ctx context.Context, workEmail string) (*models.Employee, error) {
+	var employee models.Employee
+	err := conn(ctx, r.db).Where("LOWER(work_email) = LOWER(?)", workEmail).First(&employee).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &employee, err
+}
+
+// FindByUserID mencari employee aktif yang terhubung ke akun user
+func (r *employeeRepository) FindByUserID(ctx context.Context, userID uint) (*models.Employee, error) {
+	var employee models.Employee
+	err := conn(ctx, r.db).Where("user_id = ?", userID).First(&employee).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -48,8 +149,223 @@ func (r *employeeRepository) FindByIdentityNumber(ctx context.Context, identityN
 
 func (r *employeeRepository) List(ctx context.Context, filter *models.EmployeeFilter) ([]*models.Employee, error) {
 	var employees []*models.Employee
-	query := r.db.WithContext(ctx)
+	query := applyEmployeeFilter(selectEmployee(conn(ctx, r.db)), filter)
+
+	// Apply pagination
+	query = query.Limit(filter.Limit).Offset(filter.Offset)
+
+	// Order by identity_number
+	query = query.Order("identity_number ASC")
+
+	err := query.Find(&employees).Error
+	return employees, err
+}
+
+// ListDeleted mengembalikan employee di trash yang boleh diakses userID, yang terakhir dihapus lebih dulu.
+// Pemilik melihat employee di semua department miliknya (termasuk yang di trash), kepala department
+// melihat employee di department yang dikepalainya beserta sub-department-nya.
+func (r *employeeRepository) ListDeleted(ctx context.Context, userID uint, filter *models.EmployeeFilter) ([]*models.Employee, error) {
+	var employees []*models.Employee
+	query := selectEmployee(conn(ctx, r.db)).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where(`(owner_id, department_id) IN (
+            WITH RECURSIVE accessible AS (
+                SELECT id, user_id, department_id FROM departments WHERE user_id = @user
+                UNION
+                SELECT d.id, d.user_id, d.department_id FROM departments d
+                JOIN department_heads h ON h.department_id = d.id
+                JOIN employees e ON e.id = h.employee_id
+                WHERE e.user_id = @user AND e.deleted_at IS NULL AND d.deleted_at IS NULL
+                UNION
+                SELECT d.id, d.user_id, d.department_id FROM departments d JOIN accessible a ON d.parent_id = a.id WHERE d.deleted_at IS NULL
+            )
+            SELECT user_id, department_id FROM accessible
+        )`, sql.Named("user", userID))
+	query = applyEmployeeFilter(query, filter)
+	query = query.Limit(filter.Limit).Offset(filter.Offset)
+
+	err := query.Order("deleted_at DESC").Order("id DESC").Find(&employees).Error
+	return employees, err
+}
 
+// FindDeletedByIdentityNumberForUpdate mengunci employee di trash dengan identity number tersebut.
+// Jika identity number yang sama sudah beberapa kali dihapus, yang terakhir dihapus yang diambil.
+func (r *employeeRepository) FindDeletedByIdentityNumberForUpdate(ctx context.Context, identityNumber string) (*models.Employee, error) {
+	var employee models.Employee
+	err := selectEmployee(conn(ctx, r.db)).
+		Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("identity_number = ? AND deleted_at IS NOT NULL", identityNumber).
+		Order("deleted_at DESC").
+		First(&employee).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &employee, err
+}
+
+// Restore mengeluarkan employee dari trash, version ikut naik supaya ETag lama tidak berlaku
+func (r *employeeRepository) Restore(ctx context.Context, employee *models.Employee) error {
+	result := conn(ctx, r.db).
+		Unscoped().
+		Model(&models.Employee{}).
+		Where("id = ? AND deleted_at IS NOT NULL", employee.ID).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("employee not found")
+	}
+
+	employee.DeletedAt = gorm.DeletedAt{}
+	employee.Version++
+	return nil
+}
+
+// ListDeletedBefore mengembalikan employee yang sudah di trash sejak sebelum waktu tertentu
+func (r *employeeRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]*models.Employee, error) {
+	var employees []*models.Employee
+	err := conn(ctx, r.db).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&employees).Error
+	return employees, err
+}
+
+// Purge menghapus permanen employee yang ada di trash
+func (r *employeeRepository) Purge(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Delete(&models.Employee{}).Error
+}
+
+// ListIdentityNumbersByDepartmentForUpdate mengunci semua employee aktif di department,
+// dipakai sebelum memindahkan atau menghapus employee sekaligus
+func (r *employeeRepository) ListIdentityNumbersByDepartmentForUpdate(ctx context.Context, ownerID uint, departmentID string) ([]string, error) {
+	var identityNumbers []string
+	err := conn(ctx, r.db).
+		Model(&models.Employee{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("owner_id = ? AND department_id = ?", ownerID, departmentID).
+		Order("identity_number ASC").
+		Pluck("identity_number", &identityNumbers).Error
+	return identityNumbers, err
+}
+
+// ReassignDepartment memindahkan semua employee aktif ke department lain di tenant yang sama,
+// version ikut naik supaya ETag lama tidak berlaku
+func (r *employeeRepository) ReassignDepartment(ctx context.Context, ownerID uint, fromDepartmentID, toDepartmentID string) error {
+	return conn(ctx, r.db).
+		Model(&models.Employee{}).
+		Where("owner_id = ? AND department_id = ?", ownerID, fromDepartmentID).
+		Updates(map[string]interface{}{
+			"department_id": toDepartmentID,
+			"version":       gorm.Expr("version + 1"),
+			"updated_at":    time.Now(),
+		}).Error
+}
+
+// DeleteByDepartment memindahkan semua employee aktif di department ke trash, version ikut naik
+// supaya ETag lama tidak berlaku
+func (r *employeeRepository) DeleteByDepartment(ctx context.Context, ownerID uint, departmentID string) error {
+	now := time.Now()
+	return conn(ctx, r.db).
+		Model(&models.Employee{}).
+		Where("owner_id = ? AND department_id = ?", ownerID, departmentID).
+		Updates(map[string]interface{}{
+			"deleted_at": now,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": now,
+		}).Error
+}
+
+// Stream memanggil fn untuk setiap employee yang cocok dengan filter tanpa memuat semuanya ke
+// memori. Limit dan Offset hanya diterapkan jika lebih dari 0.
+func (r *employeeRepository) Stream(ctx context.Context, filter *models.EmployeeFilter, fn func(*models.Employee) error) error {
+	query := applyEmployeeFilter(selectEmployee(conn(ctx, r.db).Model(&models.Employee{})), filter)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	rows, err := query.Order("identity_number ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var employee models.Employee
+		if err := query.ScanRows(rows, &employee); err != nil {
+			return err
+		}
+		if err := fn(&employee); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// IsInManagementChain mengecek apakah employeeID ada di rantai atasan managerID (termasuk managerID
+// sendiri). Dipakai sebelum mengubah atasan supaya tidak terbentuk siklus.
+func (r *employeeRepository) IsInManagementChain(ctx context.Context, employeeID, managerID uint) (bool, error) {
+	var found bool
+	err := conn(ctx, r.db).Raw(`
+        WITH RECURSIVE chain AS (
+            SELECT id, manager_id FROM employees WHERE id = ?
+            UNION
+            SELECT e.id, e.manager_id FROM employees e JOIN chain c ON e.id = c.manager_id
+        )
+        SELECT EXISTS (SELECT 1 FROM chain WHERE id = ?)
+    `, managerID, employeeID).Scan(&found).Error
+	return found, err
+}
+
+// ListReports mengembalikan bawahan aktif managerID sampai maxLevel tingkat (0 = semua tingkat),
+// diurutkan per tingkat. path mencegah loop jika data lama sudah terlanjur membentuk siklus.
+func (r *employeeRepository) ListReports(ctx context.Context, managerID uint, maxLevel int) ([]*models.EmployeeReport, error) {
+	var reports []*models.EmployeeReport
+	err := conn(ctx, r.db).Raw(`
+        WITH RECURSIVE reports AS (
+            SELECT id, 1 AS level, ARRAY[id] AS path
+            FROM employees
+            WHERE manager_id = @manager AND deleted_at IS NULL
+            UNION ALL
+            SELECT e.id, r.level + 1, r.path || e.id
+            FROM employees e JOIN reports r ON e.manager_id = r.id
+            WHERE e.deleted_at IS NULL AND e.id <> ALL(r.path) AND (@max_level = 0 OR r.level < @max_level)
+        )
+        SELECT employees.*, `+managerIdentityColumn+`, `+userEmailColumn+`, reports.level
+        FROM employees JOIN reports ON employees.id = reports.id
+        ORDER BY reports.level ASC, employees.identity_number ASC
+    `, sql.Named("manager", managerID), sql.Named("max_level", maxLevel)).Scan(&reports).Error
+	return reports, err
+}
+
+// ListOrgChart mengembalikan semua employee aktif (atau yang ada di satu department) dengan kolom
+// yang dibutuhkan untuk menyusun struktur organisasi. departmentID dicari di tenant ownerID.
+func (r *employeeRepository) ListOrgChart(ctx context.Context, ownerID uint, departmentID string) ([]*models.Employee, error) {
+	var employees []*models.Employee
+	query := conn(ctx, r.db).Select("id", "identity_number", "name", "job_title", "department_id", "owner_id", "manager_id")
+	if departmentID != "" {
+		query = query.Where("owner_id = ? AND department_id = ?", ownerID, departmentID)
+	}
+	err := query.Order("identity_number ASC").Find(&employees).Error
+	return employees, err
+}
+
+// applyEmployeeFilter menerapkan filter query parameter GET /v1/employee, pagination diatur pemanggil
+func applyEmployeeFilter(query *gorm.DB, filter *models.EmployeeFilter) *gorm.DB {
 	// Filter by identity number (prefix search)
 	if filter.IdentityNumber != "" {
 		query = query.Where("identity_number ILIKE ?", filter.IdentityNumber+"%")
@@ -65,24 +381,44 @@ func (r *employeeRepository) List(ctx context.Context, filter *models.EmployeeFi
 		query = query.Where("gender = ?", filter.Gender)
 	}
 
-	// Filter by department
-	if filter.DepartmentID != "" {
-		query = query.Where("department_id = ?", filter.DepartmentID)
+	// Filter by department di tenant filter.OwnerID, opsional beserta semua sub-department di bawahnya
+	if filter.DepartmentID != "" && filter.IncludeSubdepartments {
+		query = query.Where("owner_id = ?", filter.OwnerID).Where(`department_id IN (
+            WITH RECURSIVE tree AS (
+                SELECT id, department_id FROM departments WHERE user_id = ? AND department_id = ? AND deleted_at IS NULL
+                UNION
+                SELECT d.id, d.department_id FROM departments d JOIN tree t ON d.parent_id = t.id WHERE d.deleted_at IS NULL
+            )
+            SELECT department_id FROM tree
+        )`, filter.OwnerID, filter.DepartmentID)
+	} else if filter.DepartmentID != "" {
+		query = query.Where("owner_id = ? AND department_id = ?", filter.OwnerID, filter.DepartmentID)
 	}
 
-	// Apply pagination
-	query = query.Limit(filter.Limit).Offset(filter.Offset)
+	// Filter by job title (prefix-suffix search, case insensitive)
+	if filter.JobTitle != "" {
+		query = query.Where("job_title ILIKE ?", "%"+filter.JobTitle+"%")
+	}
 
-	// Order by identity_number
-	query = query.Order("identity_number ASC")
+	// Filter by employment type
+	if filter.EmploymentType != "" {
+		query = query.Where("employment_type = ?", filter.EmploymentType)
+	}
 
-	err := query.Find(&employees).Error
-	return employees, err
+	// Filter by range hire date, employee tanpa hire date tidak ikut
+	if filter.HiredFrom != "" {
+		query = query.Where("hire_date >= ?", filter.HiredFrom)
+	}
+	if filter.HiredTo != "" {
+		query = query.Where("hire_date <= ?", filter.HiredTo)
+	}
+
+	return query
 }
 
 func (r *employeeRepository) CheckIdentityExists(ctx context.Context, identityNumber string, excludeID uint) (bool, error) {
 	var count int64
-	query := r.db.WithContext(ctx).Model(&models.Employee{}).Where("identity_number = ?", identityNumber)
+	query := conn(ctx, r.db).Model(&models.Employee{}).Where("identity_number = ?", identityNumber)
 
 	if excludeID != 0 {
 		query = query.Where("id != ?", excludeID)
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type EmployeeHistoryRepository interface {
+	Record(ctx context.Context, entry *models.EmployeeHistory) error
+	RecordDepartmentReassignment(ctx context.Context, ownerID uint, fromDepartmentID, toDepartmentID string, effectiveFrom time.Time) error
+	FindCurrent(ctx context.Context, employeeID uint) (*models.EmployeeHistory, error)
+	ListByEmployee(ctx context.Context, employeeID uint) ([]*models.EmployeeHistory, error)
+	CreateTransfer(ctx context.Context, transfer *models.EmployeeTransfer) error
+	UpdateTransfer(ctx context.Context, transfer *models.EmployeeTransfer) error
+	FindTransferForUpdate(ctx context.Context, id uint) (*models.EmployeeTransfer, error)
+	ListTransfersByEmployee(ctx context.Context, employeeID uint) ([]*models.EmployeeTransfer, error)
+	ListDueTransfers(ctx context.Context, date time.Time, limit int) ([]*models.EmployeeTransfer, error)
+	Backfill(ctx context.Context, limit int) (int64, error)
+}
+
+// Identity number atasan dibaca tanpa filter deleted_at, history tetap menunjukkan atasan yang sudah di trash
+const (
+	historyManagerColumn  = `(SELECT m.identity_number FROM employees m WHERE m.id = employee_histories.manager_id) AS manager_identity_number`
+	transferManagerColumn = `(SELECT m.identity_number FROM employees m WHERE m.id = employee_transfers.manager_id) AS manager_identity_number`
+)
+
+type employeeHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewEmployeeHistoryRepository(db *gorm.DB) EmployeeHistoryRepository {
+	return &employeeHistoryRepository{
+		db: db,
+	}
+}
+
+// Record menutup periode yang sedang berjalan dan memulai periode baru pada entry.EffectiveFrom.
+// Periode berjalan yang dimulai pada tanggal yang sama atau sesudahnya diganti oleh entry.
+func (r *employeeHistoryRepository) Record(ctx context.Context, entry *models.EmployeeHistory) error {
+	db := conn(ctx, r.db)
+	if err := db.Where("employee_id = ? AND effective_to IS NULL AND effective_from >= ?", entry.EmployeeID, entry.EffectiveFrom).
+		Delete(&models.EmployeeHistory{}).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.EmployeeHistory{}).
+		Where("employee_id = ? AND effective_to IS NULL", entry.EmployeeID).
+		Update("effective_to", entry.EffectiveFrom).Error; err != nil {
+		return err
+	}
+	return db.Create(entry).Error
+}
+
+// RecordDepartmentReassignment mencatat perpindahan semua employee aktif tenant ownerID dari
+// fromDepartmentID ke toDepartmentID, dipanggil sebelum department employee diubah
+func (r *employeeHistoryRepository) RecordDepartmentReassignment(ctx context.Context, ownerID uint, fromDepartmentID, toDepartmentID string, effectiveFrom time.Time) error {
+	db := conn(ctx, r.db)
+	employeeIDs := db.Model(&models.Employee{}).Select("id").Where("owner_id = ? AND department_id = ?", ownerID, fromDepartmentID)
+
+	if err := db.Where("employee_id IN (?) AND effective_to IS NULL AND effective_from >= ?", employeeIDs, effectiveFrom).
+		Delete(&models.EmployeeHistory{}).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.EmployeeHistory{}).
+		Where("employee_id IN (?) AND effective_to IS NULL", employeeIDs).
+		Update("effective_to", effectiveFrom).Error; err != nil {
+		return err
+	}
+	return db.Exec(`
+        INSERT INTO employee_histories (employee_id, department_id, job_title, manager_id, effective_from, created_at)
+        SELECT id, ?, job_title, manager_id, ?, NOW()
+        FROM employees
+        WHERE owner_id = ? AND department_id = ? AND deleted_at IS NULL
+    `, toDepartmentID, effectiveFrom, ownerID, fromDepartmentID).Error
+}
+
+// FindCurrent mengembalikan periode employee yang sedang berjalan, nil jika belum punya history
+func (r *employeeHistoryRepository) FindCurrent(ctx context.Context, employeeID uint) (*models.EmployeeHistory, error) {
+	var entry models.EmployeeHistory
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND effective_to IS NULL", employeeID).
+		Order("effective_from DESC").
+		First(&entry).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListByEmployee mengembalikan semua periode employee, yang terbaru lebih dulu
+func (r *employeeHistoryRepository) ListByEmployee(ctx context.Context, employeeID uint) ([]*models.EmployeeHistory, error) {
+	var history []*models.EmployeeHistory
+	err := conn(ctx, r.db).
+		Select("employee_histories.*, "+historyManagerColumn).
+		Where("employee_id = ?", employeeID).
+		Order("effective_from DESC, id DESC").
+		Find(&history).Error
+	return history, err
+}
+
+func (r *employeeHistoryRepository) CreateTransfer(ctx context.Context, transfer *models.EmployeeTransfer) error {
+	return conn(ctx, r.db).Create(transfer).Error
+}
+
+func (r *employeeHistoryRepository) UpdateTransfer(ctx context.Context, transfer *models.EmployeeTransfer) error {
+	return conn(ctx, r.db).
+		Model(transfer).
+		Select("status", "failure_reason", "applied_at", "updated_at").
+		Updates(transfer).Error
+}
+
+func (r *employeeHistoryRepository) FindTransferForUpdate(ctx context.Context, id uint) (*models.EmployeeTransfer, error) {
+	var transfer models.EmployeeTransfer
+	err := conn(ctx, r.db).
+		Select("employee_transfers.*, "+transferManagerColumn).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&transfer).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &transfer, err
+}
+
+// ListTransfersByEmployee mengembalikan semua transfer employee, tanggal berlaku terbaru lebih dulu
+func (r *employeeHistoryRepository) ListTransfersByEmployee(ctx context.Context, employeeID uint) ([]*models.EmployeeTransfer, error) {
+	var transfers []*models.EmployeeTransfer
+	err := conn(ctx, r.db).
+		Select("employee_transfers.*, "+transferManagerColumn).
+		Where("employee_id = ?", employeeID).
+		Order("effective_date DESC, id DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// ListDueTransfers mengembalikan transfer pending yang tanggal berlakunya sudah lewat atau hari ini,
+// yang paling lama lebih dulu supaya transfer berurutan diterapkan sesuai urutannya
+func (r *employeeHistoryRepository) ListDueTransfers(ctx context.Context, date time.Time, limit int) ([]*models.EmployeeTransfer, error) {
+	var transfers []*models.EmployeeTransfer
+	err := conn(ctx, r.db).
+		Where("status = ? AND effective_date <= ?", models.TransferStatusPending, date).
+		Order("effective_date ASC, id ASC").
+		Limit(limit).
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// Backfill memberi maksimal limit employee yang belum punya history (dibuat sebelum ada history)
+// satu periode mulai tanggal masuk atau tanggal dibuat. Return jumlah employee yang diisi.
+func (r *employeeHistoryRepository) Backfill(ctx context.Context, limit int) (int64, error) {
+	result := conn(ctx, r.db).Exec(`
+        INSERT INTO employee_histories (employee_id, department_id, job_title, manager_id, effective_from)
+        SELECT e.id, e.department_id, e.job_title, e.manager_id, COALESCE(e.hire_date, e.created_at::date, CURRENT_DATE)
+        FROM employees e
+        WHERE NOT EXISTS (SELECT 1 FROM employee_histories h WHERE h.employee_id = e.id)
+        ORDER BY e.id
+        LIMIT @limit
+    `, sql.Named("limit", limit))
+	return result.RowsAffected, result.Error
+}
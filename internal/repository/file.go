@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type FileRepository interface {
+	NextID(ctx context.Context) (uint, error)
+	Create(ctx context.Context, file *models.File) error
+	FindByID(ctx context.Context, id uint) (*models.File, error)
+	FindByIDForUpdate(ctx context.Context, id uint) (*models.File, error)
+	FindByStorageKey(ctx context.Context, key string) (*models.File, error)
+	Update(ctx context.Context, file *models.File) error
+	CreateVariants(ctx context.Context, variants []models.FileVariant) error
+	ListByUser(ctx context.Context, userID uint, filter *models.FileFilter) ([]*models.File, error)
+	Delete(ctx context.Context, id uint) error
+	ListUnreferenced(ctx context.Context, createdBefore time.Time, afterID uint, limit int) ([]*models.File, error)
+	TrackedKeys(ctx context.Context, keys []string) (map[string]bool, error)
+}
+
+type fileRepository struct {
+	db *gorm.DB
+}
+
+func NewFileRepository(db *gorm.DB) FileRepository {
+	return &fileRepository{
+		db: db,
+	}
+}
+
+// NextID mengambil ID file berikutnya dari sequence, supaya URI file bisa dibuat sebelum insert
+func (r *fileRepository) NextID(ctx context.Context) (uint, error) {
+	var id uint
+	err := conn(ctx, r.db).Raw("SELECT nextval(pg_get_serial_sequence('files', 'id'))").Scan(&id).Error
+	return id, err
+}
+
+func (r *fileRepository) Create(ctx context.Context, file *models.File) error {
+	return conn(ctx, r.db).Create(file).Error
+}
+
+func (r *fileRepository) FindByID(ctx context.Context, id uint) (*models.File, error) {
+	var file models.File
+	err := conn(ctx, r.db).Preload("Variants").First(&file, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FindByIDForUpdate mengunci row file (SELECT ... FOR UPDATE), dipakai di dalam transaksi
+func (r *fileRepository) FindByIDForUpdate(ctx context.Context, id uint) (*models.File, error) {
+	var file models.File
+	err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&file, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FindByStorageKey mencari file (tanpa variant) yang object utamanya disimpan di key tersebut
+func (r *fileRepository) FindByStorageKey(ctx context.Context, key string) (*models.File, error) {
+	var file models.File
+	err := conn(ctx, r.db).Where("storage_key = ?", key).First(&file).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *fileRepository) Update(ctx context.Context, file *models.File) error {
+	return conn(ctx, r.db).Omit(clause.Associations).Save(file).Error
+}
+
+func (r *fileRepository) CreateVariants(ctx context.Context, variants []models.FileVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(&variants).Error
+}
+
+func (r *fileRepository) ListByUser(ctx context.Context, userID uint, filter *models.FileFilter) ([]*models.File, error) {
+	var files []*models.File
+	err := conn(ctx, r.db).
+		Preload("Variants").
+		Where("user_id = ? AND status = ?", userID, models.FileStatusReady).
+		Order("id DESC"). // File terbaru di atas
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&files).Error
+	return files, err
+}
+
+func (r *fileRepository) Delete(ctx context.Context, id uint) error {
+	result := conn(ctx, r.db).Delete(&models.File{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("file not found")
+	}
+
+	return nil
+}
+
+// ListUnreferenced mencari file yang dibuat sebelum createdBefore dan tidak dipakai entity manapun:
+// upload pending yang tidak pernah di-complete, atau file tanpa referensi. URI lama (sebelum ada
+// file_references) di employees dan users juga dicek supaya file yang masih dipakai tidak ikut terhapus.
+func (r *fileRepository) ListUnreferenced(ctx context.Context, createdBefore time.Time, afterID uint, limit int) ([]*models.File, error) {
+	var files []*models.File
+	err := conn(ctx, r.db).
+		Preload("Variants").
+		Where("files.created_at < ? AND files.id > ? AND files.storage_key <> ''", createdBefore, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM file_references r WHERE r.file_id = files.id)").
+		Where("NOT EXISTS (SELECT 1 FROM tus_uploads t WHERE t.file_id = files.id AND t.expires_at > NOW())").
+		Where(`NOT EXISTS (
+            SELECT 1 FROM employees e
+            WHERE e.employee_image_uri LIKE '%' || files.storage_key || '%'
+               OR e.employee_image_uri LIKE '%/v1/file/' || files.id || '/content%'
+        )`).
+		Where(`NOT EXISTS (
+            SELECT 1 FROM users u
+            WHERE u.user_image_uri LIKE '%' || files.storage_key || '%'
+               OR u.user_image_uri LIKE '%/v1/file/' || files.id || '/content%'
+               OR u.company_image_uri LIKE '%' || files.storage_key || '%'
+               OR u.company_image_uri LIKE '%/v1/file/' || files.id || '/content%'
+        )`).
+		Order("files.id").
+		Limit(limit).
+		Find(&files).Error
+	return files, err
+}
+
+// TrackedKeys mengembalikan key mana saja yang tercatat sebagai file atau variant
+func (r *fileRepository) TrackedKeys(ctx context.Context, keys []string) (map[string]bool, error) {
+	tracked := make(map[string]bool, len(keys))
+	if len(keys) == 0 {
+		return tracked, nil
+	}
+
+	var found []string
+	err := conn(ctx, r.db).Raw(`
+        SELECT storage_key FROM files WHERE storage_key IN ?
+        UNION
+        SELECT storage_key FROM file_variants WHERE storage_key IN ?
+        UNION
+        SELECT staging_key FROM tus_uploads WHERE staging_key IN ?
+    `, keys, keys, keys).Scan(&found).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range found {
+		tracked[key] = true
+	}
+	return tracked, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FileReferenceRepository interface {
+	Set(ctx context.Context, reference *models.FileReference) error
+	Delete(ctx context.Context, entityType string, entityID uint, field string) error
+	DeleteByEntity(ctx context.Context, entityType string, entityID uint) error
+	IsReferenced(ctx context.Context, fileID uint) (bool, error)
+}
+
+type fileReferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewFileReferenceRepository(db *gorm.DB) FileReferenceRepository {
+	return &fileReferenceRepository{
+		db: db,
+	}
+}
+
+// Set menyimpan referensi, satu field entity hanya bisa menunjuk satu file
+func (r *fileReferenceRepository) Set(ctx context.Context, reference *models.FileReference) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"file_id", "created_at"}),
+	}).Create(reference).Error
+}
+
+func (r *fileReferenceRepository) Delete(ctx context.Context, entityType string, entityID uint, field string) error {
+	return conn(ctx, r.db).
+		Where("entity_type = ? AND entity_id = ? AND field = ?", entityType, entityID, field).
+		Delete(&models.FileReference{}).Error
+}
+
+func (r *fileReferenceRepository) DeleteByEntity(ctx context.Context, entityType string, entityID uint) error {
+	return conn(ctx, r.db).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Delete(&models.FileReference{}).Error
+}
+
+func (r *fileReferenceRepository) IsReferenced(ctx context.Context, fileID uint) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.FileReference{}).
+		Where("file_id = ?", fileID).
+		Count(&count).Error
+	return count > 0, err
+}
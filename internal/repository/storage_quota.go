@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StorageQuotaRepository interface {
+	Find(ctx context.Context, userID uint) (*models.StorageQuota, error)
+	Set(ctx context.Context, quota *models.StorageQuota) error
+	Delete(ctx context.Context, userID uint) error
+	Usage(ctx context.Context, userID uint) (*models.StorageUsage, error)
+}
+
+type storageQuotaRepository struct {
+	db *gorm.DB
+}
+
+func NewStorageQuotaRepository(db *gorm.DB) StorageQuotaRepository {
+	return &storageQuotaRepository{
+		db: db,
+	}
+}
+
+func (r *storageQuotaRepository) Find(ctx context.Context, userID uint) (*models.StorageQuota, error) {
+	var quota models.StorageQuota
+	err := conn(ctx, r.db).First(&quota, userID).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// Set membuat atau mengganti override kuota user
+func (r *storageQuotaRepository) Set(ctx context.Context, quota *models.StorageQuota) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quota_bytes", "updated_at"}),
+	}).Create(quota).Error
+}
+
+func (r *storageQuotaRepository) Delete(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).Delete(&models.StorageQuota{}, userID).Error
+}
+
+// Usage menjumlahkan ukuran file milik user beserta variant-nya, dipisah antara file ready dan pending.
+// File karantina tidak dihitung.
+func (r *storageQuotaRepository) Usage(ctx context.Context, userID uint) (*models.StorageUsage, error) {
+	var usage models.StorageUsage
+	err := conn(ctx, r.db).Raw(`
+		SELECT
+			COALESCE(SUM(f.file_size + COALESCE(v.size, 0)) FILTER (WHERE f.status = ?), 0)::BIGINT AS used_bytes,
+			COALESCE(SUM(f.file_size) FILTER (WHERE f.status = ?), 0)::BIGINT AS pending_bytes,
+			COUNT(*) FILTER (WHERE f.status = ?) AS file_count
+		FROM files f
+		LEFT JOIN (
+			SELECT file_id, SUM(file_size) AS size FROM file_variants GROUP BY file_id
+		) v ON v.file_id = f.id
+		WHERE f.user_id = ?`,
+		models.FileStatusReady, models.FileStatusPending, models.FileStatusReady, userID,
+	).Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
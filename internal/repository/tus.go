@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type TusUploadRepository interface {
+	Create(ctx context.Context, upload *models.TusUpload) error
+	FindByID(ctx context.Context, id string) (*models.TusUpload, error)
+	FindByIDForUpdate(ctx context.Context, id string) (*models.TusUpload, error)
+	UpdateProgress(ctx context.Context, upload *models.TusUpload, previousOffset int64) error
+	Delete(ctx context.Context, id string) error
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.TusUpload, error)
+}
+
+type tusUploadRepository struct {
+	db *gorm.DB
+}
+
+func NewTusUploadRepository(db *gorm.DB) TusUploadRepository {
+	return &tusUploadRepository{
+		db: db,
+	}
+}
+
+func (r *tusUploadRepository) Create(ctx context.Context, upload *models.TusUpload) error {
+	return conn(ctx, r.db).Create(upload).Error
+}
+
+func (r *tusUploadRepository) FindByID(ctx context.Context, id string) (*models.TusUpload, error) {
+	var upload models.TusUpload
+	err := conn(ctx, r.db).Where("id = ?", id).First(&upload).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// FindByIDForUpdate sama dengan FindByID tapi mengunci row sampai transaksi selesai,
+// dipakai selama part chunk diupload
+func (r *tusUploadRepository) FindByIDForUpdate(ctx context.Context, id string) (*models.TusUpload, error) {
+	var upload models.TusUpload
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&upload).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// UpdateProgress menyimpan offset baru hanya jika offset di database masih previousOffset,
+// PATCH paralel ke upload yang sama akan gagal dengan "upload offset mismatch"
+func (r *tusUploadRepository) UpdateProgress(ctx context.Context, upload *models.TusUpload, previousOffset int64) error {
+	result := conn(ctx, r.db).Model(upload).
+		Where("upload_offset = ?", previousOffset).
+		Select("Offset", "Parts", "StagedSize", "UpdatedAt").
+		Updates(upload)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("upload offset mismatch")
+	}
+
+	return nil
+}
+
+func (r *tusUploadRepository) Delete(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Where("id = ?", id).Delete(&models.TusUpload{}).Error
+}
+
+func (r *tusUploadRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.TusUpload, error) {
+	var uploads []*models.TusUpload
+	err := conn(ctx, r.db).
+		Where("expires_at < ?", before).
+		Order("expires_at").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TxManager menjalankan beberapa pemanggilan repository dalam satu transaksi.
+// Repository yang menerima ctx dari fn otomatis memakai transaksi tersebut.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	WithinReadCommittedTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManager struct {
+	db         *gorm.DB
+	maxRetries int
+}
+
+// Key context untuk menyimpan *gorm.DB transaksi aktif dan isolation level-nya
+type (
+	txKey          struct{}
+	txIsolationKey struct{}
+)
+
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{
+		db:         db,
+		maxRetries: 3,
+	}
+}
+
+// WithinTransaction menjalankan fn dalam transaksi SERIALIZABLE dan mengulang otomatis
+// jika terjadi serialization failure atau deadlock. Jika ctx sudah membawa transaksi,
+// fn ikut transaksi tersebut (retry diurus oleh transaksi terluar) asalkan isolasinya sama.
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, sql.LevelSerializable, fn)
+}
+
+// WithinReadCommittedTransaction sama seperti WithinTransaction tapi dengan isolasi READ COMMITTED.
+// Dipakai untuk penulisan yang antre di row lock (counter), di SERIALIZABLE setiap antrean
+// berakhir dengan serialization failure.
+func (m *txManager) WithinReadCommittedTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, sql.LevelReadCommitted, fn)
+}
+
+func (m *txManager) run(ctx context.Context, isolation sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		// Transaksi bersarang memakai isolasi transaksi terluar, tolak daripada diam-diam
+		// memberi jaminan yang berbeda dari yang diminta
+		if outer, _ := ctx.Value(txIsolationKey{}).(sql.IsolationLevel); outer != isolation {
+			return fmt.Errorf("cannot run %s transaction inside a %s transaction", isolation, outer)
+		}
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txCtx := context.WithValue(ctx, txKey{}, tx)
+			return fn(context.WithValue(txCtx, txIsolationKey{}, isolation))
+		}, &sql.TxOptions{Isolation: isolation})
+
+		if !isRetryableTxError(err) || attempt >= m.maxRetries {
+			return err
+		}
+
+		// Backoff sederhana sebelum mencoba lagi
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 20 * time.Millisecond):
+		}
+	}
+}
+
+// conn mengembalikan transaksi dari ctx jika ada, jika tidak pakai koneksi biasa
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// isRetryableTxError mengecek serialization_failure (40001) dan deadlock_detected (40P01)
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
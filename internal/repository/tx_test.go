@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestNestedTransactionIsolation(t *testing.T) {
+	// Transaksi bersarang tidak membuka koneksi, cukup ctx yang sudah membawa transaksi
+	m := &txManager{}
+	inTx := func(isolation sql.IsolationLevel) context.Context {
+		ctx := context.WithValue(context.Background(), txKey{}, &gorm.DB{})
+		return context.WithValue(ctx, txIsolationKey{}, isolation)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		run     func(ctx context.Context, fn func(ctx context.Context) error) error
+		wantRun bool
+		wantErr string
+	}{
+		{
+			name:    "serializable inside serializable",
+			ctx:     inTx(sql.LevelSerializable),
+			run:     m.WithinTransaction,
+			wantRun: true,
+		},
+		{
+			name:    "read committed inside read committed",
+			ctx:     inTx(sql.LevelReadCommitted),
+			run:     m.WithinReadCommittedTransaction,
+			wantRun: true,
+		},
+		{
+			name:    "read committed inside serializable",
+			ctx:     inTx(sql.LevelSerializable),
+			run:     m.WithinReadCommittedTransaction,
+			wantErr: "cannot run Read Committed transaction inside a Serializable transaction",
+		},
+		{
+			name:    "serializable inside read committed",
+			ctx:     inTx(sql.LevelReadCommitted),
+			run:     m.WithinTransaction,
+			wantErr: "cannot run Serializable transaction inside a Read Committed transaction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			err := tt.run(tt.ctx, func(ctx context.Context) error {
+				ran = true
+				return nil
+			})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if ran != tt.wantRun {
+				t.Errorf("fn ran = %v, want %v", ran, tt.wantRun)
+			}
+		})
+	}
+}
@@ -2,14 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByIDForUpdate(ctx context.Context, id uint) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 }
 
@@ -24,12 +27,12 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -41,7 +44,7 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models
 
 func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := conn(ctx, r.db).First(&user, id).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -51,7 +54,40 @@ func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, e
 	return &user, nil
 }
 
-// Implementasi method Update
+// FindByIDForUpdate mengunci row user (SELECT ... FOR UPDATE), dipakai di dalam transaksi
+func (r *userRepository) FindByIDForUpdate(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Implementasi method Update dengan optimistic locking berdasarkan kolom version
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	currentVersion := user.Version
+	user.Version++
+
+	result := conn(ctx, r.db).
+		Model(user).
+		Where("version = ?", currentVersion).
+		Select("*").
+		Omit(clause.Associations, "CreatedAt").
+		Updates(user)
+	if result.Error != nil {
+		user.Version = currentVersion
+		return result.Error
+	}
+
+	// Versi sudah berubah, berarti ada yang mengupdate lebih dulu
+	if result.RowsAffected == 0 {
+		user.Version = currentVersion
+		return errors.New("precondition failed")
+	}
+
+	return nil
 }
@@ -6,22 +6,40 @@ import (
 	"fmt"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"sort"
+	"time"
 )
 
 type DepartmentService interface {
 	CreateDepartment(ctx context.Context, userID uint, req *models.CreateDepartmentRequest) (*models.DepartmentResponse, error)
-	UpdateDepartment(ctx context.Context, userID uint, departmentID string, req *models.UpdateDepartmentRequest) (*models.DepartmentResponse, error)
-	DeleteDepartment(ctx context.Context, userID uint, departmentID string) error
+	GetDepartment(ctx context.Context, userID uint, departmentID string) (*models.DepartmentResponse, error)
+	UpdateDepartment(ctx context.Context, userID uint, departmentID string, req *models.UpdateDepartmentRequest, ifMatch string) (*models.DepartmentResponse, error)
+	DeleteDepartment(ctx context.Context, userID uint, departmentID string, opts models.DeleteDepartmentOptions, ifMatch string) (*models.DeleteDepartmentReport, error)
 	ListDepartments(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.DepartmentResponse, error)
+	ListTrash(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.TrashedDepartmentResponse, error)
+	RestoreDepartment(ctx context.Context, userID uint, departmentID string) (*models.DepartmentResponse, error)
+	ArchiveDepartment(ctx context.Context, userID uint, departmentID string, ifMatch string) (*models.DepartmentResponse, error)
+	UnarchiveDepartment(ctx context.Context, userID uint, departmentID string, ifMatch string) (*models.DepartmentResponse, error)
+	ExportDepartments(ctx context.Context, userID uint, filter *models.DepartmentFilter, fn func(*models.DepartmentResponse) error) error
+	DepartmentTree(ctx context.Context, userID uint, status string) ([]*models.DepartmentTreeNode, error)
+	SetDepartmentHeads(ctx context.Context, userID uint, departmentID string, identityNumbers []string, ifMatch string) (*models.DepartmentResponse, error)
 }
 
 type departmentService struct {
 	departmentRepo repository.DepartmentRepository
+	employeeRepo   repository.EmployeeRepository
+	historyRepo    repository.EmployeeHistoryRepository
+	txManager      repository.TxManager
+	idFormat       models.DepartmentIDFormat
 }
 
-func NewDepartmentService(departmentRepo repository.DepartmentRepository) DepartmentService {
+func NewDepartmentService(departmentRepo repository.DepartmentRepository, employeeRepo repository.EmployeeRepository, historyRepo repository.EmployeeHistoryRepository, txManager repository.TxManager, idFormat models.DepartmentIDFormat) DepartmentService {
 	return &departmentService{
 		departmentRepo: departmentRepo,
+		employeeRepo:   employeeRepo,
+		historyRepo:    historyRepo,
+		txManager:      txManager,
+		idFormat:       idFormat,
 	}
 }
 
@@ -31,23 +49,36 @@ func (s *departmentService) CreateDepartment(ctx context.Context, userID uint, r
 		Name:   req.Name,
 	}
 
-	if err := s.departmentRepo.Create(ctx, department); err != nil {
+	// READ COMMITTED: create paralel di tenant yang sama antre di row counter, bukan gagal serialization
+	err := s.txManager.WithinReadCommittedTransaction(ctx, func(ctx context.Context) error {
+		// Nomor urut per tenant, ikut di-rollback jika create gagal supaya tidak ada nomor yang terlewat
+		seq, err := s.departmentRepo.NextSequence(ctx, userID, s.idFormat.Prefix)
+		if err != nil {
+			return err
+		}
+		department.DepartmentID, err = s.idFormat.Format(seq)
+		if err != nil {
+			return err
+		}
+
+		if err := s.setParent(ctx, userID, department, req.ParentDepartmentId); err != nil {
+			return err
+		}
+		return s.departmentRepo.Create(ctx, department)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return department.ToResponse(), nil
 }
 
-func (s *departmentService) UpdateDepartment(ctx context.Context, userID uint, departmentID string, req *models.UpdateDepartmentRequest) (*models.DepartmentResponse, error) {
-	fmt.Printf("Attempting to update department: %s for user: %d\n", departmentID, userID)
-
-	department, err := s.departmentRepo.FindByDepartmentID(ctx, departmentID)
+func (s *departmentService) GetDepartment(ctx context.Context, userID uint, departmentID string) (*models.DepartmentResponse, error) {
+	department, err := s.departmentRepo.FindByDepartmentID(ctx, userID, departmentID)
 	if err != nil {
-		fmt.Printf("Error finding department: %v\n", err)
 		return nil, err
 	}
 	if department == nil {
-		fmt.Printf("Department not found: %s\n", departmentID)
 		return nil, errors.New("department not found")
 	}
 
@@ -56,53 +87,436 @@ func (s *departmentService) UpdateDepartment(ctx context.Context, userID uint, d
 		return nil, errors.New("unauthorized access to department")
 	}
 
-	department.Name = req.Name
+	return department.ToResponse(), nil
+}
+
+func (s *departmentService) UpdateDepartment(ctx context.Context, userID uint, departmentID string, req *models.UpdateDepartmentRequest, ifMatch string) (*models.DepartmentResponse, error) {
+	fmt.Printf("Attempting to update department: %s for user: %d\n", departmentID, userID)
 
-	if err := s.departmentRepo.Update(ctx, department); err != nil {
+	var department *models.Department
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		department, err = s.departmentRepo.FindByDepartmentIDForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			fmt.Printf("Error finding department: %v\n", err)
+			return err
+		}
+		if department == nil {
+			fmt.Printf("Department not found: %s\n", departmentID)
+			return errors.New("department not found")
+		}
+
+		// Verify ownership
+		if department.UserID != userID {
+			return errors.New("unauthorized access to department")
+		}
+
+		// Check If-Match precondition
+		if !models.MatchesIfMatch(ifMatch, department.Version) {
+			return errors.New("precondition failed")
+		}
+
+		// Induk hanya divalidasi ulang jika berubah
+		if req.ParentDepartmentId != department.ParentDepartmentID {
+			if err := s.setParent(ctx, userID, department, req.ParentDepartmentId); err != nil {
+				return err
+			}
+		}
+
+		department.Name = req.Name
+
+		return s.departmentRepo.Update(ctx, department)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return department.ToResponse(), nil
 }
 
-func (s *departmentService) DeleteDepartment(ctx context.Context, userID uint, departmentID string) error {
-	department, err := s.departmentRepo.FindByDepartmentID(ctx, departmentID)
+// DeleteDepartment menghapus department. Employee di dalamnya bisa dipindahkan ke department lain
+// (opts.ReassignTo) atau ikut dihapus (opts.Cascade), semuanya dalam satu transaksi.
+func (s *departmentService) DeleteDepartment(ctx context.Context, userID uint, departmentID string, opts models.DeleteDepartmentOptions, ifMatch string) (*models.DeleteDepartmentReport, error) {
+	var report *models.DeleteDepartmentReport
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		report = &models.DeleteDepartmentReport{
+			DepartmentID:        departmentID,
+			ReassignedEmployees: []string{},
+			DeletedEmployees:    []string{},
+		}
+
+		// Lock department, employee baru tidak bisa masuk sampai transaksi selesai
+		department, err := s.departmentRepo.FindByDepartmentIDForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			return err
+		}
+		if department == nil {
+			return errors.New("department not found")
+		}
+
+		// Verify ownership
+		if department.UserID != userID {
+			return errors.New("unauthorized access to department")
+		}
+
+		// Check If-Match precondition
+		if !models.MatchesIfMatch(ifMatch, department.Version) {
+			return errors.New("precondition failed")
+		}
+
+		// Sub-department harus dipindahkan atau dihapus lebih dulu
+		hasSubdepartments, err := s.departmentRepo.HasSubdepartments(ctx, department.ID)
+		if err != nil {
+			return err
+		}
+		if hasSubdepartments {
+			return errors.New("department still contains subdepartments")
+		}
+
+		if opts.ReassignTo != "" {
+			if opts.ReassignTo == departmentID {
+				return errors.New("cannot reassign employees to the same department")
+			}
+
+			// Lock target supaya tidak dihapus atau diarsipkan sebelum employee dipindahkan
+			target, err := s.departmentRepo.FindByDepartmentIDForShare(ctx, userID, opts.ReassignTo)
+			if err != nil {
+				return err
+			}
+			if target == nil || target.UserID != userID {
+				return errors.New("target department not found")
+			}
+			if target.ArchivedAt != nil {
+				return errors.New("department is archived")
+			}
+		}
+
+		identityNumbers, err := s.employeeRepo.ListIdentityNumbersByDepartmentForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			return err
+		}
+
+		if len(identityNumbers) > 0 {
+			switch {
+			case opts.ReassignTo != "":
+				// History dicatat sebelum department employee diubah
+				if err := s.historyRepo.RecordDepartmentReassignment(ctx, userID, departmentID, opts.ReassignTo, models.DateOf(time.Now())); err != nil {
+					return err
+				}
+				if err := s.employeeRepo.ReassignDepartment(ctx, userID, departmentID, opts.ReassignTo); err != nil {
+					return err
+				}
+				report.ReassignedTo = opts.ReassignTo
+				report.ReassignedEmployees = identityNumbers
+			case opts.Cascade:
+				// Referensi gambar tetap disimpan, employee masih bisa direstore dari trash
+				if err := s.employeeRepo.DeleteByDepartment(ctx, userID, departmentID); err != nil {
+					return err
+				}
+				report.DeletedEmployees = identityNumbers
+			default:
+				return errors.New("department still contains employees")
+			}
+		}
+
+		return s.departmentRepo.Delete(ctx, userID, departmentID)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if department == nil {
-		return errors.New("department not found")
+
+	return report, nil
+}
+
+func (s *departmentService) ListDepartments(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.DepartmentResponse, error) {
+	filter.Normalize()
+
+	departments, err := s.departmentRepo.List(ctx, userID, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	// Verify ownership
-	if department.UserID != userID {
-		return errors.New("unauthorized access to department")
+	var response []*models.DepartmentResponse
+	for _, dept := range departments {
+		response = append(response, dept.ToResponse())
+	}
+
+	return response, nil
+}
+
+// ExportDepartments memanggil fn untuk setiap department milik user yang cocok dengan filter
+func (s *departmentService) ExportDepartments(ctx context.Context, userID uint, filter *models.DepartmentFilter, fn func(*models.DepartmentResponse) error) error {
+	if filter.Status == "" {
+		filter.Status = models.DepartmentStatusActive
+	}
+
+	return s.departmentRepo.Stream(ctx, userID, filter, func(department *models.Department) error {
+		return fn(department.ToResponse())
+	})
+}
+
+// DepartmentTree mengembalikan department milik user sebagai pohon. Department yang induknya
+// tidak ikut terpilih (misalnya induk diarsipkan) ditampilkan di level teratas.
+func (s *departmentService) DepartmentTree(ctx context.Context, userID uint, status string) ([]*models.DepartmentTreeNode, error) {
+	filter := &models.DepartmentFilter{Status: status}
+	if filter.Status == "" {
+		filter.Status = models.DepartmentStatusActive
+	}
+
+	var departments []*models.Department
+	err := s.departmentRepo.Stream(ctx, userID, filter, func(department *models.Department) error {
+		departments = append(departments, department)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*models.DepartmentTreeNode, len(departments))
+	for _, department := range departments {
+		nodes[department.ID] = &models.DepartmentTreeNode{
+			DepartmentID:   department.DepartmentID,
+			Name:           department.Name,
+			ArchivedAt:     department.ArchivedAt,
+			Subdepartments: []*models.DepartmentTreeNode{},
+		}
+	}
+
+	roots := []*models.DepartmentTreeNode{}
+	for _, department := range departments {
+		node := nodes[department.ID]
+		if department.ParentID != nil {
+			if parent, ok := nodes[*department.ParentID]; ok {
+				parent.Subdepartments = append(parent.Subdepartments, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
 	}
 
-	// Check if department has employees
-	hasEmployees, err := s.departmentRepo.HasEmployees(ctx, departmentID)
+	return roots, nil
+}
+
+// SetDepartmentHeads mengganti kepala department. Kepala harus employee aktif milik user yang
+// terhubung ke akun user, karena hak akses kepala diperiksa lewat akun tersebut.
+func (s *departmentService) SetDepartmentHeads(ctx context.Context, userID uint, departmentID string, identityNumbers []string, ifMatch string) (*models.DepartmentResponse, error) {
+	var department *models.Department
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		department, err = s.departmentRepo.FindByDepartmentIDForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			return err
+		}
+		if department == nil {
+			return errors.New("department not found")
+		}
+
+		// Verify ownership
+		if department.UserID != userID {
+			return errors.New("unauthorized access to department")
+		}
+
+		// Check If-Match precondition
+		if !models.MatchesIfMatch(ifMatch, department.Version) {
+			return errors.New("precondition failed")
+		}
+
+		heads := models.DepartmentHeads{}
+		employeeIDs := make([]uint, 0, len(identityNumbers))
+		seen := make(map[string]bool, len(identityNumbers))
+		for _, identityNumber := range identityNumbers {
+			if seen[identityNumber] {
+				continue
+			}
+			seen[identityNumber] = true
+
+			employee, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
+			if err != nil {
+				return err
+			}
+			if employee == nil {
+				return errors.New("head employee not found")
+			}
+			// Employee dari department milik user lain dianggap tidak ada
+			employeeDept, err := s.departmentRepo.FindByDepartmentID(ctx, employee.OwnerID, employee.DepartmentID)
+			if err != nil {
+				return err
+			}
+			if employeeDept == nil || employeeDept.UserID != userID {
+				return errors.New("head employee not found")
+			}
+			if employee.UserID == nil {
+				return errors.New("head employee is not linked to a user account")
+			}
+
+			employeeIDs = append(employeeIDs, employee.ID)
+			heads = append(heads, employee.IdentityNumber)
+		}
+
+		if err := s.departmentRepo.ReplaceHeads(ctx, department.ID, employeeIDs); err != nil {
+			return err
+		}
+
+		// Urutan sama dengan yang dibaca repository
+		sort.Strings(heads)
+		department.Heads = heads
+
+		// Version dinaikkan karena kepala department ikut di response dan ETag
+		return s.departmentRepo.Update(ctx, department)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return department.ToResponse(), nil
+}
+
+// setParent memvalidasi dan mengisi department induk, kosong berarti level teratas
+func (s *departmentService) setParent(ctx context.Context, userID uint, department *models.Department, parentDepartmentID string) error {
+	if parentDepartmentID == "" {
+		department.ParentID = nil
+		department.ParentDepartmentID = ""
+		return nil
+	}
+
+	if parentDepartmentID == department.DepartmentID {
+		return errors.New("department cannot be its own parent")
+	}
+
+	// Lock induk supaya tidak dihapus atau diarsipkan sebelum transaksi selesai
+	parent, err := s.departmentRepo.FindByDepartmentIDForShare(ctx, userID, parentDepartmentID)
 	if err != nil {
 		return err
 	}
-	if hasEmployees {
-		return errors.New("department still contains employees")
+	if parent == nil || parent.UserID != userID {
+		return errors.New("parent department not found")
+	}
+	if parent.ArchivedAt != nil {
+		return errors.New("department is archived")
+	}
+
+	// Department baru belum punya sub-department, tidak mungkin membentuk siklus
+	if department.ID != 0 {
+		inChain, err := s.departmentRepo.IsInDepartmentChain(ctx, department.ID, parent.ID)
+		if err != nil {
+			return err
+		}
+		if inChain {
+			return errors.New("parent assignment would create a cycle")
+		}
 	}
 
-	return s.departmentRepo.Delete(ctx, departmentID)
+	department.ParentID = &parent.ID
+	department.ParentDepartmentID = parent.DepartmentID
+	return nil
 }
 
-func (s *departmentService) ListDepartments(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.DepartmentResponse, error) {
+func (s *departmentService) ListTrash(ctx context.Context, userID uint, filter *models.DepartmentFilter) ([]*models.TrashedDepartmentResponse, error) {
 	filter.Normalize()
 
-	departments, err := s.departmentRepo.List(ctx, userID, filter)
+	departments, err := s.departmentRepo.ListDeleted(ctx, userID, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	var response []*models.DepartmentResponse
+	response := make([]*models.TrashedDepartmentResponse, 0, len(departments))
 	for _, dept := range departments {
-		response = append(response, dept.ToResponse())
+		response = append(response, dept.ToTrashedResponse())
 	}
 
 	return response, nil
 }
+
+// RestoreDepartment mengembalikan department yang terhapus. DepartmentID hanya unik di antara
+// department aktif milik tenant, jadi restore ditolak jika ID yang sama sudah dipakai department lain.
+func (s *departmentService) RestoreDepartment(ctx context.Context, userID uint, departmentID string) (*models.DepartmentResponse, error) {
+	var department *models.Department
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		department, err = s.departmentRepo.FindDeletedByDepartmentIDForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			return err
+		}
+		if department == nil {
+			return errors.New("department not found")
+		}
+
+		// Verify ownership
+		if department.UserID != userID {
+			return errors.New("unauthorized access to department")
+		}
+
+		existing, err := s.departmentRepo.FindByDepartmentIDForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.New("department id already in use")
+		}
+
+		return s.departmentRepo.Restore(ctx, department)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return department.ToResponse(), nil
+}
+
+// ArchiveDepartment menyembunyikan department dari daftar pilihan tanpa menghapusnya,
+// employee yang sudah ada tetap di department tersebut
+func (s *departmentService) ArchiveDepartment(ctx context.Context, userID uint, departmentID string, ifMatch string) (*models.DepartmentResponse, error) {
+	return s.setArchived(ctx, userID, departmentID, ifMatch, true)
+}
+
+func (s *departmentService) UnarchiveDepartment(ctx context.Context, userID uint, departmentID string, ifMatch string) (*models.DepartmentResponse, error) {
+	return s.setArchived(ctx, userID, departmentID, ifMatch, false)
+}
+
+func (s *departmentService) setArchived(ctx context.Context, userID uint, departmentID string, ifMatch string, archived bool) (*models.DepartmentResponse, error) {
+	var department *models.Department
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		department, err = s.departmentRepo.FindByDepartmentIDForUpdate(ctx, userID, departmentID)
+		if err != nil {
+			return err
+		}
+		if department == nil {
+			return errors.New("department not found")
+		}
+
+		// Verify ownership
+		if department.UserID != userID {
+			return errors.New("unauthorized access to department")
+		}
+
+		// Archive dan unarchive (POST) boleh tanpa If-Match
+		if ifMatch != "" && !models.MatchesIfMatch(ifMatch, department.Version) {
+			return errors.New("precondition failed")
+		}
+
+		if archived {
+			if department.ArchivedAt != nil {
+				return errors.New("department is already archived")
+			}
+			now := time.Now()
+			department.ArchivedAt = &now
+		} else {
+			if department.ArchivedAt == nil {
+				return errors.New("department is not archived")
+			}
+			department.ArchivedAt = nil
+		}
+
+		return s.departmentRepo.Update(ctx, department)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return department.ToResponse(), nil
+}
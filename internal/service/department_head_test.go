@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"reflect"
+	"testing"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// newHeadTestRepositories menyiapkan tenant 1 dengan Engineering (DEP-01) yang dikepalai user 5,
+// sub department Backend (DEP-02) dan Finance (DEP-03) di luar wilayahnya, serta tenant 2
+// dengan employee yang terhubung ke user 6
+func newHeadTestRepositories() (*fakeEmployeeRepository, *fakeDepartmentRepository) {
+	employees := &fakeEmployeeRepository{employees: map[uint]*models.Employee{
+		1: {ID: 1, OwnerID: 1, IdentityNumber: "1000001", Name: "Head", Gender: "female", DepartmentID: "DEP-01", UserID: uintPtr(5), UserEmail: "head@example.com"},
+		2: {ID: 2, OwnerID: 1, IdentityNumber: "1000002", Name: "Backend", Gender: "male", DepartmentID: "DEP-02"},
+		3: {ID: 3, OwnerID: 1, IdentityNumber: "1000003", Name: "Finance", Gender: "female", DepartmentID: "DEP-03"},
+		4: {ID: 4, OwnerID: 2, IdentityNumber: "2000001", Name: "Other tenant", Gender: "male", DepartmentID: "DEP-01", UserID: uintPtr(6)},
+	}}
+
+	departments := newFakeDepartmentRepository()
+	departments.employees = employees
+	departments.departments[1] = map[string]*models.Department{
+		"DEP-01": {ID: 1, UserID: 1, DepartmentID: "DEP-01", Name: "Engineering"},
+		"DEP-02": {ID: 2, UserID: 1, DepartmentID: "DEP-02", Name: "Backend", ParentID: uintPtr(1)},
+		"DEP-03": {ID: 3, UserID: 1, DepartmentID: "DEP-03", Name: "Finance"},
+	}
+	departments.departments[2] = map[string]*models.Department{
+		"DEP-01": {ID: 4, UserID: 2, DepartmentID: "DEP-01", Name: "Other"},
+	}
+	departments.heads[1] = []uint{1}
+	return employees, departments
+}
+
+// updateRequest mengisi request dengan data employee saat ini, test hanya mengubah field yang diuji
+func updateRequest(employee *models.Employee) *models.UpdateEmployeeRequest {
+	return &models.UpdateEmployeeRequest{
+		IdentityNumber: employee.IdentityNumber,
+		Name:           employee.Name,
+		Gender:         employee.Gender,
+		DepartmentId:   employee.DepartmentID,
+		UserEmail:      employee.UserEmail,
+	}
+}
+
+func TestUpdateEmployeeHeadAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		identityNumber string
+		change         func(req *models.UpdateEmployeeRequest)
+		wantErr        error
+	}{
+		{
+			name:           "owner edits any department",
+			userID:         1,
+			identityNumber: "1000003",
+			change:         func(req *models.UpdateEmployeeRequest) { req.Name = "Renamed" },
+		},
+		{
+			name:           "head edits sub department",
+			userID:         5,
+			identityNumber: "1000002",
+			change:         func(req *models.UpdateEmployeeRequest) { req.Name = "Renamed" },
+		},
+		{
+			name:           "head moves employee within subtree",
+			userID:         5,
+			identityNumber: "1000002",
+			change:         func(req *models.UpdateEmployeeRequest) { req.DepartmentId = "DEP-01" },
+		},
+		{
+			name:           "head moves employee outside subtree",
+			userID:         5,
+			identityNumber: "1000002",
+			change:         func(req *models.UpdateEmployeeRequest) { req.DepartmentId = "DEP-03" },
+			wantErr:        errEmployeeAccessDenied,
+		},
+		{
+			name:           "head edits department outside subtree",
+			userID:         5,
+			identityNumber: "1000003",
+			change:         func(req *models.UpdateEmployeeRequest) { req.Name = "Renamed" },
+			wantErr:        errEmployeeAccessDenied,
+		},
+		{
+			name:           "head changes user account",
+			userID:         5,
+			identityNumber: "1000002",
+			change:         func(req *models.UpdateEmployeeRequest) { req.UserEmail = "someone@example.com" },
+			wantErr:        errEmployeeAccessDenied,
+		},
+		{
+			name:           "head of another tenant",
+			userID:         6,
+			identityNumber: "1000002",
+			change:         func(req *models.UpdateEmployeeRequest) { req.Name = "Renamed" },
+			wantErr:        errEmployeeAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			employees, departments := newHeadTestRepositories()
+			svc := NewEmployeeService(employees, departments, nil, &fakeEmployeeHistoryRepository{}, fakeTxManager{}, fakeFileReferenceService{})
+
+			current, _ := employees.FindByIdentityNumber(context.Background(), tt.identityNumber)
+			req := updateRequest(current)
+			tt.change(req)
+
+			_, err := svc.UpdateEmployee(context.Background(), tt.userID, tt.identityNumber, req, "*")
+			if err != tt.wantErr {
+				t.Fatalf("UpdateEmployee() error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored := employees.employees[current.ID]
+			want := current
+			if tt.wantErr == nil {
+				want = &models.Employee{Name: req.Name, DepartmentID: req.DepartmentId}
+			}
+			if stored.Name != want.Name || stored.DepartmentID != want.DepartmentID {
+				t.Errorf("stored name, department = %s, %s, want %s, %s", stored.Name, stored.DepartmentID, want.Name, want.DepartmentID)
+			}
+		})
+	}
+}
+
+func TestDeleteEmployeeHeadAccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint
+		identityNumber string
+		wantErr        error
+	}{
+		{name: "owner", userID: 1, identityNumber: "1000003"},
+		{name: "head of parent department", userID: 5, identityNumber: "1000002"},
+		{name: "head outside subtree", userID: 5, identityNumber: "1000003", wantErr: errEmployeeAccessDenied},
+		{name: "not a head", userID: 7, identityNumber: "1000002", wantErr: errEmployeeAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			employees, departments := newHeadTestRepositories()
+			svc := NewEmployeeService(employees, departments, nil, &fakeEmployeeHistoryRepository{}, fakeTxManager{}, fakeFileReferenceService{})
+
+			err := svc.DeleteEmployee(context.Background(), tt.userID, tt.identityNumber, "*")
+			if err != tt.wantErr {
+				t.Fatalf("DeleteEmployee() error = %v, want %v", err, tt.wantErr)
+			}
+
+			remaining, _ := employees.FindByIdentityNumber(context.Background(), tt.identityNumber)
+			if deleted := remaining == nil; deleted != (tt.wantErr == nil) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestSetDepartmentHeads(t *testing.T) {
+	tests := []struct {
+		name            string
+		userID          uint
+		departmentID    string
+		identityNumbers []string
+		wantHeads       []string
+		wantErr         string
+	}{
+		{
+			name:            "duplicates ignored",
+			userID:          1,
+			departmentID:    "DEP-03",
+			identityNumbers: []string{"1000001", "1000001"},
+			wantHeads:       []string{"1000001"},
+		},
+		{
+			name:         "empty list clears heads",
+			userID:       1,
+			departmentID: "DEP-01",
+			wantHeads:    []string{},
+		},
+		{
+			name:            "employee without user account",
+			userID:          1,
+			departmentID:    "DEP-03",
+			identityNumbers: []string{"1000002"},
+			wantErr:         "head employee is not linked to a user account",
+		},
+		{
+			name:            "employee of another tenant",
+			userID:          1,
+			departmentID:    "DEP-03",
+			identityNumbers: []string{"2000001"},
+			wantErr:         "head employee not found",
+		},
+		{
+			name:            "unknown employee",
+			userID:          1,
+			departmentID:    "DEP-03",
+			identityNumbers: []string{"9999999"},
+			wantErr:         "head employee not found",
+		},
+		{
+			// Kepala department tidak bisa mengganti kepala department, hanya pemilik
+			name:            "head is not the owner",
+			userID:          5,
+			departmentID:    "DEP-02",
+			identityNumbers: []string{"1000001"},
+			wantErr:         "department not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			employees, departments := newHeadTestRepositories()
+			svc := NewDepartmentService(departments, employees, nil, fakeTxManager{}, models.DepartmentIDFormat{})
+
+			resp, err := svc.SetDepartmentHeads(context.Background(), tt.userID, tt.departmentID, tt.identityNumbers, "*")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("SetDepartmentHeads() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetDepartmentHeads() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(resp.Heads, tt.wantHeads) {
+				t.Errorf("heads = %v, want %v", resp.Heads, tt.wantHeads)
+			}
+			department := departments.departments[tt.userID][tt.departmentID]
+			if len(departments.heads[department.ID]) != len(tt.wantHeads) {
+				t.Errorf("stored head employee IDs = %v, want %d", departments.heads[department.ID], len(tt.wantHeads))
+			}
+		})
+	}
+}
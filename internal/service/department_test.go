@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/internalsql"
+	"gorm.io/gorm"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDB membuka database dari TEST_DATABASE_DSN, test di-skip jika tidak diset.
+// Database harus kosong atau khusus test, schema dibuat lewat internalsql.Connect.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := internalsql.Connect(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		internalsql.CloseDatabaseConnection(db)
+	})
+	return db
+}
+
+// createTestTenant membuat user baru sebagai tenant, ikut dihapus bersama department-nya setelah test
+func createTestTenant(t *testing.T, db *gorm.DB) uint {
+	t.Helper()
+
+	user := &models.User{
+		Email:    fmt.Sprintf("tenant-%d@example.test", time.Now().UnixNano()),
+		Password: "password",
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Department{})
+		db.Exec("DELETE FROM department_id_counters WHERE user_id = ?", user.ID)
+		db.Delete(user)
+	})
+	return user.ID
+}
+
+// fakeTx adalah transaksi fakeTxManager. Seperti di database, row lock baru dilepas saat
+// transaksi selesai dan perubahan dibatalkan jika fn gagal.
+type fakeTx struct {
+	locked []sync.Locker
+	undo   []func()
+}
+
+type fakeTxKey struct{}
+
+type fakeTxManager struct{}
+
+func (m fakeTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, fn)
+}
+
+func (m fakeTxManager) WithinReadCommittedTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, fn)
+}
+
+func (fakeTxManager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(fakeTxKey{}).(*fakeTx); ok {
+		return fn(ctx)
+	}
+
+	tx := &fakeTx{}
+	err := fn(context.WithValue(ctx, fakeTxKey{}, tx))
+	if err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+	}
+	for _, lock := range tx.locked {
+		lock.Unlock()
+	}
+	return err
+}
+
+// fakeCounter adalah satu row department_id_counters, mutex-nya berperan sebagai row lock
+type fakeCounter struct {
+	sync.Mutex
+	last int64
+}
+
+// fakeDepartmentRepository menyimpan department di memori dengan aturan yang sama seperti schema:
+// counter per tenant dan prefix terkunci sampai transaksi selesai, department_id unik per tenant
+type fakeDepartmentRepository struct {
+	repository.DepartmentRepository
+
+	mu          sync.Mutex
+	counters    map[string]*fakeCounter
+	departments map[uint]map[string]*models.Department
+	heads       map[uint][]uint         // Department.ID ke employee ID kepala department
+	employees   *fakeEmployeeRepository // Untuk join kepala department ke akun user di IsHead
+}
+
+func newFakeDepartmentRepository() *fakeDepartmentRepository {
+	return &fakeDepartmentRepository{
+		counters:    make(map[string]*fakeCounter),
+		departments: make(map[uint]map[string]*models.Department),
+		heads:       make(map[uint][]uint),
+	}
+}
+
+func (r *fakeDepartmentRepository) NextSequence(ctx context.Context, userID uint, prefix string) (int64, error) {
+	tx, ok := ctx.Value(fakeTxKey{}).(*fakeTx)
+	if !ok {
+		return 0, errors.New("NextSequence called outside a transaction")
+	}
+
+	r.mu.Lock()
+	key := fmt.Sprintf("%d/%s", userID, prefix)
+	counter, ok := r.counters[key]
+	if !ok {
+		counter = &fakeCounter{}
+		r.counters[key] = counter
+	}
+	r.mu.Unlock()
+
+	counter.Lock()
+	tx.locked = append(tx.locked, counter)
+	counter.last++
+	tx.undo = append(tx.undo, func() { counter.last-- })
+	return counter.last, nil
+}
+
+func (r *fakeDepartmentRepository) Create(ctx context.Context, department *models.Department) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenant := r.departments[department.UserID]
+	if tenant == nil {
+		tenant = make(map[string]*models.Department)
+		r.departments[department.UserID] = tenant
+	}
+	if _, ok := tenant[department.DepartmentID]; ok {
+		return fmt.Errorf("duplicate department id %s", department.DepartmentID)
+	}
+	tenant[department.DepartmentID] = department
+	if tx, ok := ctx.Value(fakeTxKey{}).(*fakeTx); ok {
+		tx.undo = append(tx.undo, func() {
+			r.mu.Lock()
+			delete(tenant, department.DepartmentID)
+			r.mu.Unlock()
+		})
+	}
+	return nil
+}
+
+func (r *fakeDepartmentRepository) FindByDepartmentIDForShare(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.departments[userID][departmentID], nil
+}
+
+func (r *fakeDepartmentRepository) FindByDepartmentID(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.departments[userID][departmentID], nil
+}
+
+func (r *fakeDepartmentRepository) FindByDepartmentIDForUpdate(ctx context.Context, userID uint, departmentID string) (*models.Department, error) {
+	return r.FindByDepartmentID(ctx, userID, departmentID)
+}
+
+func (r *fakeDepartmentRepository) Update(ctx context.Context, department *models.Department) error {
+	department.Version++
+	return nil
+}
+
+func (r *fakeDepartmentRepository) ReplaceHeads(ctx context.Context, id uint, employeeIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heads[id] = employeeIDs
+	return nil
+}
+
+// IsHead mengikuti query repository: userID kepala department atau salah satu induknya
+func (r *fakeDepartmentRepository) IsHead(ctx context.Context, userID, ownerID uint, departmentID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byID := make(map[uint]*models.Department)
+	for _, department := range r.departments[ownerID] {
+		byID[department.ID] = department
+	}
+	for department := r.departments[ownerID][departmentID]; department != nil; {
+		for _, employeeID := range r.heads[department.ID] {
+			head := r.employees.employees[employeeID]
+			if head != nil && head.UserID != nil && *head.UserID == userID {
+				return true, nil
+			}
+		}
+		if department.ParentID == nil {
+			break
+		}
+		department = byID[*department.ParentID]
+	}
+	return false, nil
+}
+
+// createDepartmentsInParallel menjalankan perTenant create yang berhasil dan failedPerTenant create
+// yang gagal setelah nomor dialokasikan (parent tidak ada) untuk setiap tenant sekaligus, lalu
+// memastikan setiap tenant mendapat nomor 1..perTenant tanpa duplikat dan tanpa celah
+func createDepartmentsInParallel(t *testing.T, svc DepartmentService, format models.DepartmentIDFormat, tenantIDs []uint, perTenant, failedPerTenant int) {
+	t.Helper()
+
+	type result struct {
+		userID uint
+		id     string
+		err    error
+	}
+
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		results = make(chan result, len(tenantIDs)*(perTenant+failedPerTenant))
+	)
+	for _, userID := range tenantIDs {
+		for i := 0; i < perTenant+failedPerTenant; i++ {
+			req := &models.CreateDepartmentRequest{Name: fmt.Sprintf("Department %d", i)}
+			if i >= perTenant {
+				req.ParentDepartmentId = "does-not-exist"
+			}
+
+			wg.Add(1)
+			go func(userID uint, req *models.CreateDepartmentRequest) {
+				defer wg.Done()
+				<-start
+				resp, err := svc.CreateDepartment(context.Background(), userID, req)
+				if err != nil {
+					results <- result{userID: userID, err: err}
+					return
+				}
+				results <- result{userID: userID, id: resp.DepartmentID}
+			}(userID, req)
+		}
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	created := make(map[uint]map[string]bool)
+	failed := make(map[uint]int)
+	for r := range results {
+		if r.err != nil {
+			if r.err.Error() != "parent department not found" {
+				t.Errorf("tenant %d: unexpected error: %v", r.userID, r.err)
+			}
+			failed[r.userID]++
+			continue
+		}
+		if created[r.userID] == nil {
+			created[r.userID] = make(map[string]bool)
+		}
+		if created[r.userID][r.id] {
+			t.Errorf("tenant %d: duplicate department id %s", r.userID, r.id)
+		}
+		created[r.userID][r.id] = true
+	}
+
+	for _, userID := range tenantIDs {
+		if failed[userID] != failedPerTenant {
+			t.Errorf("tenant %d: %d creates failed, want %d", userID, failed[userID], failedPerTenant)
+		}
+		if len(created[userID]) != perTenant {
+			t.Errorf("tenant %d: %d departments created, want %d", userID, len(created[userID]), perTenant)
+		}
+
+		// Tanpa celah: nomor 1..perTenant semuanya terpakai, create yang gagal tidak memakan nomor.
+		// ID yang sama dipakai setiap tenant karena nomor urut tidak berbagi antar tenant.
+		for seq := int64(1); seq <= int64(perTenant); seq++ {
+			id, err := format.Format(seq)
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if !created[userID][id] {
+				t.Errorf("tenant %d: missing department id %s", userID, id)
+			}
+		}
+	}
+}
+
+func TestCreateDepartmentParallelInMemory(t *testing.T) {
+	format := models.DepartmentIDFormat{Prefix: "ENG-", Padding: 4}
+	repo := newFakeDepartmentRepository()
+	svc := NewDepartmentService(repo, nil, nil, fakeTxManager{}, format)
+
+	createDepartmentsInParallel(t, svc, format, []uint{1, 2, 3}, 200, 50)
+
+	for userID, departments := range repo.departments {
+		if len(departments) != 200 {
+			t.Errorf("tenant %d: %d departments stored, want 200", userID, len(departments))
+		}
+	}
+}
+
+func TestCreateDepartmentParallel(t *testing.T) {
+	db := testDB(t)
+
+	format := models.DepartmentIDFormat{Prefix: "DEP-", Padding: 2}
+	svc := NewDepartmentService(
+		repository.NewDepartmentRepository(db),
+		repository.NewEmployeeRepository(db),
+		repository.NewEmployeeHistoryRepository(db),
+		repository.NewTxManager(db),
+		format,
+	)
+
+	const (
+		perTenant       = 150 // Create yang harus berhasil per tenant
+		failedPerTenant = 25  // Create dengan parent yang tidak ada, gagal setelah nomor dialokasikan
+	)
+	tenantIDs := []uint{createTestTenant(t, db), createTestTenant(t, db)}
+
+	createDepartmentsInParallel(t, svc, format, tenantIDs, perTenant, failedPerTenant)
+
+	for _, userID := range tenantIDs {
+		var stored int64
+		if err := db.Model(&models.Department{}).Where("user_id = ?", userID).Count(&stored).Error; err != nil {
+			t.Fatalf("count departments: %v", err)
+		}
+		if stored != perTenant {
+			t.Errorf("tenant %d: %d departments stored, want %d", userID, stored, perTenant)
+		}
+	}
+}
+
+func TestCreateDepartmentSeedsFromExistingIDs(t *testing.T) {
+	db := testDB(t)
+
+	format := models.DepartmentIDFormat{Prefix: "DEP-", Padding: 2}
+	svc := NewDepartmentService(
+		repository.NewDepartmentRepository(db),
+		repository.NewEmployeeRepository(db),
+		repository.NewEmployeeHistoryRepository(db),
+		repository.NewTxManager(db),
+		format,
+	)
+
+	userID := createTestTenant(t, db)
+	otherID := createTestTenant(t, db)
+
+	// Department yang dibuat sebelum ada counter, termasuk yang sudah di trash. ID tenant lain
+	// atau dengan format lain tidak ikut dihitung.
+	existing := []*models.Department{
+		{DepartmentID: "DEP-07", UserID: userID, Name: "Legacy"},
+		{DepartmentID: "DEP-41", UserID: userID, Name: "Trashed"},
+		{DepartmentID: "OLD-9000", UserID: userID, Name: "Other prefix"},
+		{DepartmentID: "DEP-x99", UserID: userID, Name: "Not numeric"},
+		{DepartmentID: "DEP-99", UserID: otherID, Name: "Other tenant"},
+	}
+	for _, d := range existing {
+		if err := db.Create(d).Error; err != nil {
+			t.Fatalf("create existing department: %v", err)
+		}
+	}
+	if err := db.Delete(existing[1]).Error; err != nil {
+		t.Fatalf("trash department: %v", err)
+	}
+
+	resp, err := svc.CreateDepartment(context.Background(), userID, &models.CreateDepartmentRequest{Name: "Engineering"})
+	if err != nil {
+		t.Fatalf("CreateDepartment: %v", err)
+	}
+	want, _ := format.Format(42)
+	if resp.DepartmentID != want {
+		t.Errorf("DepartmentID = %s, want %s", resp.DepartmentID, want)
+	}
+}
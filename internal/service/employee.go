@@ -3,130 +3,746 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"strings"
+	"time"
 )
 
 type EmployeeService interface {
-	CreateEmployee(ctx context.Context, req *models.CreateEmployeeRequest) (*models.EmployeeResponse, error)
-	UpdateEmployee(ctx context.Context, identityNumber string, req *models.UpdateEmployeeRequest) (*models.EmployeeResponse, error)
-	DeleteEmployee(ctx context.Context, identityNumber string) error
+	CreateEmployee(ctx context.Context, userID uint, req *models.CreateEmployeeRequest) (*models.EmployeeResponse, error)
+	GetEmployee(ctx context.Context, identityNumber string) (*models.EmployeeResponse, error)
+	UpdateEmployee(ctx context.Context, userID uint, identityNumber string, req *models.UpdateEmployeeRequest, ifMatch string) (*models.EmployeeResponse, error)
+	DeleteEmployee(ctx context.Context, userID uint, identityNumber string, ifMatch string) error
 	ListEmployees(ctx context.Context, filter *models.EmployeeFilter) ([]*models.EmployeeResponse, error)
+	ListTrash(ctx context.Context, userID uint, filter *models.EmployeeFilter) ([]*models.TrashedEmployeeResponse, error)
+	RestoreEmployee(ctx context.Context, userID uint, identityNumber string) (*models.EmployeeResponse, error)
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error)
+	ImportEmployees(ctx context.Context, userID uint, rows []*models.EmployeeImportRow, dryRun bool) (*models.EmployeeImportResult, error)
+	ExportEmployees(ctx context.Context, filter *models.EmployeeFilter, fn func(*models.EmployeeResponse) error) error
+	ListReports(ctx context.Context, identityNumber string, maxLevel int) ([]*models.EmployeeReportResponse, error)
+	OrgChart(ctx context.Context, filter *models.OrgChartFilter) ([]*models.OrgChartNode, error)
+	GetHistory(ctx context.Context, identityNumber string) (*models.EmployeeHistoryResponse, error)
+	ScheduleTransfer(ctx context.Context, userID uint, identityNumber string, req *models.ScheduleTransferRequest) (*models.EmployeeTransferResponse, error)
+	CancelTransfer(ctx context.Context, userID uint, identityNumber string, transferID uint) (*models.EmployeeTransferResponse, error)
+	ApplyDueTransfers(ctx context.Context, today time.Time) (int, int, error)
+	BackfillHistory(ctx context.Context) (int, error)
 }
 
+// Jumlah employee yang dipurge per batch
+const employeePurgeBatchSize = 100
+
+var errWorkEmailExists = errors.New("work email already exists")
+
+var (
+	errEmployeeAccessDenied = errors.New("unauthorized access to employee")
+	errUserAlreadyLinked    = errors.New("user is already linked to another employee")
+)
+
 type employeeService struct {
 	employeeRepo   repository.EmployeeRepository
 	departmentRepo repository.DepartmentRepository
+	userRepo       repository.UserRepository
+	historyRepo    repository.EmployeeHistoryRepository
+	txManager      repository.TxManager
+	fileRefs       FileReferenceService
 }
 
-func NewEmployeeService(employeeRepo repository.EmployeeRepository, departmentRepo repository.DepartmentRepository) EmployeeService {
+func NewEmployeeService(employeeRepo repository.EmployeeRepository, departmentRepo repository.DepartmentRepository, userRepo repository.UserRepository, historyRepo repository.EmployeeHistoryRepository, txManager repository.TxManager, fileRefs FileReferenceService) EmployeeService {
 	return &employeeService{
 		employeeRepo:   employeeRepo,
 		departmentRepo: departmentRepo,
+		userRepo:       userRepo,
+		historyRepo:    historyRepo,
+		txManager:      txManager,
+		fileRefs:       fileRefs,
 	}
 }
 
-func (s *employeeService) CreateEmployee(ctx context.Context, req *models.CreateEmployeeRequest) (*models.EmployeeResponse, error) {
-	// Check if department exists
-	dept, err := s.departmentRepo.FindByDepartmentID(ctx, req.DepartmentId)
-	if err != nil {
+func (s *employeeService) CreateEmployee(ctx context.Context, userID uint, req *models.CreateEmployeeRequest) (*models.EmployeeResponse, error) {
+	employee := &models.Employee{
+		IdentityNumber: req.IdentityNumber,
+		Name:           req.Name,
+		Gender:         req.Gender,
+		DepartmentID:   req.DepartmentId,
+		OwnerID:        userID,
+	}
+	if err := employee.ApplyDetails(req.Details(), time.Now()); err != nil {
 		return nil, err
 	}
-	if dept == nil {
-		return nil, errors.New("department not found")
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Check if department exists, lock agar tidak dihapus sebelum insert selesai
+		dept, err := s.departmentRepo.FindByDepartmentIDForShare(ctx, userID, req.DepartmentId)
+		if err != nil {
+			return err
+		}
+		if dept == nil {
+			return errors.New("department not found")
+		}
+		if dept.ArchivedAt != nil {
+			return errors.New("department is archived")
+		}
+
+		// Check if identity number exists
+		existingEmp, err := s.employeeRepo.FindByIdentityNumber(ctx, req.IdentityNumber)
+		if err != nil {
+			return err
+		}
+		if existingEmp != nil {
+			return errors.New("identity number already exists")
+		}
+
+		if err := s.checkWorkEmail(ctx, employee.WorkEmail, 0); err != nil {
+			return err
+		}
+
+		if err := s.setManager(ctx, employee, req.ManagerIdentityNumber); err != nil {
+			return err
+		}
+
+		if err := s.setUser(ctx, employee, req.UserEmail); err != nil {
+			return err
+		}
+
+		// Gambar harus file yang diupload user sendiri
+		image, err := s.fileRefs.ResolveImage(ctx, userID, req.EmployeeImageFileId, req.EmployeeImageUri)
+		if err != nil {
+			return err
+		}
+		employee.EmployeeImageUri = image.URI
+
+		// Create employee
+		if err := s.employeeRepo.Create(ctx, employee); err != nil {
+			return err
+		}
+		if err := s.recordHistory(ctx, employee, nil, historyStart(employee, time.Now())); err != nil {
+			return err
+		}
+
+		return s.fileRefs.SetReference(ctx, models.FileReferenceEmployee, employee.ID, models.FileFieldEmployeeImage, image)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if identity number exists
-	existingEmp, err := s.employeeRepo.FindByIdentityNumber(ctx, req.IdentityNumber)
+	s.signImageURI(employee)
+	return employee.ToResponse(), nil
+}
+
+func (s *employeeService) GetEmployee(ctx context.Context, identityNumber string) (*models.EmployeeResponse, error) {
+	employee, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
 	if err != nil {
 		return nil, err
 	}
-	if existingEmp != nil {
-		return nil, errors.New("identity number already exists")
+	if employee == nil {
+		return nil, errors.New("employee not found")
 	}
 
-	// Create employee
-	employee := &models.Employee{
-		IdentityNumber:   req.IdentityNumber,
-		Name:             req.Name,
-		EmployeeImageUri: req.EmployeeImageUri,
-		Gender:           req.Gender,
-		DepartmentID:     req.DepartmentId,
-	}
+	s.signImageURI(employee)
+	return employee.ToResponse(), nil
+}
+
+func (s *employeeService) UpdateEmployee(ctx context.Context, userID uint, identityNumber string, req *models.UpdateEmployeeRequest, ifMatch string) (*models.EmployeeResponse, error) {
+	var employee *models.Employee
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Check if employee exists
+		var err error
+		employee, err = s.employeeRepo.FindByIdentityNumberForUpdate(ctx, identityNumber)
+		if err != nil {
+			return err
+		}
+		if employee == nil {
+			return errors.New("employee not found")
+		}
+
+		// Check If-Match precondition
+		if !models.MatchesIfMatch(ifMatch, employee.Version) {
+			return errors.New("precondition failed")
+		}
+
+		isOwner, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, employee.DepartmentID)
+		if err != nil {
+			return err
+		}
+
+		// Department, jabatan dan atasan sebelum diubah, untuk history
+		today := time.Now()
+		before := employee.HistoryEntry(today)
+
+		// Check if department exists, employee tetap di tenant yang sama
+		dept, err := s.departmentRepo.FindByDepartmentIDForShare(ctx, employee.OwnerID, req.DepartmentId)
+		if err != nil {
+			return err
+		}
+		if dept == nil {
+			return errors.New("department not found")
+		}
+		if req.DepartmentId != employee.DepartmentID {
+			// Employee lama tetap boleh diupdate, tapi tidak bisa dipindah ke department yang diarsipkan
+			if dept.ArchivedAt != nil {
+				return errors.New("department is archived")
+			}
+			// Kepala department hanya bisa memindahkan employee di dalam wilayahnya sendiri
+			if _, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, req.DepartmentId); err != nil {
+				return err
+			}
+		}
+
+		// Check if new identity number exists (if changed)
+		if req.IdentityNumber != identityNumber {
+			existingEmp, err := s.employeeRepo.FindByIdentityNumber(ctx, req.IdentityNumber)
+			if err != nil {
+				return err
+			}
+			if existingEmp != nil {
+				return errors.New("identity number already exists")
+			}
+		}
 
-	if err := s.employeeRepo.Create(ctx, employee); err != nil {
+		if err := employee.ApplyDetails(req.Details(), today); err != nil {
+			return err
+		}
+		if err := s.checkWorkEmail(ctx, employee.WorkEmail, employee.ID); err != nil {
+			return err
+		}
+
+		// Atasan hanya dicek ulang jika berubah
+		if req.ManagerIdentityNumber != employee.ManagerIdentityNumber {
+			if err := s.setManager(ctx, employee, req.ManagerIdentityNumber); err != nil {
+				return err
+			}
+		}
+
+		// Hubungan ke akun user menentukan hak akses kepala department, hanya pemilik yang boleh mengubah
+		if req.UserEmail != employee.UserEmail {
+			if !isOwner {
+				return errEmployeeAccessDenied
+			}
+			if err := s.setUser(ctx, employee, req.UserEmail); err != nil {
+				return err
+			}
+		}
+
+		// Gambar hanya divalidasi ulang jika berubah
+		if req.EmployeeImageFileId != nil || req.EmployeeImageUri != employee.EmployeeImageUri {
+			image, err := s.fileRefs.ResolveImage(ctx, userID, req.EmployeeImageFileId, req.EmployeeImageUri)
+			if err != nil {
+				return err
+			}
+			if err := s.fileRefs.SetReference(ctx, models.FileReferenceEmployee, employee.ID, models.FileFieldEmployeeImage, image); err != nil {
+				return err
+			}
+			employee.EmployeeImageUri = image.URI
+		}
+
+		// Update employee
+		employee.IdentityNumber = req.IdentityNumber
+		employee.Name = req.Name
+		employee.Gender = req.Gender
+		employee.DepartmentID = req.DepartmentId
+
+		if err := s.recordHistory(ctx, employee, before, today); err != nil {
+			return err
+		}
+
+		return s.employeeRepo.Update(ctx, employee)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.signImageURI(employee)
 	return employee.ToResponse(), nil
 }
 
-func (s *employeeService) UpdateEmployee(ctx context.Context, identityNumber string, req *models.UpdateEmployeeRequest) (*models.EmployeeResponse, error) {
-	// Check if employee exists
-	employee, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
+func (s *employeeService) DeleteEmployee(ctx context.Context, userID uint, identityNumber string, ifMatch string) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		employee, err := s.employeeRepo.FindByIdentityNumberForUpdate(ctx, identityNumber)
+		if err != nil {
+			return err
+		}
+		if employee == nil {
+			return errors.New("employee not found")
+		}
+
+		// Check If-Match precondition
+		if !models.MatchesIfMatch(ifMatch, employee.Version) {
+			return errors.New("precondition failed")
+		}
+
+		if _, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, employee.DepartmentID); err != nil {
+			return err
+		}
+
+		// Masuk trash, referensi gambar tetap disimpan supaya file tidak terhapus sebelum
+		// employee dipurge dan gambar masih ada saat employee direstore
+		return s.employeeRepo.Delete(ctx, identityNumber)
+	})
+}
+
+func (s *employeeService) ListEmployees(ctx context.Context, filter *models.EmployeeFilter) ([]*models.EmployeeResponse, error) {
+	employees, err := s.employeeRepo.List(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	if employee == nil {
-		return nil, errors.New("employee not found")
+
+	var response []*models.EmployeeResponse
+	for _, emp := range employees {
+		s.signImageURI(emp)
+		response = append(response, emp.ToResponse())
 	}
 
-	// Check if department exists
-	dept, err := s.departmentRepo.FindByDepartmentID(ctx, req.DepartmentId)
+	return response, nil
+}
+
+// ExportEmployees memanggil fn untuk setiap employee yang cocok dengan filter, data dibaca
+// streaming dari database sehingga jumlah employee tidak mempengaruhi pemakaian memori
+func (s *employeeService) ExportEmployees(ctx context.Context, filter *models.EmployeeFilter, fn func(*models.EmployeeResponse) error) error {
+	return s.employeeRepo.Stream(ctx, filter, func(employee *models.Employee) error {
+		s.signImageURI(employee)
+		return fn(employee.ToResponse())
+	})
+}
+
+// ListTrash mengembalikan employee di trash yang boleh dipulihkan user: employee di department
+// miliknya atau di department yang dikepalai user
+func (s *employeeService) ListTrash(ctx context.Context, userID uint, filter *models.EmployeeFilter) ([]*models.TrashedEmployeeResponse, error) {
+	employees, err := s.employeeRepo.ListDeleted(ctx, userID, filter)
 	if err != nil {
 		return nil, err
 	}
-	if dept == nil {
-		return nil, errors.New("department not found")
+
+	response := make([]*models.TrashedEmployeeResponse, 0, len(employees))
+	for _, emp := range employees {
+		s.signImageURI(emp)
+		response = append(response, emp.ToTrashedResponse())
 	}
 
-	// Check if new identity number exists (if changed)
-	if req.IdentityNumber != identityNumber {
-		existingEmp, err := s.employeeRepo.FindByIdentityNumber(ctx, req.IdentityNumber)
+	return response, nil
+}
+
+// RestoreEmployee mengeluarkan employee dari trash. Gagal jika identity number sudah dipakai
+// employee lain atau department-nya sudah dihapus. Hak akses sama seperti update dan delete.
+func (s *employeeService) RestoreEmployee(ctx context.Context, userID uint, identityNumber string) (*models.EmployeeResponse, error) {
+	var employee *models.Employee
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		employee, err = s.employeeRepo.FindDeletedByIdentityNumberForUpdate(ctx, identityNumber)
+		if err != nil {
+			return err
+		}
+		if employee == nil {
+			return errors.New("employee not found")
+		}
+
+		existingEmp, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if existingEmp != nil {
-			return nil, errors.New("identity number already exists")
+			return errors.New("identity number already exists")
 		}
-	}
 
-	// Update employee
-	employee.IdentityNumber = req.IdentityNumber
-	employee.Name = req.Name
-	employee.EmployeeImageUri = req.EmployeeImageUri
-	employee.Gender = req.Gender
-	employee.DepartmentID = req.DepartmentId
+		if err := s.checkWorkEmail(ctx, employee.WorkEmail, employee.ID); err != nil {
+			return err
+		}
+		if employee.UserID != nil {
+			if err := s.checkUserLink(ctx, *employee.UserID, employee.ID); err != nil {
+				return err
+			}
+		}
+
+		dept, err := s.departmentRepo.FindByDepartmentIDForShare(ctx, employee.OwnerID, employee.DepartmentID)
+		if err != nil {
+			return err
+		}
+		if dept == nil {
+			return errors.New("department not found")
+		}
+		if _, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, employee.DepartmentID); err != nil {
+			return err
+		}
 
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+		return s.employeeRepo.Restore(ctx, employee)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.signImageURI(employee)
 	return employee.ToResponse(), nil
 }
 
-func (s *employeeService) DeleteEmployee(ctx context.Context, identityNumber string) error {
-	employee, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
+// setManager mengatur atasan employee. Atasan harus employee aktif dan employee tidak boleh
+// menjadi atasan (langsung atau tidak langsung) dari atasannya sendiri.
+func (s *employeeService) setManager(ctx context.Context, employee *models.Employee, managerIdentityNumber string) error {
+	if managerIdentityNumber == "" {
+		employee.ManagerID = nil
+		employee.ManagerIdentityNumber = ""
+		return nil
+	}
+
+	manager, err := s.employeeRepo.FindByIdentityNumber(ctx, managerIdentityNumber)
 	if err != nil {
 		return err
 	}
-	if employee == nil {
-		return errors.New("employee not found")
+	if manager == nil {
+		return errors.New("manager not found")
 	}
 
-	return s.employeeRepo.Delete(ctx, identityNumber)
+	// Employee baru belum punya bawahan, tidak mungkin membentuk siklus
+	if employee.ID != 0 {
+		if manager.ID == employee.ID {
+			return errors.New("employee cannot be their own manager")
+		}
+		inChain, err := s.employeeRepo.IsInManagementChain(ctx, employee.ID, manager.ID)
+		if err != nil {
+			return err
+		}
+		if inChain {
+			return errors.New("manager assignment would create a cycle")
+		}
+	}
+
+	employee.ManagerID = &manager.ID
+	employee.ManagerIdentityNumber = manager.IdentityNumber
+	return nil
 }
 
-func (s *employeeService) ListEmployees(ctx context.Context, filter *models.EmployeeFilter) ([]*models.EmployeeResponse, error) {
-	employees, err := s.employeeRepo.List(ctx, filter)
+// ListReports mengembalikan bawahan employee sampai maxLevel tingkat, 0 = semua tingkat
+func (s *employeeService) ListReports(ctx context.Context, identityNumber string, maxLevel int) ([]*models.EmployeeReportResponse, error) {
+	employee, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
 	if err != nil {
 		return nil, err
 	}
+	if employee == nil {
+		return nil, errors.New("employee not found")
+	}
 
-	var response []*models.EmployeeResponse
-	for _, emp := range employees {
-		response = append(response, emp.ToResponse())
+	reports, err := s.employeeRepo.ListReports(ctx, employee.ID, maxLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*models.EmployeeReportResponse, 0, len(reports))
+	for _, report := range reports {
+		s.signImageURI(&report.Employee)
+		response = append(response, report.ToResponse())
 	}
 
 	return response, nil
 }
+
+// OrgChart menyusun struktur organisasi dari relasi atasan. Employee tanpa atasan aktif (atau
+// atasannya di luar department yang difilter) menjadi puncak.
+func (s *employeeService) OrgChart(ctx context.Context, filter *models.OrgChartFilter) ([]*models.OrgChartNode, error) {
+	employees, err := s.employeeRepo.ListOrgChart(ctx, filter.OwnerID, filter.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*models.OrgChartNode, len(employees))
+	for _, employee := range employees {
+		nodes[employee.ID] = &models.OrgChartNode{
+			IdentityNumber: employee.IdentityNumber,
+			Name:           employee.Name,
+			JobTitle:       employee.JobTitle,
+			DepartmentID:   employee.DepartmentID,
+			Reports:        []*models.OrgChartNode{},
+		}
+	}
+
+	roots := []*models.OrgChartNode{}
+	for _, employee := range employees {
+		node := nodes[employee.ID]
+		if filter.Root != "" && employee.IdentityNumber == filter.Root {
+			roots = []*models.OrgChartNode{node}
+		}
+
+		if employee.ManagerID != nil {
+			if manager, ok := nodes[*employee.ManagerID]; ok {
+				manager.Reports = append(manager.Reports, node)
+				continue
+			}
+		}
+		if filter.Root == "" {
+			roots = append(roots, node)
+		}
+	}
+
+	if filter.Root != "" && len(roots) == 0 {
+		return nil, errors.New("employee not found")
+	}
+
+	return roots, nil
+}
+
+// signImageURI memperbarui signature link gambar employee sebelum dikirim ke client
+func (s *employeeService) signImageURI(employee *models.Employee) {
+	employee.EmployeeImageUri = s.fileRefs.SignURI(employee.EmployeeImageUri)
+}
+
+// checkWorkEmail memastikan work email belum dipakai employee aktif lain, excludeID untuk employee itu sendiri
+func (s *employeeService) checkWorkEmail(ctx context.Context, workEmail string, excludeID uint) error {
+	if workEmail == "" {
+		return nil
+	}
+	existing, err := s.employeeRepo.FindByWorkEmail(ctx, workEmail)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != excludeID {
+		return errWorkEmailExists
+	}
+	return nil
+}
+
+// checkEmployeeAccess memastikan user boleh mengubah employee di department tersebut, yaitu pemilik
+// department atau kepala department itu atau salah satu induknya. departmentID dicari di tenant ownerID.
+// Nilai bool true jika user pemilik department.
+func (s *employeeService) checkEmployeeAccess(ctx context.Context, userID, ownerID uint, departmentID string) (bool, error) {
+	dept, err := s.departmentRepo.FindByDepartmentID(ctx, ownerID, departmentID)
+	if err != nil {
+		return false, err
+	}
+	if dept != nil && dept.UserID == userID {
+		return true, nil
+	}
+
+	isHead, err := s.departmentRepo.IsHead(ctx, userID, ownerID, departmentID)
+	if err != nil {
+		return false, err
+	}
+	if !isHead {
+		return false, errEmployeeAccessDenied
+	}
+	return false, nil
+}
+
+// setUser menghubungkan employee ke akun user berdasarkan email, kosong berarti tanpa akun
+func (s *employeeService) setUser(ctx context.Context, employee *models.Employee, userEmail string) error {
+	if userEmail == "" {
+		employee.UserID = nil
+		employee.UserEmail = ""
+		return nil
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, userEmail)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if err := s.checkUserLink(ctx, user.ID, employee.ID); err != nil {
+		return err
+	}
+
+	employee.UserID = &user.ID
+	employee.UserEmail = user.Email
+	return nil
+}
+
+// checkUserLink memastikan akun user belum terhubung ke employee aktif lain
+func (s *employeeService) checkUserLink(ctx context.Context, userID uint, excludeID uint) error {
+	existing, err := s.employeeRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != excludeID {
+		return errUserAlreadyLinked
+	}
+	return nil
+}
+
+// PurgeTrash menghapus permanen employee yang dihapus sebelum deletedBefore beserta referensi gambarnya
+func (s *employeeService) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged := 0
+	for {
+		employees, err := s.employeeRepo.ListDeletedBefore(ctx, deletedBefore, employeePurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		if len(employees) == 0 {
+			return purged, nil
+		}
+
+		for _, employee := range employees {
+			err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				if err := s.fileRefs.RemoveReferences(ctx, models.FileReferenceEmployee, employee.ID); err != nil {
+					return err
+				}
+				return s.employeeRepo.Purge(ctx, employee.ID)
+			})
+			if err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+}
+
+// ImportEmployees menjalankan pengecekan yang sama dengan CreateEmployee untuk setiap baris,
+// lalu menyimpan semua baris dalam satu transaksi. Jika ada satu baris saja yang error atau
+// dryRun, tidak ada yang disimpan dan result berisi error per baris.
+func (s *employeeService) ImportEmployees(ctx context.Context, userID uint, rows []*models.EmployeeImportRow, dryRun bool) (*models.EmployeeImportResult, error) {
+	var result *models.EmployeeImportResult
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		result = &models.EmployeeImportResult{
+			DryRun:    dryRun,
+			TotalRows: len(rows),
+			Errors:    []models.EmployeeImportError{},
+		}
+
+		departments := make(map[string]*models.Department)
+		seen := make(map[string]int)
+		seenEmails := make(map[string]int)
+		seenUsers := make(map[uint]int)
+		employees := make([]*models.Employee, len(rows))
+		images := make([]*models.ImageLink, len(rows))
+		today := time.Now()
+
+		for i, row := range rows {
+			if len(row.Errors) > 0 {
+				result.Errors = append(result.Errors, row.Errors...)
+				continue
+			}
+
+			req := &row.Request
+			rowError := func(field, message string) {
+				result.Errors = append(result.Errors, models.EmployeeImportError{
+					Row:            row.Row,
+					IdentityNumber: req.IdentityNumber,
+					Field:          field,
+					Message:        message,
+				})
+			}
+			errCount := len(result.Errors)
+
+			// Identity number harus unik di dalam file juga
+			if first, ok := seen[req.IdentityNumber]; ok {
+				rowError("identityNumber", fmt.Sprintf("duplicate identity number, already used in row %d", first))
+			} else {
+				seen[req.IdentityNumber] = row.Row
+
+				existingEmp, err := s.employeeRepo.FindByIdentityNumber(ctx, req.IdentityNumber)
+				if err != nil {
+					return err
+				}
+				if existingEmp != nil {
+					rowError("identityNumber", "identity number already exists")
+				}
+			}
+
+			employee := &models.Employee{
+				IdentityNumber: req.IdentityNumber,
+				Name:           req.Name,
+				Gender:         req.Gender,
+				DepartmentID:   req.DepartmentId,
+				OwnerID:        userID,
+			}
+			if err := employee.ApplyDetails(req.Details(), today); err != nil {
+				rowError("", err.Error())
+			}
+
+			// Work email juga harus unik di dalam file
+			if email := strings.ToLower(employee.WorkEmail); email != "" {
+				if first, ok := seenEmails[email]; ok {
+					rowError("workEmail", fmt.Sprintf("duplicate work email, already used in row %d", first))
+				} else {
+					seenEmails[email] = row.Row
+					if err := s.checkWorkEmail(ctx, employee.WorkEmail, 0); err != nil {
+						if err != errWorkEmailExists {
+							return err
+						}
+						rowError("workEmail", err.Error())
+					}
+				}
+			}
+
+			// Department dicek sekali per ID, lock agar tidak dihapus sebelum import selesai
+			dept, ok := departments[req.DepartmentId]
+			if !ok {
+				var err error
+				dept, err = s.departmentRepo.FindByDepartmentIDForShare(ctx, userID, req.DepartmentId)
+				if err != nil {
+					return err
+				}
+				departments[req.DepartmentId] = dept
+			}
+			if dept == nil {
+				rowError("departmentId", "department not found")
+			} else if dept.ArchivedAt != nil {
+				rowError("departmentId", "department is archived")
+			}
+
+			// Atasan harus sudah ada di database, sama seperti CreateEmployee
+			if err := s.setManager(ctx, employee, req.ManagerIdentityNumber); err != nil {
+				if err.Error() != "manager not found" {
+					return err
+				}
+				rowError("managerIdentityNumber", err.Error())
+			}
+
+			// Satu akun user hanya boleh terhubung ke satu employee, termasuk di dalam file
+			if err := s.setUser(ctx, employee, req.UserEmail); err != nil {
+				if err.Error() != "user not found" && err != errUserAlreadyLinked {
+					return err
+				}
+				rowError("userEmail", err.Error())
+			} else if employee.UserID != nil {
+				if first, ok := seenUsers[*employee.UserID]; ok {
+					rowError("userEmail", fmt.Sprintf("duplicate user email, already used in row %d", first))
+				} else {
+					seenUsers[*employee.UserID] = row.Row
+				}
+			}
+
+			image, err := s.fileRefs.ResolveImage(ctx, userID, req.EmployeeImageFileId, req.EmployeeImageUri)
+			if err != nil {
+				switch err.Error() {
+				case "image must be an uploaded file", "image file not found":
+					rowError("employeeImageUri", err.Error())
+				default:
+					return err
+				}
+			}
+
+			if len(result.Errors) == errCount {
+				result.ValidRows++
+				employees[i] = employee
+				images[i] = image
+			}
+		}
+
+		if len(result.Errors) > 0 || dryRun {
+			return nil
+		}
+
+		for i, employee := range employees {
+			employee.EmployeeImageUri = images[i].URI
+			if err := s.employeeRepo.Create(ctx, employee); err != nil {
+				return err
+			}
+			if err := s.recordHistory(ctx, employee, nil, historyStart(employee, today)); err != nil {
+				return err
+			}
+			if err := s.fileRefs.SetReference(ctx, models.FileReferenceEmployee, employee.ID, models.FileFieldEmployeeImage, images[i]); err != nil {
+				return err
+			}
+		}
+		result.Imported = len(rows)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
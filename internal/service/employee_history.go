@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"time"
+)
+
+// Jumlah transfer yang diterapkan per batch
+const employeeTransferBatchSize = 100
+
+// Jumlah employee yang diisi history-nya per batch saat backfill
+const employeeHistoryBackfillBatchSize = 1000
+
+// GetHistory mengembalikan riwayat department, jabatan dan atasan employee beserta transfer terjadwalnya
+func (s *employeeService) GetHistory(ctx context.Context, identityNumber string) (*models.EmployeeHistoryResponse, error) {
+	employee, err := s.employeeRepo.FindByIdentityNumber(ctx, identityNumber)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.New("employee not found")
+	}
+
+	history, err := s.historyRepo.ListByEmployee(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := s.historyRepo.ListTransfersByEmployee(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.EmployeeHistoryResponse{
+		IdentityNumber: employee.IdentityNumber,
+		History:        make([]*models.EmployeeHistoryEntry, 0, len(history)),
+		Transfers:      make([]*models.EmployeeTransferResponse, 0, len(transfers)),
+	}
+	for _, entry := range history {
+		response.History = append(response.History, entry.ToEntry())
+	}
+	for _, transfer := range transfers {
+		response.Transfers = append(response.Transfers, transfer.ToResponse())
+	}
+
+	return response, nil
+}
+
+// ScheduleTransfer menjadwalkan perpindahan employee di tanggal yang akan datang. Perubahan yang
+// berlaku hari ini cukup lewat PATCH /v1/employee/:identityNumber.
+func (s *employeeService) ScheduleTransfer(ctx context.Context, userID uint, identityNumber string, req *models.ScheduleTransferRequest) (*models.EmployeeTransferResponse, error) {
+	effectiveDate, err := req.EffectiveDateValue()
+	if err != nil {
+		return nil, err
+	}
+	if !effectiveDate.After(models.DateOf(time.Now())) {
+		return nil, errors.New("effective date must be in the future")
+	}
+
+	var transfer *models.EmployeeTransfer
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		employee, err := s.employeeRepo.FindByIdentityNumberForUpdate(ctx, identityNumber)
+		if err != nil {
+			return err
+		}
+		if employee == nil {
+			return errors.New("employee not found")
+		}
+
+		if _, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, employee.DepartmentID); err != nil {
+			return err
+		}
+
+		// Department dicek sekarang supaya kesalahan langsung terlihat, dicek ulang saat diterapkan
+		dept, err := s.departmentRepo.FindByDepartmentID(ctx, employee.OwnerID, req.DepartmentId)
+		if err != nil {
+			return err
+		}
+		if dept == nil {
+			return errors.New("department not found")
+		}
+		if dept.ArchivedAt != nil {
+			return errors.New("department is archived")
+		}
+		if req.DepartmentId != employee.DepartmentID {
+			if _, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, req.DepartmentId); err != nil {
+				return err
+			}
+		}
+
+		existing, err := s.historyRepo.ListTransfersByEmployee(ctx, employee.ID)
+		if err != nil {
+			return err
+		}
+		for _, t := range existing {
+			if t.Status == models.TransferStatusPending && t.EffectiveDate.Equal(effectiveDate) {
+				return errors.New("transfer already scheduled for this date")
+			}
+		}
+
+		transfer = &models.EmployeeTransfer{
+			EmployeeID:    employee.ID,
+			DepartmentID:  req.DepartmentId,
+			JobTitle:      req.JobTitle,
+			EffectiveDate: effectiveDate,
+			Status:        models.TransferStatusPending,
+		}
+
+		// Validasi atasan memakai salinan, data employee baru berubah saat transfer diterapkan
+		transfer.ClearManager = req.ClearManager
+		if req.ManagerIdentityNumber != "" {
+			candidate := *employee
+			if err := s.setManager(ctx, &candidate, req.ManagerIdentityNumber); err != nil {
+				return err
+			}
+			transfer.ManagerID = candidate.ManagerID
+			transfer.ManagerIdentityNumber = candidate.ManagerIdentityNumber
+		}
+
+		return s.historyRepo.CreateTransfer(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer.ToResponse(), nil
+}
+
+// CancelTransfer membatalkan transfer yang belum diterapkan
+func (s *employeeService) CancelTransfer(ctx context.Context, userID uint, identityNumber string, transferID uint) (*models.EmployeeTransferResponse, error) {
+	var transfer *models.EmployeeTransfer
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		employee, err := s.employeeRepo.FindByIdentityNumberForUpdate(ctx, identityNumber)
+		if err != nil {
+			return err
+		}
+		if employee == nil {
+			return errors.New("employee not found")
+		}
+
+		if _, err := s.checkEmployeeAccess(ctx, userID, employee.OwnerID, employee.DepartmentID); err != nil {
+			return err
+		}
+
+		transfer, err = s.historyRepo.FindTransferForUpdate(ctx, transferID)
+		if err != nil {
+			return err
+		}
+		if transfer == nil || transfer.EmployeeID != employee.ID {
+			return errors.New("transfer not found")
+		}
+		if transfer.Status != models.TransferStatusPending {
+			return errors.New("transfer is not pending")
+		}
+
+		transfer.Status = models.TransferStatusCancelled
+		return s.historyRepo.UpdateTransfer(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer.ToResponse(), nil
+}
+
+// ApplyDueTransfers menerapkan transfer pending yang tanggal berlakunya sudah sampai, dipanggil
+// berkala oleh job employee-transfer. Transfer yang tidak lagi valid (misalnya department sudah
+// diarsipkan) ditandai failed. Nilai kembalian: jumlah transfer yang diterapkan dan yang gagal.
+func (s *employeeService) ApplyDueTransfers(ctx context.Context, today time.Time) (int, int, error) {
+	applied, failed := 0, 0
+	for {
+		transfers, err := s.historyRepo.ListDueTransfers(ctx, models.DateOf(today), employeeTransferBatchSize)
+		if err != nil {
+			return applied, failed, err
+		}
+		if len(transfers) == 0 {
+			return applied, failed, nil
+		}
+
+		for _, due := range transfers {
+			var status string
+			err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				status = ""
+
+				transfer, err := s.historyRepo.FindTransferForUpdate(ctx, due.ID)
+				if err != nil {
+					return err
+				}
+				// Sudah dibatalkan atau diproses di antara list dan lock
+				if transfer == nil || transfer.Status != models.TransferStatusPending {
+					return nil
+				}
+
+				reason, err := s.applyTransfer(ctx, transfer)
+				if err != nil {
+					return err
+				}
+				if reason != "" {
+					transfer.Status = models.TransferStatusFailed
+					transfer.FailureReason = reason
+				} else {
+					now := time.Now()
+					transfer.Status = models.TransferStatusApplied
+					transfer.AppliedAt = &now
+				}
+				status = transfer.Status
+
+				return s.historyRepo.UpdateTransfer(ctx, transfer)
+			})
+			if err != nil {
+				return applied, failed, err
+			}
+
+			switch status {
+			case models.TransferStatusApplied:
+				applied++
+			case models.TransferStatusFailed:
+				failed++
+			}
+		}
+	}
+}
+
+// applyTransfer mengubah employee sesuai transfer dan mencatat history-nya. Alasan gagal dikembalikan
+// sebagai string, error hanya untuk kegagalan database.
+func (s *employeeService) applyTransfer(ctx context.Context, transfer *models.EmployeeTransfer) (string, error) {
+	employee, err := s.employeeRepo.FindByIDForUpdate(ctx, transfer.EmployeeID)
+	if err != nil {
+		return "", err
+	}
+	if employee == nil {
+		return "employee not found", nil
+	}
+
+	dept, err := s.departmentRepo.FindByDepartmentIDForShare(ctx, employee.OwnerID, transfer.DepartmentID)
+	if err != nil {
+		return "", err
+	}
+	if dept == nil {
+		return "department not found", nil
+	}
+	if dept.ArchivedAt != nil {
+		return "department is archived", nil
+	}
+
+	before := employee.HistoryEntry(transfer.EffectiveDate)
+	employee.DepartmentID = transfer.DepartmentID
+	if transfer.JobTitle != "" {
+		employee.JobTitle = transfer.JobTitle
+	}
+
+	// Atasan dicek ulang, bisa saja sudah dihapus atau struktur berubah sejak transfer dijadwalkan
+	if transfer.ClearManager {
+		if err := s.setManager(ctx, employee, ""); err != nil {
+			return "", err
+		}
+	} else if transfer.ManagerID != nil {
+		if err := s.setManager(ctx, employee, transfer.ManagerIdentityNumber); err != nil {
+			switch err.Error() {
+			case "manager not found", "employee cannot be their own manager", "manager assignment would create a cycle":
+				return err.Error(), nil
+			default:
+				return "", err
+			}
+		}
+	}
+
+	if before.SameAssignment(employee) {
+		return "", nil
+	}
+
+	// Job yang terlambat (misalnya sempat tidak jalan) bisa menerapkan transfer setelah ada perubahan
+	// lain yang dicatat sesudah tanggal transfer. Record mengganti periode berjalan yang dimulai pada
+	// atau setelah tanggal entry, jadi periode baru dimulai paling awal dari periode terakhir itu.
+	effectiveFrom := transfer.EffectiveDate
+	current, err := s.historyRepo.FindCurrent(ctx, employee.ID)
+	if err != nil {
+		return "", err
+	}
+	if current != nil && current.EffectiveFrom.After(effectiveFrom) {
+		effectiveFrom = current.EffectiveFrom
+	}
+	if err := s.historyRepo.Record(ctx, employee.HistoryEntry(effectiveFrom)); err != nil {
+		return "", err
+	}
+	return "", s.employeeRepo.Update(ctx, employee)
+}
+
+// recordHistory memulai periode history baru jika department, jabatan atau atasan berbeda dari before
+func (s *employeeService) recordHistory(ctx context.Context, employee *models.Employee, before *models.EmployeeHistory, effectiveFrom time.Time) error {
+	if before != nil && before.SameAssignment(employee) {
+		return nil
+	}
+	return s.historyRepo.Record(ctx, employee.HistoryEntry(effectiveFrom))
+}
+
+// historyStart adalah awal periode history pertama employee baru: tanggal masuk jika diisi, selain itu hari ini
+func historyStart(employee *models.Employee, today time.Time) time.Time {
+	if employee.HireDate != nil {
+		return *employee.HireDate
+	}
+	return today
+}
+
+// BackfillHistory mengisi history awal untuk employee yang dibuat sebelum ada history, dijalankan
+// sekali setelah upgrade lewat cmd/historybackfill. Return jumlah employee yang diisi.
+func (s *employeeService) BackfillHistory(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		filled, err := s.historyRepo.Backfill(ctx, employeeHistoryBackfillBatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += int(filled)
+		if filled < employeeHistoryBackfillBatchSize {
+			return total, nil
+		}
+	}
+}
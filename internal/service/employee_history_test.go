@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeEmployeeRepository menyimpan employee di memori berdasarkan ID
+type fakeEmployeeRepository struct {
+	repository.EmployeeRepository
+
+	employees map[uint]*models.Employee
+}
+
+func (r *fakeEmployeeRepository) FindByIDForUpdate(ctx context.Context, id uint) (*models.Employee, error) {
+	employee, ok := r.employees[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *employee
+	return &copied, nil
+}
+
+func (r *fakeEmployeeRepository) Update(ctx context.Context, employee *models.Employee) error {
+	copied := *employee
+	r.employees[employee.ID] = &copied
+	return nil
+}
+
+// fakeEmployeeHistoryRepository menyimpan periode history dan transfer di memori,
+// Record mengikuti query repository: periode berjalan yang dimulai pada atau setelah entry diganti
+type fakeEmployeeHistoryRepository struct {
+	repository.EmployeeHistoryRepository
+
+	history   []*models.EmployeeHistory
+	transfers map[uint]*models.EmployeeTransfer
+}
+
+func (r *fakeEmployeeHistoryRepository) Record(ctx context.Context, entry *models.EmployeeHistory) error {
+	kept := r.history[:0]
+	for _, h := range r.history {
+		if h.EmployeeID == entry.EmployeeID && h.EffectiveTo == nil && !h.EffectiveFrom.Before(entry.EffectiveFrom) {
+			continue
+		}
+		kept = append(kept, h)
+	}
+	r.history = kept
+
+	for _, h := range r.history {
+		if h.EmployeeID == entry.EmployeeID && h.EffectiveTo == nil {
+			effectiveTo := entry.EffectiveFrom
+			h.EffectiveTo = &effectiveTo
+		}
+	}
+	r.history = append(r.history, entry)
+	return nil
+}
+
+func (r *fakeEmployeeHistoryRepository) FindCurrent(ctx context.Context, employeeID uint) (*models.EmployeeHistory, error) {
+	for _, h := range r.history {
+		if h.EmployeeID == employeeID && h.EffectiveTo == nil {
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeEmployeeHistoryRepository) ListDueTransfers(ctx context.Context, date time.Time, limit int) ([]*models.EmployeeTransfer, error) {
+	var due []*models.EmployeeTransfer
+	for _, t := range r.transfers {
+		if t.Status == models.TransferStatusPending && !t.EffectiveDate.After(date) {
+			copied := *t
+			due = append(due, &copied)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].EffectiveDate.Before(due[j].EffectiveDate) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *fakeEmployeeHistoryRepository) FindTransferForUpdate(ctx context.Context, id uint) (*models.EmployeeTransfer, error) {
+	transfer, ok := r.transfers[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *transfer
+	return &copied, nil
+}
+
+func (r *fakeEmployeeHistoryRepository) UpdateTransfer(ctx context.Context, transfer *models.EmployeeTransfer) error {
+	copied := *transfer
+	r.transfers[transfer.ID] = &copied
+	return nil
+}
+
+func testDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+func TestApplyDueTransfers(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string // Awal periode berjalan, history sebelumnya dimulai 2026-01-01
+		transfer string
+		want     []string // Awal setiap periode setelah transfer diterapkan
+	}{
+		{
+			name:     "transfer starts a new period on its date",
+			current:  "2026-02-01",
+			transfer: "2026-03-10",
+			want:     []string{"2026-01-01", "2026-02-01", "2026-03-10"},
+		},
+		{
+			// Job tidak jalan tanggal 10, jabatan diubah manual tanggal 11, transfer baru diterapkan tanggal 12
+			name:     "late transfer keeps periods recorded after its date",
+			current:  "2026-03-11",
+			transfer: "2026-03-10",
+			want:     []string{"2026-01-01", "2026-03-11"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			current := testDate(t, tt.current)
+
+			employees := &fakeEmployeeRepository{employees: map[uint]*models.Employee{
+				1: {ID: 1, OwnerID: 1, DepartmentID: "DEP-01", JobTitle: "Senior Engineer"},
+			}}
+			departments := newFakeDepartmentRepository()
+			departments.departments[1] = map[string]*models.Department{
+				"DEP-02": {UserID: 1, DepartmentID: "DEP-02", Name: "Platform"},
+			}
+			history := &fakeEmployeeHistoryRepository{
+				history: []*models.EmployeeHistory{
+					{EmployeeID: 1, DepartmentID: "DEP-01", JobTitle: "Engineer", EffectiveFrom: testDate(t, "2026-01-01"), EffectiveTo: &current},
+					{EmployeeID: 1, DepartmentID: "DEP-01", JobTitle: "Senior Engineer", EffectiveFrom: current},
+				},
+				transfers: map[uint]*models.EmployeeTransfer{
+					1: {ID: 1, EmployeeID: 1, DepartmentID: "DEP-02", EffectiveDate: testDate(t, tt.transfer), Status: models.TransferStatusPending},
+				},
+			}
+			svc := NewEmployeeService(employees, departments, nil, history, fakeTxManager{}, nil)
+
+			applied, failed, err := svc.ApplyDueTransfers(ctx, testDate(t, "2026-03-12"))
+			if err != nil {
+				t.Fatalf("ApplyDueTransfers() error = %v", err)
+			}
+			if applied != 1 || failed != 0 {
+				t.Fatalf("applied, failed = %d, %d, want 1, 0", applied, failed)
+			}
+
+			periods := history.history
+			sort.Slice(periods, func(i, j int) bool { return periods[i].EffectiveFrom.Before(periods[j].EffectiveFrom) })
+			if len(periods) != len(tt.want) {
+				t.Fatalf("got %d periods, want %d", len(periods), len(tt.want))
+			}
+			for i, period := range periods {
+				if got := period.EffectiveFrom.Format(time.DateOnly); got != tt.want[i] {
+					t.Errorf("period %d starts %s, want %s", i, got, tt.want[i])
+				}
+				// Setiap periode berakhir tepat saat periode berikutnya dimulai
+				if i+1 < len(periods) && (period.EffectiveTo == nil || !period.EffectiveTo.Equal(periods[i+1].EffectiveFrom)) {
+					t.Errorf("period %d ends %v, want %s", i, period.EffectiveTo, periods[i+1].EffectiveFrom.Format(time.DateOnly))
+				}
+			}
+
+			// Periode berjalan memuat department transfer dan jabatan dari perubahan manual
+			last := periods[len(periods)-1]
+			if last.EffectiveTo != nil || last.DepartmentID != "DEP-02" || last.JobTitle != "Senior Engineer" {
+				t.Errorf("current period = %s %s until %v, want open DEP-02 Senior Engineer", last.DepartmentID, last.JobTitle, last.EffectiveTo)
+			}
+			if employees.employees[1].DepartmentID != "DEP-02" {
+				t.Errorf("employee department = %s, want DEP-02", employees.employees[1].DepartmentID)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"strings"
+	"testing"
+)
+
+func (r *fakeEmployeeRepository) FindByIdentityNumber(ctx context.Context, identityNumber string) (*models.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.IdentityNumber == identityNumber {
+			copied := *employee
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeEmployeeRepository) FindByIdentityNumberForUpdate(ctx context.Context, identityNumber string) (*models.Employee, error) {
+	return r.FindByIdentityNumber(ctx, identityNumber)
+}
+
+func (r *fakeEmployeeRepository) Delete(ctx context.Context, identityNumber string) error {
+	for id, employee := range r.employees {
+		if employee.IdentityNumber == identityNumber {
+			delete(r.employees, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeEmployeeRepository) FindByWorkEmail(ctx context.Context, workEmail string) (*models.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.WorkEmail != "" && strings.EqualFold(employee.WorkEmail, workEmail) {
+			copied := *employee
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeEmployeeRepository) Create(ctx context.Context, employee *models.Employee) error {
+	employee.ID = uint(len(r.employees) + 1)
+	copied := *employee
+	r.employees[employee.ID] = &copied
+	return nil
+}
+
+// fakeFileReferenceService menerima employee tanpa gambar
+type fakeFileReferenceService struct {
+	FileReferenceService
+}
+
+func (fakeFileReferenceService) ResolveImage(ctx context.Context, userID uint, fileID *uint, uri string) (*models.ImageLink, error) {
+	return &models.ImageLink{URI: uri}, nil
+}
+
+func (fakeFileReferenceService) SetReference(ctx context.Context, entityType string, entityID uint, field string, link *models.ImageLink) error {
+	return nil
+}
+
+func (fakeFileReferenceService) SignURI(uri string) string {
+	return uri
+}
+
+func importRow(row int, identityNumber, departmentID, workEmail string) *models.EmployeeImportRow {
+	return &models.EmployeeImportRow{
+		Row: row,
+		Request: models.CreateEmployeeRequest{
+			IdentityNumber: identityNumber,
+			Name:           "Employee " + identityNumber,
+			Gender:         "female",
+			DepartmentId:   departmentID,
+			WorkEmail:      workEmail,
+		},
+	}
+}
+
+func TestImportEmployees(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       []*models.EmployeeImportRow
+		dryRun     bool
+		wantValid  int
+		wantErrors []models.EmployeeImportError
+	}{
+		{
+			name:      "all rows imported",
+			rows:      []*models.EmployeeImportRow{importRow(2, "1000001", "DEP-01", ""), importRow(3, "1000002", "DEP-01", "")},
+			wantValid: 2,
+		},
+		{
+			name:      "dry run",
+			rows:      []*models.EmployeeImportRow{importRow(2, "1000001", "DEP-01", "")},
+			dryRun:    true,
+			wantValid: 1,
+		},
+		{
+			name: "row errors reject the whole file",
+			rows: []*models.EmployeeImportRow{
+				importRow(2, "1000001", "DEP-01", "a@example.com"),
+				importRow(3, "1000001", "DEP-01", ""),
+				importRow(4, "1000003", "DEP-99", ""),
+				importRow(5, "9999999", "DEP-01", ""),
+				importRow(6, "1000006", "DEP-01", "A@example.com"),
+				importRow(7, "1000007", "DEP-02", ""),
+				{Row: 8, Errors: []models.EmployeeImportError{{Row: 8, Field: "gender", Message: "Gender is invalid"}}},
+			},
+			wantValid: 1,
+			wantErrors: []models.EmployeeImportError{
+				{Row: 3, IdentityNumber: "1000001", Field: "identityNumber", Message: "duplicate identity number, already used in row 2"},
+				{Row: 4, IdentityNumber: "1000003", Field: "departmentId", Message: "department not found"},
+				{Row: 5, IdentityNumber: "9999999", Field: "identityNumber", Message: "identity number already exists"},
+				{Row: 6, IdentityNumber: "1000006", Field: "workEmail", Message: "duplicate work email, already used in row 2"},
+				{Row: 7, IdentityNumber: "1000007", Field: "departmentId", Message: "department not found"},
+				{Row: 8, Field: "gender", Message: "Gender is invalid"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			employees := &fakeEmployeeRepository{employees: map[uint]*models.Employee{
+				1: {ID: 1, OwnerID: 1, IdentityNumber: "9999999", DepartmentID: "DEP-01"},
+			}}
+			departments := newFakeDepartmentRepository()
+			departments.departments[1] = map[string]*models.Department{
+				"DEP-01": {UserID: 1, DepartmentID: "DEP-01", Name: "Engineering"},
+			}
+			// DEP-02 milik tenant lain, tidak bisa dipakai user 1
+			departments.departments[2] = map[string]*models.Department{
+				"DEP-02": {UserID: 2, DepartmentID: "DEP-02", Name: "Finance"},
+			}
+			history := &fakeEmployeeHistoryRepository{}
+			svc := NewEmployeeService(employees, departments, nil, history, fakeTxManager{}, fakeFileReferenceService{})
+
+			result, err := svc.ImportEmployees(context.Background(), 1, tt.rows, tt.dryRun)
+			if err != nil {
+				t.Fatalf("ImportEmployees() error = %v", err)
+			}
+
+			if result.TotalRows != len(tt.rows) || result.ValidRows != tt.wantValid {
+				t.Errorf("total, valid = %d, %d, want %d, %d", result.TotalRows, result.ValidRows, len(tt.rows), tt.wantValid)
+			}
+			if len(result.Errors) != len(tt.wantErrors) {
+				t.Fatalf("errors = %+v, want %+v", result.Errors, tt.wantErrors)
+			}
+			for i, want := range tt.wantErrors {
+				if result.Errors[i] != want {
+					t.Errorf("errors[%d] = %+v, want %+v", i, result.Errors[i], want)
+				}
+			}
+
+			// Import semua atau tidak sama sekali
+			wantImported := 0
+			if len(tt.wantErrors) == 0 && !tt.dryRun {
+				wantImported = len(tt.rows)
+			}
+			if result.Imported != wantImported || len(employees.employees) != 1+wantImported || len(history.history) != wantImported {
+				t.Errorf("imported %d, stored %d employees and %d history periods, want %d new", result.Imported, len(employees.employees)-1, len(history.history), wantImported)
+			}
+			for _, employee := range employees.employees {
+				if employee.OwnerID != 1 {
+					t.Errorf("employee %s owner = %d, want 1", employee.IdentityNumber, employee.OwnerID)
+				}
+			}
+		})
+	}
+}
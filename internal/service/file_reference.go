@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"strings"
+)
+
+// FileReferenceService menghubungkan field gambar (employee, profile) dengan file yang diupload
+type FileReferenceService interface {
+	// ResolveImage memvalidasi input gambar: fileID diutamakan, jika kosong uri harus link file milik user
+	ResolveImage(ctx context.Context, userID uint, fileID *uint, uri string) (*models.ImageLink, error)
+	// SetReference menyimpan (atau menghapus jika link kosong) referensi field entity ke file
+	SetReference(ctx context.Context, entityType string, entityID uint, field string, link *models.ImageLink) error
+	// RemoveReferences menghapus semua referensi milik entity, dipanggil saat entity dihapus
+	RemoveReferences(ctx context.Context, entityType string, entityID uint) error
+	// SignURI mengganti signature link file yang tersimpan dengan yang masih berlaku, dipanggil setiap dibaca
+	SignURI(uri string) string
+}
+
+type fileReferenceService struct {
+	fileRepo      repository.FileRepository
+	referenceRepo repository.FileReferenceRepository
+	signer        *urlsign.Signer
+	baseURL       string
+}
+
+func NewFileReferenceService(fileRepo repository.FileRepository, referenceRepo repository.FileReferenceRepository, signer *urlsign.Signer, baseURL string) FileReferenceService {
+	return &fileReferenceService{
+		fileRepo:      fileRepo,
+		referenceRepo: referenceRepo,
+		signer:        signer,
+		baseURL:       baseURL,
+	}
+}
+
+func (s *fileReferenceService) ResolveImage(ctx context.Context, userID uint, fileID *uint, uri string) (*models.ImageLink, error) {
+	// Gambar dikosongkan
+	if fileID == nil && uri == "" {
+		return &models.ImageLink{}, nil
+	}
+
+	var variant string
+	if fileID == nil {
+		// URI harus link /v1/file/:id/content yang valid, host eksternal ditolak
+		// Link yang sudah kedaluwarsa tetap diterima, kepemilikan file dicek di bawah
+		id, v, ok := verifyFileContentURL(s.signer, uri)
+		if !ok {
+			return nil, errors.New("image must be an uploaded file")
+		}
+		fileID, variant = &id, v
+	}
+
+	file, err := s.fileRepo.FindByID(ctx, *fileID)
+	if err != nil {
+		return nil, err
+	}
+	// File milik user lain diperlakukan sama dengan file yang tidak ada
+	if file == nil || file.UserID != userID || file.Status != models.FileStatusReady {
+		return nil, errors.New("image file not found")
+	}
+	if !isImageType(file.FileType) {
+		return nil, errors.New("image file not found")
+	}
+
+	if variant != "" {
+		found := false
+		for _, v := range file.Variants {
+			if v.Name() == variant {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("image file not found")
+		}
+	}
+
+	return &models.ImageLink{
+		FileID: file.ID,
+		URI:    fileContentURL(s.baseURL, s.signer, file.ID, variant),
+	}, nil
+}
+
+func (s *fileReferenceService) SetReference(ctx context.Context, entityType string, entityID uint, field string, link *models.ImageLink) error {
+	if link == nil || link.FileID == 0 {
+		return s.referenceRepo.Delete(ctx, entityType, entityID, field)
+	}
+
+	return s.referenceRepo.Set(ctx, &models.FileReference{
+		FileID:     link.FileID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Field:      field,
+	})
+}
+
+func (s *fileReferenceService) RemoveReferences(ctx context.Context, entityType string, entityID uint) error {
+	return s.referenceRepo.DeleteByEntity(ctx, entityType, entityID)
+}
+
+func (s *fileReferenceService) SignURI(uri string) string {
+	return resignFileContentURL(s.baseURL, s.signer, uri)
+}
+
+func isImageType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
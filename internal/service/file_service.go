@@ -1,44 +1,702 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"github.com/google/uuid"
+	"io"
+	"log"
 	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Batas waktu presigned upload
+const presignedUploadTTL = 15 * time.Minute
+
 type FileService interface {
-	UploadFile(ctx context.Context, file *multipart.FileHeader, userID uint, userEmail string) (*models.FileUploadResponse, error)
+	UploadFile(ctx context.Context, file *multipart.FileHeader, purpose string, userID uint, userEmail string) (*models.FileUploadResponse, error)
+	CreateUpload(ctx context.Context, userID uint, userEmail string, req *models.CreateUploadRequest) (*models.PresignedUploadResponse, error)
+	CompleteUpload(ctx context.Context, userID uint, fileID uint) (*models.FileUploadResponse, error)
+	FinishUpload(ctx context.Context, userID uint, fileID uint) (*models.FileUploadResponse, error)
+	WriteSignedFile(ctx context.Context, key, expires, size, contentType, signature string, body io.Reader, contentLength int64) error
+	ListFiles(ctx context.Context, userID uint, filter *models.FileFilter) ([]*models.FileResponse, error)
+	GetFile(ctx context.Context, userID uint, fileID uint) (*models.FileResponse, error)
+	DeleteFile(ctx context.Context, userID uint, fileID uint) error
+	OpenSignedFile(ctx context.Context, key, expires, signature string) (io.ReadCloser, *ObjectInfo, error)
+	ResolveContentURL(ctx context.Context, fileID uint, variant, expires, signature string) (string, error)
 }
 
 type fileService struct {
 	storageService StorageService
+	fileRepo       repository.FileRepository
+	referenceRepo  repository.FileReferenceRepository
+	txManager      repository.TxManager
+	quotaService   QuotaService
+	scanner        Scanner
+	signer         *urlsign.Signer
+	policies       map[string]models.UploadPolicy // Batasan upload per purpose
+	baseURL        string                         // Base URL API untuk URI file yang disimpan
 }
 
-func NewFileService(storageService StorageService) FileService {
+func NewFileService(storageService StorageService, fileRepo repository.FileRepository, referenceRepo repository.FileReferenceRepository, txManager repository.TxManager, quotaService QuotaService, scanner Scanner, signer *urlsign.Signer, policies map[string]models.UploadPolicy, baseURL string) FileService {
 	return &fileService{
 		storageService: storageService,
+		fileRepo:       fileRepo,
+		referenceRepo:  referenceRepo,
+		txManager:      txManager,
+		quotaService:   quotaService,
+		scanner:        scanner,
+		signer:         signer,
+		policies:       policies,
+		baseURL:        baseURL,
 	}
 }
 
-func (s *fileService) UploadFile(ctx context.Context, file *multipart.FileHeader, userID uint, userEmail string) (*models.FileUploadResponse, error) {
-	// Validate file
-	validator := &models.FileValidator{
-		File:      file,
-		MaxSize:   102400, // 100KiB in bytes
-		MimeTypes: []string{"image/jpeg", "image/jpg", "image/png"},
+func (s *fileService) UploadFile(ctx context.Context, file *multipart.FileHeader, purpose string, userID uint, userEmail string) (*models.FileUploadResponse, error) {
+	purpose, policy, err := s.uploadPolicy(purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open file
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	// Validasi file dari isinya (bukan dari Content-Type client) sekaligus scan malware
+	// sebelum file disimpan ke storage
+	upload, err := s.inspectUpload(ctx, file.Filename, src, file.Size, policy)
+	if err != nil {
+		return nil, err
+	}
+	if !upload.Scan.Clean {
+		log.Printf("rejected upload %s from user %d: %s\n", file.Filename, userID, upload.Scan.Signature)
+		return nil, infectedFileError
+	}
+	scannedAt := time.Now()
+
+	// Generate unique filename, ekstensi mengikuti tipe hasil deteksi
+	key := fmt.Sprintf("%s/%s", userEmail, uuid.New().String())
+	stored, err := s.storeUpload(ctx, key, upload, func() (io.ReadCloser, error) {
+		// File multipart ada di memori atau file sementara, dibaca ulang dari awal
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(src), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ID diambil lebih dulu supaya URI stabil /v1/file/:id/content bisa ikut disimpan
+	fileID, err := s.fileRepo.NextID(ctx)
+	if err != nil {
+		s.deleteObjects(ctx, stored.keys())
+		return nil, err
+	}
+
+	// Simpan metadata file
+	record := &models.File{
+		ID:         fileID,
+		UserID:     userID,
+		Filename:   filepath.Base(file.Filename),
+		StorageKey: stored.Key,
+		FileType:   stored.ContentType,
+		FileSize:   stored.Size,
+		Checksum:   stored.Checksum,
+		Purpose:    purpose,
+		ScannedAt:  &scannedAt,
+		Variants:   stored.Variants,
+	}
+	s.assignURIs(record)
+
+	// Cek kuota dan simpan record dalam satu transaksi
+	err = s.quotaService.Reserve(ctx, userID, stored.totalSize(), func(ctx context.Context) error {
+		return s.fileRepo.Create(ctx, record)
+	})
+	if err != nil {
+		// Metadata gagal disimpan atau kuota habis, hapus object supaya tidak jadi sampah
+		s.deleteObjects(ctx, stored.keys())
+		return nil, err
+	}
+
+	return record.ToUploadResponse(), nil
+}
+
+// CreateUpload membuat record file pending dan presigned URL untuk upload langsung ke storage
+func (s *fileService) CreateUpload(ctx context.Context, userID uint, userEmail string, req *models.CreateUploadRequest) (*models.PresignedUploadResponse, error) {
+	purpose, policy, err := s.uploadPolicy(req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validasi ukuran dan tipe file, batasannya ikut di-sign di URL.
+	// Isi file divalidasi ulang saat complete.
+	var validationErrors models.FileValidationErrors
+	if req.Size > policy.MaxSize {
+		validationErrors = append(validationErrors, models.FileValidationError{
+			Field:   "size",
+			Message: fmt.Sprintf("File size exceeds maximum limit of %d KiB", policy.MaxSize/1024),
+		})
+	}
+	if !policy.Allows(req.ContentType) {
+		validationErrors = append(validationErrors, models.FileValidationError{
+			Field:   "contentType",
+			Message: fmt.Sprintf("File type must be one of: %s", strings.Join(policy.AllowedTypes, ", ")),
+		})
+	}
+	if len(validationErrors) > 0 {
+		return nil, validationErrors
+	}
+
+	key := fmt.Sprintf("%s/%s%s", userEmail, uuid.New().String(), models.UploadExtension(req.ContentType))
+
+	// Presign lebih dulu, jika gagal belum ada record pending dan kuota yang terpakai
+	presigned, err := s.storageService.PresignPut(ctx, key, req.ContentType, req.Size, presignedUploadTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	fileID, err := s.fileRepo.NextID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := validator.Validate(); err != nil {
-		return nil, err // Langsung return error validasi
+	record := &models.File{
+		ID:         fileID,
+		UserID:     userID,
+		Filename:   filepath.Base(req.Filename),
+		StorageKey: key,
+		FileType:   req.ContentType,
+		FileSize:   req.Size,
+		Status:     models.FileStatusPending,
+		Purpose:    purpose,
 	}
+	s.assignURIs(record)
 
-	// Upload to storage
-	uri, err := s.storageService.UploadFile(ctx, file, userEmail)
+	// Ukuran yang di-sign langsung dipesan dari kuota selama upload pending
+	err = s.quotaService.Reserve(ctx, userID, req.Size, func(ctx context.Context) error {
+		return s.fileRepo.Create(ctx, record)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.FileUploadResponse{
-		URI: uri,
+	return &models.PresignedUploadResponse{
+		ID:        record.ID,
+		UploadURL: presigned.URL,
+		Method:    presigned.Method,
+		Headers:   presigned.Headers,
+		ExpiresAt: presigned.ExpiresAt,
 	}, nil
 }
+
+// CompleteUpload memverifikasi object hasil presigned upload lalu menandai file ready
+func (s *fileService) CompleteUpload(ctx context.Context, userID uint, fileID uint) (*models.FileUploadResponse, error) {
+	return s.completeUpload(ctx, userID, fileID, presignedUploadTTL)
+}
+
+// FinishUpload sama dengan CompleteUpload tanpa batas waktu presigned upload,
+// dipakai upload resumable (tus) yang punya masa berlaku sendiri
+func (s *fileService) FinishUpload(ctx context.Context, userID uint, fileID uint) (*models.FileUploadResponse, error) {
+	return s.completeUpload(ctx, userID, fileID, 0)
+}
+
+// completeUpload memvalidasi object file pending lalu menandai file ready, ttl 0 berarti tanpa batas waktu.
+// Scan dan penulisan object dilakukan di luar transaksi, transaksi hanya mengubah status record.
+func (s *fileService) completeUpload(ctx context.Context, userID uint, fileID uint, ttl time.Duration) (*models.FileUploadResponse, error) {
+	pending, err := s.findOwnedFile(ctx, userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if pending.Status != models.FileStatusPending {
+		return nil, errors.New("upload already completed")
+	}
+	if ttl > 0 && time.Since(pending.CreatedAt) > ttl {
+		return nil, errors.New("upload expired")
+	}
+
+	// Pastikan object benar-benar ada dan ukurannya sesuai yang di-sign
+	info, err := s.storageService.StatObject(ctx, pending.StorageKey)
+	if err == errObjectNotFound {
+		return nil, errors.New("uploaded object not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size != pending.FileSize {
+		return nil, errors.New("uploaded object does not match")
+	}
+
+	_, policy, err := s.uploadPolicy(pending.Purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	// URL presigned masih bisa menimpa object di key upload sampai kedaluwarsa, jadi isinya disalin
+	// dulu ke key baru yang tidak pernah di-sign. Validasi, scan dan file ready memakai salinan ini.
+	verifiedKey := path.Dir(pending.StorageKey) + "/" + uuid.New().String() + filepath.Ext(pending.StorageKey)
+	if err := s.copyObject(ctx, pending.StorageKey, verifiedKey, info); err != nil {
+		return nil, err
+	}
+
+	// Validasi ulang isi file yang diupload langsung ke storage
+	body, _, err := s.storageService.GetObject(ctx, verifiedKey)
+	if err != nil {
+		s.deleteObjects(ctx, []string{verifiedKey})
+		return nil, err
+	}
+	upload, err := s.inspectUpload(ctx, pending.StorageKey, body, info.Size, policy)
+	body.Close()
+	if err != nil {
+		s.deleteObjects(ctx, []string{verifiedKey})
+		return nil, err
+	}
+	scannedAt := time.Now()
+
+	// File tetap pending (tidak bisa diakses) sampai dinyatakan bersih oleh scanner
+	if !upload.Scan.Clean {
+		// Salinan dibiarkan untuk investigasi, file sweeper menghapusnya setelah grace period
+		log.Printf("quarantined file %d from user %d: %s\n", pending.ID, pending.UserID, upload.Scan.Signature)
+		_, err := s.markUploaded(ctx, fileID, func(record *models.File) {
+			record.StorageKey = verifiedKey
+			record.Status = models.FileStatusQuarantined
+			record.ScanResult = upload.Scan.Signature
+			record.ScannedAt = &scannedAt
+		})
+		if err != nil {
+			s.deleteObjects(ctx, []string{verifiedKey})
+			return nil, err
+		}
+		s.deleteObjects(ctx, []string{pending.StorageKey})
+		return nil, infectedFileError
+	}
+
+	// Salinan tidak perlu ditulis ulang, kecuali ekstensi key berubah mengikuti tipe hasil deteksi
+	var open func() (io.ReadCloser, error)
+	if uploadKey(verifiedKey, upload.Detected) != verifiedKey {
+		open = func() (io.ReadCloser, error) {
+			body, _, err := s.storageService.GetObject(ctx, verifiedKey)
+			return body, err
+		}
+	}
+	stored, err := s.storeUpload(ctx, verifiedKey, upload, open)
+	if err != nil {
+		s.deleteObjects(ctx, []string{verifiedKey})
+		return nil, err
+	}
+
+	record, err := s.markUploaded(ctx, fileID, func(record *models.File) {
+		record.StorageKey = stored.Key
+		record.FileType = stored.ContentType
+		record.FileSize = stored.Size
+		record.Checksum = stored.Checksum
+		record.Status = models.FileStatusReady
+		record.ScannedAt = &scannedAt
+		record.Variants = make([]models.FileVariant, len(stored.Variants))
+		for i, variant := range stored.Variants {
+			variant.FileID = record.ID
+			record.Variants[i] = variant
+		}
+	})
+	if err != nil {
+		// Setiap complete menulis ke key-nya sendiri, termasuk request lain yang lebih dulu selesai
+		s.deleteObjects(ctx, append(uploadedKeys(stored, verifiedKey), verifiedKey))
+		return nil, err
+	}
+
+	// Object di key presigned dan salinan yang diganti ekstensinya tidak lagi dipakai
+	obsolete := []string{pending.StorageKey}
+	if stored.Key != verifiedKey {
+		obsolete = append(obsolete, verifiedKey)
+	}
+	s.deleteObjects(ctx, obsolete)
+
+	s.assignURIs(record)
+	return record.ToUploadResponse(), nil
+}
+
+// copyObject menyalin object src ke dst, ukuran dibatasi info.Size hasil stat sebelumnya
+func (s *fileService) copyObject(ctx context.Context, src, dst string, info *ObjectInfo) error {
+	body, _, err := s.storageService.GetObject(ctx, src)
+	if err == errObjectNotFound {
+		return errors.New("uploaded object not found")
+	}
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return s.storageService.PutObject(ctx, dst, io.LimitReader(body, info.Size), info.Size, info.ContentType)
+}
+
+// markUploaded mengunci record upload yang masih pending lalu menyimpan hasil dari apply
+// beserta variant-nya. Closure bisa diulang saat transaksi di-retry.
+func (s *fileService) markUploaded(ctx context.Context, fileID uint, apply func(record *models.File)) (*models.File, error) {
+	var record *models.File
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		record, err = s.fileRepo.FindByIDForUpdate(ctx, fileID)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			return errors.New("file not found")
+		}
+		if record.Status != models.FileStatusPending {
+			return errors.New("upload already completed")
+		}
+
+		apply(record)
+		if err := s.fileRepo.Update(ctx, record); err != nil {
+			return err
+		}
+		return s.fileRepo.CreateVariants(ctx, record.Variants)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// uploadedKeys mengembalikan key object yang ditulis saat complete selain object upload asli
+func uploadedKeys(stored *storedFile, original string) []string {
+	var keys []string
+	for _, key := range stored.keys() {
+		if key != original {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// WriteSignedFile menerima upload dari presigned URL backend local/memory (PUT /v1/files/<key>)
+func (s *fileService) WriteSignedFile(ctx context.Context, key, expires, size, contentType, signature string, body io.Reader, contentLength int64) error {
+	// Upload tanpa expires tidak diizinkan
+	if expires == "" {
+		return errors.New("invalid signature")
+	}
+	if err := verifyFileSignature(s.signer, "PUT", key, expires, signature, size, contentType); err != nil {
+		return err
+	}
+
+	// Ukuran body harus sama dengan yang di-sign
+	if strconv.FormatInt(contentLength, 10) != size {
+		return errors.New("uploaded object does not match")
+	}
+
+	// Key presigned hanya bisa ditulis selama file-nya pending. Setelah complete isi yang diverifikasi
+	// sudah dipindah ke key lain, URL yang belum kedaluwarsa tidak boleh lagi membuat object.
+	file, err := s.fileRepo.FindByStorageKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return errors.New("file not found")
+	}
+	if file.Status != models.FileStatusPending {
+		return errors.New("upload already completed")
+	}
+
+	return s.storageService.PutObject(ctx, key, body, contentLength, contentType)
+}
+
+func (s *fileService) ListFiles(ctx context.Context, userID uint, filter *models.FileFilter) ([]*models.FileResponse, error) {
+	filter.Normalize()
+
+	files, err := s.fileRepo.ListByUser(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*models.FileResponse, 0, len(files))
+	for _, file := range files {
+		s.assignURIs(file)
+		response = append(response, file.ToResponse())
+	}
+
+	return response, nil
+}
+
+func (s *fileService) GetFile(ctx context.Context, userID uint, fileID uint) (*models.FileResponse, error) {
+	file, err := s.findOwnedFile(ctx, userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.assignURIs(file)
+	return file.ToResponse(), nil
+}
+
+// ResolveContentURL memverifikasi link stabil /v1/file/:id/content lalu mengembalikan
+// URL storage saat ini (CDN publik atau signed URL berumur pendek)
+func (s *fileService) ResolveContentURL(ctx context.Context, fileID uint, variant, expires, signature string) (string, error) {
+	// Link tanpa masa berlaku tidak lagi diterima, client memakai link terbaru dari response
+	if expires == "" {
+		return "", errors.New("invalid signature")
+	}
+	id := strconv.FormatUint(uint64(fileID), 10)
+	if err := verifyFileSignature(s.signer, "GET", "file/"+id, expires, signature, variant); err != nil {
+		return "", err
+	}
+
+	file, err := s.fileRepo.FindByID(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+	if file == nil || file.Status != models.FileStatusReady {
+		return "", errors.New("file not found")
+	}
+
+	key := file.StorageKey
+	if variant != "" {
+		key = ""
+		for _, v := range file.Variants {
+			if v.Name() == variant {
+				key = v.StorageKey
+				break
+			}
+		}
+		if key == "" {
+			return "", errors.New("file not found")
+		}
+	}
+
+	return s.storageService.URL(ctx, key)
+}
+
+// DeleteFile menghapus metadata file lalu object di storage. Object dihapus setelah transaksi
+// commit, yang gagal dihapus tidak lagi punya record dan dibersihkan file sweeper.
+func (s *fileService) DeleteFile(ctx context.Context, userID uint, fileID uint) error {
+	var file *models.File
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		file, err = s.findOwnedFile(ctx, userID, fileID)
+		if err != nil {
+			return err
+		}
+
+		// File yang masih dipakai employee atau profile tidak boleh dihapus
+		referenced, err := s.referenceRepo.IsReferenced(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		if referenced {
+			return errors.New("file is still referenced")
+		}
+
+		// Variant ikut terhapus lewat ON DELETE CASCADE
+		return s.fileRepo.Delete(ctx, file.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deleteObjects(ctx, fileObjectKeys(file))
+	return nil
+}
+
+// OpenSignedFile membuka file dari URL yang di-sign (/v1/files/<key>?signature=...)
+func (s *fileService) OpenSignedFile(ctx context.Context, key, expires, signature string) (io.ReadCloser, *ObjectInfo, error) {
+	// Semua URL download punya masa berlaku, URL lama tanpa expires ditolak
+	if expires == "" {
+		return nil, nil, errors.New("invalid signature")
+	}
+	if err := verifyFileSignature(s.signer, "GET", key, expires, signature); err != nil {
+		return nil, nil, err
+	}
+
+	body, info, err := s.storageService.GetObject(ctx, key)
+	if err == errObjectNotFound {
+		return nil, nil, errors.New("file not found")
+	}
+	return body, info, err
+}
+
+func (s *fileService) findOwnedFile(ctx context.Context, userID uint, fileID uint) (*models.File, error) {
+	file, err := s.fileRepo.FindByID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, errors.New("file not found")
+	}
+
+	// Verify ownership
+	if file.UserID != userID {
+		return nil, errors.New("unauthorized access to file")
+	}
+
+	return file, nil
+}
+
+// fileObjectKeys mengembalikan key object file beserta semua variant-nya
+func fileObjectKeys(file *models.File) []string {
+	keys := []string{file.StorageKey}
+	for _, variant := range file.Variants {
+		keys = append(keys, variant.StorageKey)
+	}
+	return keys
+}
+
+// assignURIs mengisi URI stabil untuk file dan variant-nya, record lama yang masih
+// menyimpan URI storage ikut terganti
+func (s *fileService) assignURIs(file *models.File) {
+	file.URI = fileContentURL(s.baseURL, s.signer, file.ID, "")
+	for i := range file.Variants {
+		file.Variants[i].URI = fileContentURL(s.baseURL, s.signer, file.ID, file.Variants[i].Name())
+	}
+}
+
+// uploadPolicy mengembalikan batasan upload untuk purpose, purpose kosong memakai default
+func (s *fileService) uploadPolicy(purpose string) (string, models.UploadPolicy, error) {
+	if purpose == "" {
+		purpose = models.DefaultUploadPurpose
+	}
+
+	policy, ok := s.policies[purpose]
+	if !ok {
+		return "", models.UploadPolicy{}, models.FileValidationErrors{{
+			Field:   "purpose",
+			Message: fmt.Sprintf("Purpose must be one of: %s", strings.Join(models.UploadPurposes, ", ")),
+		}}
+	}
+
+	return purpose, policy, nil
+}
+
+// inspectedUpload adalah isi file upload yang sudah divalidasi dan discan
+type inspectedUpload struct {
+	Detected *models.DetectedFile
+	Data     []byte // Seluruh isi file, hanya untuk gambar yang akan didecode ulang
+	Size     int64
+	Checksum string // SHA-256 isi file
+	Scan     *ScanResult
+}
+
+// inspectUpload memvalidasi dan men-scan isi file tanpa menampung seluruh file di memori.
+// Tipe file dideteksi dari header lalu sisa file di-stream ke scanner. Hanya gambar yang
+// dibaca utuh karena harus didecode ulang, ukurannya dibatasi MaxSize policy.
+func (s *fileService) inspectUpload(ctx context.Context, name string, r io.Reader, size int64, policy models.UploadPolicy) (*inspectedUpload, error) {
+	header := make([]byte, min(size, models.SniffLength))
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	validator := &models.FileValidator{
+		Data:   header,
+		Size:   size,
+		Policy: policy,
+	}
+	detected, err := validator.Detect()
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &inspectedUpload{
+		Detected: detected,
+		Size:     size,
+	}
+	content := io.MultiReader(bytes.NewReader(header), io.LimitReader(r, size-int64(len(header))))
+	if detected.IsImage {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		validator.Data = data
+		if upload.Detected, err = validator.Validate(); err != nil {
+			return nil, err
+		}
+		upload.Data = data
+		content = bytes.NewReader(data)
+	}
+
+	// Checksum dan jumlah byte dihitung dari data yang sama dengan yang discan
+	hash := sha256.New()
+	var read byteCounter
+	content = io.TeeReader(content, io.MultiWriter(hash, &read))
+
+	if upload.Scan, err = scanFile(ctx, s.scanner, name, content); err != nil {
+		return nil, err
+	}
+	if !upload.Scan.Clean {
+		return upload, nil
+	}
+
+	// Scanner boleh berhenti sebelum EOF (misalnya noop), sisa file tetap dibaca untuk checksum
+	if _, err := io.Copy(io.Discard, content); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(read) != size {
+		return nil, errors.New("uploaded object does not match")
+	}
+	upload.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	return upload, nil
+}
+
+// uploadKey mengganti ekstensi key mengikuti tipe hasil deteksi
+func uploadKey(key string, detected *models.DetectedFile) string {
+	return strings.TrimSuffix(key, filepath.Ext(key)) + detected.Extension
+}
+
+// storeUpload menyimpan file yang sudah diinspeksi, gambar diproses dulu lewat storeImage.
+// File lain di-stream dari open, open nil berarti object sudah tersimpan di key.
+func (s *fileService) storeUpload(ctx context.Context, key string, upload *inspectedUpload, open func() (io.ReadCloser, error)) (*storedFile, error) {
+	key = uploadKey(key, upload.Detected)
+	if upload.Detected.IsImage {
+		return s.storeImage(ctx, key, upload.Data)
+	}
+
+	stored := &storedFile{
+		Key:         key,
+		ContentType: upload.Detected.ContentType,
+		Size:        upload.Size,
+		Checksum:    upload.Checksum,
+	}
+	if open == nil {
+		return stored, nil
+	}
+
+	body, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if err := s.storageService.PutObject(ctx, key, io.LimitReader(body, stored.Size), stored.Size, stored.ContentType); err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// byteCounter menghitung jumlah byte yang ditulis
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// sha256Hex menghitung checksum SHA-256 dari reader dalam format hex
+func sha256Hex(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
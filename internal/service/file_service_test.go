@@ -0,0 +1,364 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/clamav"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testUploadPolicy = models.UploadPolicy{
+	AllowedTypes: []string{"application/pdf", "image/png"},
+	MaxSize:      1 << 20,
+	MaxWidth:     1024,
+	MaxHeight:    1024,
+}
+
+// newTestFileService membuat fileService dengan storage memory dan FakeScanner, cukup untuk
+// inspeksi dan penyimpanan upload yang tidak menyentuh database
+func newTestFileService(scanner Scanner) *fileService {
+	signer := urlsign.NewSigner("test-secret")
+	return &fileService{
+		storageService: NewMemoryStorageService("http://localhost:8080", signer),
+		scanner:        scanner,
+		signer:         signer,
+		baseURL:        "http://localhost:8080",
+	}
+}
+
+// testPDF membuat isi PDF sebesar size byte, payload ditaruh setelah header deteksi tipe
+func testPDF(size int, payload []byte) []byte {
+	data := []byte("%PDF-1.4\n")
+	data = append(data, bytes.Repeat([]byte("0"), size-len(data)-len(payload))...)
+	return append(data, payload...)
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspectUpload(t *testing.T) {
+	pdf := testPDF(4*models.SniffLength, nil)
+	infected := testPDF(4*models.SniffLength, clamav.EICAR)
+	photo := testPNG(t, 100, 80)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		size        int64
+		scanner     Scanner
+		wantType    string
+		wantClean   bool
+		wantErr     error
+		wantInvalid bool // FileValidationErrors
+	}{
+		{
+			name:      "clean pdf is streamed through the scanner",
+			data:      pdf,
+			size:      int64(len(pdf)),
+			scanner:   &FakeScanner{},
+			wantType:  "application/pdf",
+			wantClean: true,
+		},
+		{
+			name:     "eicar after the sniffed header is detected",
+			data:     infected,
+			size:     int64(len(infected)),
+			scanner:  &FakeScanner{},
+			wantType: "application/pdf",
+		},
+		{
+			name:      "png is validated as an image",
+			data:      photo,
+			size:      int64(len(photo)),
+			scanner:   &FakeScanner{},
+			wantType:  "image/png",
+			wantClean: true,
+		},
+		{
+			name:    "scanner unavailable",
+			data:    pdf,
+			size:    int64(len(pdf)),
+			scanner: &FakeScanner{Err: errors.New("connection refused")},
+			wantErr: errScannerUnavailable,
+		},
+		{
+			name:        "file larger than policy",
+			data:        pdf,
+			size:        testUploadPolicy.MaxSize + 1,
+			scanner:     &FakeScanner{},
+			wantInvalid: true,
+		},
+		{
+			name:        "type not allowed",
+			data:        []byte(strings.Repeat("plain text ", 100)),
+			size:        1100,
+			scanner:     &FakeScanner{},
+			wantInvalid: true,
+		},
+		{
+			name:    "body shorter than declared size",
+			data:    pdf,
+			size:    int64(len(pdf)) + 10,
+			scanner: &FakeScanner{},
+			wantErr: errors.New("uploaded object does not match"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestFileService(tt.scanner)
+
+			got, err := s.inspectUpload(context.Background(), "upload", bytes.NewReader(tt.data), tt.size, testUploadPolicy)
+			if tt.wantInvalid {
+				if _, ok := err.(models.FileValidationErrors); !ok {
+					t.Fatalf("inspectUpload() error = %v, want FileValidationErrors", err)
+				}
+				return
+			}
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("inspectUpload() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("inspectUpload() error = %v", err)
+			}
+
+			if got.Detected.ContentType != tt.wantType {
+				t.Errorf("ContentType = %s, want %s", got.Detected.ContentType, tt.wantType)
+			}
+			if got.Scan.Clean != tt.wantClean {
+				t.Errorf("Scan.Clean = %v, want %v", got.Scan.Clean, tt.wantClean)
+			}
+			if !tt.wantClean {
+				return
+			}
+
+			sum := sha256.Sum256(tt.data)
+			if got.Checksum != hex.EncodeToString(sum[:]) {
+				t.Errorf("Checksum = %s, want checksum of the whole file", got.Checksum)
+			}
+			// Hanya gambar yang ditampung utuh di memori
+			if got.Detected.IsImage != (got.Data != nil) {
+				t.Errorf("Data kept = %v, want %v", got.Data != nil, got.Detected.IsImage)
+			}
+		})
+	}
+}
+
+func TestStoreUpload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("file is streamed to storage", func(t *testing.T) {
+		s := newTestFileService(&FakeScanner{})
+		pdf := testPDF(4*models.SniffLength, nil)
+
+		upload, err := s.inspectUpload(ctx, "report.txt", bytes.NewReader(pdf), int64(len(pdf)), testUploadPolicy)
+		if err != nil {
+			t.Fatalf("inspectUpload() error = %v", err)
+		}
+		stored, err := s.storeUpload(ctx, "user@example.com/report.txt", upload, func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(pdf)), nil
+		})
+		if err != nil {
+			t.Fatalf("storeUpload() error = %v", err)
+		}
+
+		// Ekstensi mengikuti tipe hasil deteksi, bukan nama file dari client
+		if stored.Key != "user@example.com/report.pdf" {
+			t.Errorf("Key = %s, want user@example.com/report.pdf", stored.Key)
+		}
+		body, info, err := s.storageService.GetObject(ctx, stored.Key)
+		if err != nil {
+			t.Fatalf("GetObject() error = %v", err)
+		}
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		if !bytes.Equal(data, pdf) || info.ContentType != "application/pdf" {
+			t.Errorf("stored object = %d bytes %s, want %d bytes application/pdf", len(data), info.ContentType, len(pdf))
+		}
+	})
+
+	t.Run("object already at key is not rewritten", func(t *testing.T) {
+		s := newTestFileService(&FakeScanner{})
+		pdf := testPDF(4*models.SniffLength, nil)
+
+		upload, err := s.inspectUpload(ctx, "report.pdf", bytes.NewReader(pdf), int64(len(pdf)), testUploadPolicy)
+		if err != nil {
+			t.Fatalf("inspectUpload() error = %v", err)
+		}
+		stored, err := s.storeUpload(ctx, "user@example.com/report.pdf", upload, nil)
+		if err != nil {
+			t.Fatalf("storeUpload() error = %v", err)
+		}
+		if stored.Checksum != upload.Checksum || stored.Size != int64(len(pdf)) {
+			t.Errorf("stored = %+v, want checksum and size from inspection", stored)
+		}
+		if _, err := s.storageService.StatObject(ctx, stored.Key); err != errObjectNotFound {
+			t.Errorf("StatObject() error = %v, want object not written", err)
+		}
+	})
+
+	t.Run("image is re-encoded with variants", func(t *testing.T) {
+		s := newTestFileService(&FakeScanner{})
+		img := testPNG(t, 100, 80)
+
+		upload, err := s.inspectUpload(ctx, "photo.png", bytes.NewReader(img), int64(len(img)), testUploadPolicy)
+		if err != nil {
+			t.Fatalf("inspectUpload() error = %v", err)
+		}
+		stored, err := s.storeUpload(ctx, "user@example.com/photo", upload, nil)
+		if err != nil {
+			t.Fatalf("storeUpload() error = %v", err)
+		}
+
+		if stored.Key != "user@example.com/photo.png" || stored.ContentType != "image/png" {
+			t.Errorf("stored = %s %s, want user@example.com/photo.png image/png", stored.Key, stored.ContentType)
+		}
+		// Hanya ukuran 64 yang lebih kecil dari gambar, masing-masing dalam png dan webp
+		if len(stored.Variants) != 2 {
+			t.Errorf("len(Variants) = %d, want 2", len(stored.Variants))
+		}
+		for _, key := range stored.keys() {
+			if _, err := s.storageService.StatObject(ctx, key); err != nil {
+				t.Errorf("StatObject(%s) error = %v", key, err)
+			}
+		}
+	})
+}
+
+// fakeFileRepository menyimpan record file di memori, cukup untuk alur complete upload
+type fakeFileRepository struct {
+	repository.FileRepository
+
+	mu    sync.Mutex
+	files map[uint]*models.File
+}
+
+func (r *fakeFileRepository) FindByID(ctx context.Context, id uint) (*models.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if file, ok := r.files[id]; ok {
+		copied := *file
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeFileRepository) FindByIDForUpdate(ctx context.Context, id uint) (*models.File, error) {
+	return r.FindByID(ctx, id)
+}
+
+func (r *fakeFileRepository) FindByStorageKey(ctx context.Context, key string) (*models.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, file := range r.files {
+		if file.StorageKey == key {
+			copied := *file
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeFileRepository) Update(ctx context.Context, file *models.File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *file
+	r.files[file.ID] = &copied
+	return nil
+}
+
+func (r *fakeFileRepository) CreateVariants(ctx context.Context, variants []models.FileVariant) error {
+	return nil
+}
+
+func TestCompleteUploadRevokesPresignedKey(t *testing.T) {
+	ctx := context.Background()
+
+	s := newTestFileService(&FakeScanner{})
+	repo := &fakeFileRepository{files: make(map[uint]*models.File)}
+	s.fileRepo = repo
+	s.txManager = fakeTxManager{}
+	s.policies = map[string]models.UploadPolicy{models.DefaultUploadPurpose: testUploadPolicy}
+
+	pdf := testPDF(4*models.SniffLength, nil)
+	key := "user@example.com/upload.pdf"
+	repo.files[1] = &models.File{
+		ID:         1,
+		UserID:     1,
+		StorageKey: key,
+		FileSize:   int64(len(pdf)),
+		Status:     models.FileStatusPending,
+		CreatedAt:  time.Now(),
+	}
+
+	// Upload lewat URL presigned yang masih berlaku sampai expires
+	expires := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	size := strconv.Itoa(len(pdf))
+	signature := s.signer.Sign("PUT", key, expires, size, "application/pdf")
+	put := func(data []byte) error {
+		return s.WriteSignedFile(ctx, key, expires, size, "application/pdf", signature, bytes.NewReader(data), int64(len(data)))
+	}
+
+	if err := put(pdf); err != nil {
+		t.Fatalf("WriteSignedFile() error = %v", err)
+	}
+	if _, err := s.CompleteUpload(ctx, 1, 1); err != nil {
+		t.Fatalf("CompleteUpload() error = %v", err)
+	}
+
+	file := repo.files[1]
+	if file.Status != models.FileStatusReady {
+		t.Errorf("Status = %s, want %s", file.Status, models.FileStatusReady)
+	}
+	if file.StorageKey == key {
+		t.Fatalf("StorageKey = %s, want a key that was never presigned", file.StorageKey)
+	}
+	if _, err := s.storageService.StatObject(ctx, key); err != errObjectNotFound {
+		t.Errorf("StatObject(presigned key) error = %v, want object deleted", err)
+	}
+
+	// URL yang sama tidak bisa lagi menulis object, isi file yang sudah diverifikasi tidak berubah
+	// Key lama tidak lagi dimiliki file mana pun setelah isinya dipindah
+	if err := put(testPDF(len(pdf), []byte("tampered"))); err == nil || err.Error() != "file not found" {
+		t.Errorf("WriteSignedFile() after complete error = %v, want file not found", err)
+	}
+	if _, err := s.storageService.StatObject(ctx, key); err != errObjectNotFound {
+		t.Errorf("StatObject(presigned key) error = %v, want no object written", err)
+	}
+	body, _, err := s.storageService.GetObject(ctx, file.StorageKey)
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if !bytes.Equal(data, pdf) {
+		t.Errorf("stored object changed after complete")
+	}
+}
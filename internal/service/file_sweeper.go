@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"time"
+)
+
+// Jumlah file/key yang diproses per query
+const sweepBatchSize = 500
+
+// FileSweeper menghapus file dan object di storage yang sudah tidak dipakai
+type FileSweeper interface {
+	// Sweep menjalankan satu kali pembersihan, dryRun hanya melaporkan tanpa menghapus
+	Sweep(ctx context.Context, dryRun bool) (*models.FileSweepReport, error)
+}
+
+type fileSweeper struct {
+	storageService StorageService
+	fileRepo       repository.FileRepository
+	referenceRepo  repository.FileReferenceRepository
+	txManager      repository.TxManager
+	gracePeriod    time.Duration // File/object yang lebih baru dari ini tidak disentuh
+}
+
+func NewFileSweeper(storageService StorageService, fileRepo repository.FileRepository, referenceRepo repository.FileReferenceRepository, txManager repository.TxManager, gracePeriod time.Duration) (FileSweeper, error) {
+	// Upload yang baru dibuat belum sempat dipasang ke employee/profile
+	if gracePeriod < presignedUploadTTL {
+		return nil, fmt.Errorf("grace period must be at least %s", presignedUploadTTL)
+	}
+
+	return &fileSweeper{
+		storageService: storageService,
+		fileRepo:       fileRepo,
+		referenceRepo:  referenceRepo,
+		txManager:      txManager,
+		gracePeriod:    gracePeriod,
+	}, nil
+}
+
+func (s *fileSweeper) Sweep(ctx context.Context, dryRun bool) (*models.FileSweepReport, error) {
+	report := &models.FileSweepReport{
+		DryRun:      dryRun,
+		GracePeriod: s.gracePeriod.String(),
+		StartedAt:   time.Now(),
+	}
+	cutoff := report.StartedAt.Add(-s.gracePeriod)
+
+	if err := s.sweepFiles(ctx, report, cutoff, dryRun); err != nil {
+		return nil, err
+	}
+	if err := s.sweepUntrackedObjects(ctx, report, cutoff, dryRun); err != nil {
+		return nil, err
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// sweepFiles menghapus record file yang tidak direferensikan beserta object dan variant-nya
+func (s *fileSweeper) sweepFiles(ctx context.Context, report *models.FileSweepReport, cutoff time.Time, dryRun bool) error {
+	var afterID uint
+	for {
+		files, err := s.fileRepo.ListUnreferenced(ctx, cutoff, afterID, sweepBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return nil
+		}
+
+		for _, file := range files {
+			afterID = file.ID
+
+			reason := models.SweepReasonUnreferenced
+			if file.Status == models.FileStatusPending {
+				reason = models.SweepReasonExpiredUpload
+			}
+
+			if !dryRun {
+				if err := s.deleteFile(ctx, file); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("file %d: %v", file.ID, err))
+					continue
+				}
+
+				// Object yang gagal dihapus sudah tidak punya record, dibersihkan lagi lewat sweepUntrackedObjects
+				for _, key := range fileObjectKeys(file) {
+					if err := s.storageService.DeleteObject(ctx, key); err != nil {
+						report.Errors = append(report.Errors, fmt.Sprintf("object %s: %v", key, err))
+					}
+				}
+			}
+
+			report.RemovedFiles++
+			for _, variant := range file.Variants {
+				s.record(report, models.SweptObject{Key: variant.StorageKey, FileID: file.ID, Size: variant.FileSize, Reason: reason})
+			}
+			s.record(report, models.SweptObject{Key: file.StorageKey, FileID: file.ID, Size: file.FileSize, Reason: reason})
+		}
+	}
+}
+
+// deleteFile mengecek ulang referensi di dalam transaksi sebelum menghapus record file,
+// object di storage dihapus caller setelah transaksi commit
+func (s *fileSweeper) deleteFile(ctx context.Context, file *models.File) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := s.fileRepo.FindByIDForUpdate(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		if locked == nil {
+			return errors.New("file not found")
+		}
+
+		referenced, err := s.referenceRepo.IsReferenced(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		if referenced {
+			return errors.New("file is still referenced")
+		}
+
+		return s.fileRepo.Delete(ctx, file.ID)
+	})
+}
+
+// sweepUntrackedObjects menghapus object di storage yang tidak punya record file maupun variant,
+// contoh: upload yang gagal disimpan ke database atau sisa data sebelum metadata file dicatat
+func (s *fileSweeper) sweepUntrackedObjects(ctx context.Context, report *models.FileSweepReport, cutoff time.Time, dryRun bool) error {
+	var candidates []ObjectInfo
+
+	flush := func() error {
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			keys = append(keys, candidate.Key)
+		}
+		tracked, err := s.fileRepo.TrackedKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+
+		for _, candidate := range candidates {
+			if tracked[candidate.Key] {
+				continue
+			}
+			if !dryRun {
+				if err := s.storageService.DeleteObject(ctx, candidate.Key); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("object %s: %v", candidate.Key, err))
+					continue
+				}
+			}
+			s.record(report, models.SweptObject{Key: candidate.Key, Size: candidate.Size, Reason: models.SweepReasonUntrackedObject})
+		}
+
+		candidates = candidates[:0]
+		return nil
+	}
+
+	err := s.storageService.ListObjects(ctx, "", func(info ObjectInfo) error {
+		report.ScannedObjects++
+		if !info.LastModified.Before(cutoff) {
+			return nil
+		}
+
+		candidates = append(candidates, info)
+		if len(candidates) >= sweepBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+func (s *fileSweeper) record(report *models.FileSweepReport, object models.SweptObject) {
+	report.Removed = append(report.Removed, object)
+	report.RemovedBytes += object.Size
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeFileReferenceRepository mencatat file mana saja yang masih dipakai entity lain
+type fakeFileReferenceRepository struct {
+	repository.FileReferenceRepository
+
+	referenced map[uint]bool
+}
+
+func (r *fakeFileReferenceRepository) IsReferenced(ctx context.Context, fileID uint) (bool, error) {
+	return r.referenced[fileID], nil
+}
+
+// fakeSweepFileRepository menambahkan query sweeper ke fakeFileRepository
+type fakeSweepFileRepository struct {
+	*fakeFileRepository
+
+	references *fakeFileReferenceRepository
+}
+
+func (r *fakeSweepFileRepository) ListUnreferenced(ctx context.Context, createdBefore time.Time, afterID uint, limit int) ([]*models.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var files []*models.File
+	for _, file := range r.files {
+		if file.CreatedAt.Before(createdBefore) && file.ID > afterID && !r.references.referenced[file.ID] {
+			copied := *file
+			files = append(files, &copied)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+func (r *fakeSweepFileRepository) TrackedKeys(ctx context.Context, keys []string) (map[string]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracked := make(map[string]bool, len(keys))
+	for _, file := range r.files {
+		for _, key := range fileObjectKeys(file) {
+			tracked[key] = true
+		}
+	}
+	return tracked, nil
+}
+
+func (r *fakeSweepFileRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.files, id)
+	return nil
+}
+
+func TestFileSweeperSweep(t *testing.T) {
+	ctx := context.Background()
+	old := time.Now().Add(-2 * time.Hour)
+
+	for _, dryRun := range []bool{true, false} {
+		name := "delete"
+		if dryRun {
+			name = "dry run"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			storage := NewMemoryStorageService("http://localhost:8080", urlsign.NewSigner("test-secret")).(*memoryStorageService)
+			files := &fakeFileRepository{files: map[uint]*models.File{
+				1: {ID: 1, StorageKey: "a/referenced.png", FileSize: 10, Status: models.FileStatusReady, CreatedAt: old},
+				2: {ID: 2, StorageKey: "a/unused.png", FileSize: 20, Status: models.FileStatusReady, CreatedAt: old,
+					Variants: []models.FileVariant{{StorageKey: "a/unused_64.webp", FileSize: 5}}},
+				3: {ID: 3, StorageKey: "a/abandoned.pdf", FileSize: 30, Status: models.FileStatusPending, CreatedAt: old},
+				4: {ID: 4, StorageKey: "a/new.png", FileSize: 40, Status: models.FileStatusReady, CreatedAt: time.Now()},
+			}}
+			references := &fakeFileReferenceRepository{referenced: map[uint]bool{1: true}}
+
+			// Object lama tanpa record dihapus, object yang baru diupload belum
+			objects := map[string]time.Time{
+				"a/referenced.png": old,
+				"a/unused.png":     old,
+				"a/unused_64.webp": old,
+				"a/abandoned.pdf":  old,
+				"a/new.png":        time.Now(),
+				"a/orphan.png":     old,
+				"a/uploading.png":  time.Now(),
+			}
+			for key, modified := range objects {
+				storage.objects[key] = memoryObject{data: []byte("data"), lastModified: modified}
+			}
+
+			sweeper, err := NewFileSweeper(storage, &fakeSweepFileRepository{fakeFileRepository: files, references: references}, references, fakeTxManager{}, time.Hour)
+			if err != nil {
+				t.Fatalf("NewFileSweeper() error = %v", err)
+			}
+			report, err := sweeper.Sweep(ctx, dryRun)
+			if err != nil {
+				t.Fatalf("Sweep() error = %v", err)
+			}
+
+			want := map[string]string{
+				"a/unused.png":     models.SweepReasonUnreferenced,
+				"a/unused_64.webp": models.SweepReasonUnreferenced,
+				"a/abandoned.pdf":  models.SweepReasonExpiredUpload,
+				"a/orphan.png":     models.SweepReasonUntrackedObject,
+			}
+			if report.RemovedFiles != 2 || len(report.Removed) != len(want) || len(report.Errors) > 0 {
+				t.Fatalf("report = %d files, %d objects, errors %v; want 2 files, %d objects", report.RemovedFiles, len(report.Removed), report.Errors, len(want))
+			}
+			for _, removed := range report.Removed {
+				if want[removed.Key] != removed.Reason {
+					t.Errorf("removed %s (%s), want reason %q", removed.Key, removed.Reason, want[removed.Key])
+				}
+			}
+
+			for key := range objects {
+				_, err := storage.StatObject(ctx, key)
+				exists := err == nil
+				if wantExists := dryRun || want[key] == ""; exists != wantExists {
+					t.Errorf("object %s exists = %v, want %v", key, exists, wantExists)
+				}
+			}
+			for _, id := range []uint{2, 3} {
+				if _, exists := files.files[id]; exists != dryRun {
+					t.Errorf("file %d exists = %v, want %v", id, exists, dryRun)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/imaging"
+	"image"
+	"log"
+	"path"
+	"strings"
+)
+
+// Ukuran variant gambar (sisi terpanjang dalam px)
+var imageVariantSizes = []int{64, 256, 1024}
+
+// storedFile adalah file (dan variant gambar) yang sudah tersimpan di storage
+type storedFile struct {
+	Key         string
+	ContentType string
+	Size        int64
+	Checksum    string
+	Variants    []models.FileVariant
+}
+
+// keys mengembalikan semua key object (original dan variant) yang ditulis
+func (i *storedFile) keys() []string {
+	keys := []string{i.Key}
+	for _, v := range i.Variants {
+		keys = append(keys, v.StorageKey)
+	}
+	return keys
+}
+
+// totalSize adalah ukuran original ditambah semua variant, dipakai untuk perhitungan kuota
+func (i *storedFile) totalSize() int64 {
+	size := i.Size
+	for _, v := range i.Variants {
+		size += v.FileSize
+	}
+	return size
+}
+
+// invalidImageError dikembalikan saat file tidak bisa didecode sebagai JPEG/PNG
+var invalidImageError = models.FileValidationErrors{{
+	Field:   "file",
+	Message: "File must be a valid jpeg or png image",
+}}
+
+// storeImage mendecode gambar, memutarnya sesuai EXIF, lalu menyimpan ulang
+// original (tanpa metadata) dan semua variant resize + WebP di samping original.
+// Key original dinormalisasi mengikuti format hasil decode.
+func (s *fileService) storeImage(ctx context.Context, key string, data []byte) (*storedFile, error) {
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return nil, invalidImageError
+	}
+
+	base := strings.TrimSuffix(key, path.Ext(key))
+	result := &storedFile{
+		Key:         base + imaging.Extension(format),
+		ContentType: imaging.ContentType(format),
+	}
+
+	// Encode ulang original, EXIF dan metadata lain otomatis terbuang
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	result.Size = int64(buf.Len())
+	if result.Checksum, err = sha256Hex(bytes.NewReader(buf.Bytes())); err != nil {
+		return nil, err
+	}
+	if err := s.storageService.PutObject(ctx, result.Key, &buf, result.Size, result.ContentType); err != nil {
+		return nil, err
+	}
+
+	for _, size := range imageVariantSizes {
+		// Jangan upscale, ukuran yang lebih besar dari original dilewati
+		if size > longestSide(img) {
+			continue
+		}
+		resized := imaging.Fit(img, size)
+
+		for _, variantFormat := range []string{format, imaging.FormatWebP} {
+			variant, err := s.storeVariant(ctx, base, resized, size, variantFormat)
+			if err != nil {
+				s.deleteObjects(ctx, result.keys())
+				return nil, err
+			}
+			result.Variants = append(result.Variants, *variant)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *fileService) storeVariant(ctx context.Context, base string, img image.Image, size int, format string) (*models.FileVariant, error) {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format); err != nil {
+		return nil, fmt.Errorf("failed to encode image variant: %w", err)
+	}
+
+	key := fmt.Sprintf("%s_%d%s", base, size, imaging.Extension(format))
+	variant := &models.FileVariant{
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+		Format:     format,
+		StorageKey: key,
+		FileType:   imaging.ContentType(format),
+		FileSize:   int64(buf.Len()),
+	}
+
+	if err := s.storageService.PutObject(ctx, key, &buf, variant.FileSize, variant.FileType); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// deleteObjects menghapus object yang sudah terlanjur ditulis, error hanya dicatat
+func (s *fileService) deleteObjects(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.storageService.DeleteObject(ctx, key); err != nil {
+			log.Printf("failed to remove untracked object %s: %v\n", key, err)
+		}
+	}
+}
+
+func longestSide(img image.Image) int {
+	return max(img.Bounds().Dx(), img.Bounds().Dy())
+}
@@ -9,16 +9,20 @@ import (
 
 type ProfileService interface {
 	GetProfile(ctx context.Context, userID uint) (*models.User, error)
-	UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest, ifMatch string) (*models.User, error)
 }
 
 type profileService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	txManager repository.TxManager
+	fileRefs  FileReferenceService
 }
 
-func NewProfileService(userRepo repository.UserRepository) ProfileService {
+func NewProfileService(userRepo repository.UserRepository, txManager repository.TxManager, fileRefs FileReferenceService) ProfileService {
 	return &profileService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
+		fileRefs:  fileRefs,
 	}
 }
 
@@ -30,42 +34,83 @@ func (s *profileService) GetProfile(ctx context.Context, userID uint) (*models.U
 	if user == nil {
 		return nil, errors.New("user not found")
 	}
+
+	s.signImageURIs(user)
 	return user, nil
 }
 
-func (s *profileService) UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest) (*models.User, error) {
-	// Get existing user
-	user, err := s.userRepo.FindByID(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, errors.New("user not found")
-	}
+func (s *profileService) UpdateProfile(ctx context.Context, userID uint, req *models.UpdateProfileRequest, ifMatch string) (*models.User, error) {
+	var user *models.User
 
-	// Check if email is changed and already exists
-	if req.Email != user.Email {
-		existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Get existing user
+		var err error
+		user, err = s.userRepo.FindByIDForUpdate(ctx, userID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if existingUser != nil {
-			return nil, errors.New("email is already used by another user")
+		if user == nil {
+			return errors.New("user not found")
 		}
-	}
 
-	// Update user fields
-	user.Email = req.Email
-	user.Name = req.Name
-	user.UserImageUri = req.UserImageUri
-	user.CompanyName = req.CompanyName
-	user.CompanyImageUri = req.CompanyImageUri
+		// Check If-Match precondition
+		if !models.MatchesIfMatch(ifMatch, user.Version) {
+			return errors.New("precondition failed")
+		}
+
+		// Check if email is changed and already exists
+		if req.Email != user.Email {
+			existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
+			if err != nil {
+				return err
+			}
+			if existingUser != nil {
+				return errors.New("email is already used by another user")
+			}
+		}
 
-	// Save updates
-	err = s.userRepo.Update(ctx, user)
+		// Gambar hanya divalidasi ulang jika berubah
+		if req.UserImageFileId != nil || req.UserImageUri != user.UserImageUri {
+			if user.UserImageUri, err = s.linkImage(ctx, user.ID, models.FileFieldUserImage, req.UserImageFileId, req.UserImageUri); err != nil {
+				return err
+			}
+		}
+		if req.CompanyImageFileId != nil || req.CompanyImageUri != user.CompanyImageUri {
+			if user.CompanyImageUri, err = s.linkImage(ctx, user.ID, models.FileFieldCompanyImage, req.CompanyImageFileId, req.CompanyImageUri); err != nil {
+				return err
+			}
+		}
+
+		// Update user fields
+		user.Email = req.Email
+		user.Name = req.Name
+		user.CompanyName = req.CompanyName
+
+		// Save updates
+		return s.userRepo.Update(ctx, user)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	s.signImageURIs(user)
 	return user, nil
 }
+
+// signImageURIs memperbarui signature link gambar profile sebelum dikirim ke client
+func (s *profileService) signImageURIs(user *models.User) {
+	user.UserImageUri = s.fileRefs.SignURI(user.UserImageUri)
+	user.CompanyImageUri = s.fileRefs.SignURI(user.CompanyImageUri)
+}
+
+// linkImage memvalidasi gambar profile lalu menyimpan referensinya, return URI yang disimpan
+func (s *profileService) linkImage(ctx context.Context, userID uint, field string, fileID *uint, uri string) (string, error) {
+	image, err := s.fileRefs.ResolveImage(ctx, userID, fileID, uri)
+	if err != nil {
+		return "", err
+	}
+	if err := s.fileRefs.SetReference(ctx, models.FileReferenceUser, userID, field, image); err != nil {
+		return "", err
+	}
+	return image.URI, nil
+}
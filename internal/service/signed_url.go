@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix route untuk menyajikan file dari backend local dan memory
+const signedFilesPath = "/v1/files/"
+
+const (
+	// Masa berlaku link /v1/file/:id/content, link di response di-sign ulang setiap dibaca
+	contentURLTTL = 24 * time.Hour
+	// Masa berlaku URL /v1/files/<key> tujuan redirect link content
+	signedDownloadTTL = 15 * time.Minute
+)
+
+// fileContentURL membuat link stabil /v1/file/:id/content yang di-sign dengan masa berlaku
+// contentURLTTL. Endpoint-nya redirect ke URL storage terkini sehingga tetap valid walaupun
+// bucket atau CDN berganti.
+func fileContentURL(baseURL string, signer *urlsign.Signer, fileID uint, variant string) string {
+	id := strconv.FormatUint(uint64(fileID), 10)
+	// Dibulatkan per jam supaya link yang sama bisa di-cache client selama satu jam
+	expires := strconv.FormatInt(time.Now().Truncate(time.Hour).Add(contentURLTTL).Unix(), 10)
+
+	query := url.Values{}
+	if variant != "" {
+		query.Set("variant", variant)
+	}
+	query.Set("expires", expires)
+	query.Set("signature", signer.Sign("GET", "file/"+id, expires, variant))
+
+	return strings.TrimRight(baseURL, "/") + "/v1/file/" + id + "/content?" + query.Encode()
+}
+
+// verifyFileContentURL memverifikasi signature link dari fileContentURL tanpa mengecek masa
+// berlakunya, dipakai untuk link yang tersimpan di database atau dikirim ulang client
+func verifyFileContentURL(signer *urlsign.Signer, uri string) (fileID uint, variant string, ok bool) {
+	fileID, variant, expires, signature, ok := parseFileContentURL(uri)
+	if !ok {
+		return 0, "", false
+	}
+	if !signer.Verify(signature, "GET", "file/"+strconv.FormatUint(uint64(fileID), 10), expires, variant) {
+		return 0, "", false
+	}
+	return fileID, variant, true
+}
+
+// resignFileContentURL mengganti link dari fileContentURL dengan link bermasa berlaku baru.
+// URI lain (misalnya URL eksternal data lama) dikembalikan apa adanya tanpa signature.
+func resignFileContentURL(baseURL string, signer *urlsign.Signer, uri string) string {
+	fileID, variant, ok := verifyFileContentURL(signer, uri)
+	if !ok {
+		return uri
+	}
+	return fileContentURL(baseURL, signer, fileID, variant)
+}
+
+// signedURLBuilder membuat URL /v1/files/<key> yang di-sign untuk backend tanpa URL publik sendiri
+type signedURLBuilder struct {
+	baseURL string
+	signer  *urlsign.Signer
+}
+
+func newSignedURLBuilder(baseURL string, signer *urlsign.Signer) *signedURLBuilder {
+	return &signedURLBuilder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		signer:  signer,
+	}
+}
+
+// URL membuat URL GET yang berlaku selama signedDownloadTTL, client mendapat URL baru
+// lewat redirect dari link /v1/file/:id/content
+func (b *signedURLBuilder) URL(key string) string {
+	expires := strconv.FormatInt(time.Now().Add(signedDownloadTTL).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", b.signer.Sign("GET", key, expires))
+	return b.baseURL + signedFilesPath + escapeKey(key) + "?" + query.Encode()
+}
+
+// PresignPut membuat URL PUT /v1/files/<key> dengan ukuran dan content type yang di-sign
+func (b *signedURLBuilder) PresignPut(key string, contentType string, size int64, expires time.Duration) *PresignedRequest {
+	expiresAt := time.Now().Add(expires)
+	expiresUnix := strconv.FormatInt(expiresAt.Unix(), 10)
+	sizeStr := strconv.FormatInt(size, 10)
+
+	query := url.Values{}
+	query.Set("expires", expiresUnix)
+	query.Set("size", sizeStr)
+	query.Set("type", contentType)
+	query.Set("signature", b.signer.Sign("PUT", key, expiresUnix, sizeStr, contentType))
+
+	return &PresignedRequest{
+		URL:    b.baseURL + signedFilesPath + escapeKey(key) + "?" + query.Encode(),
+		Method: "PUT",
+		Headers: map[string]string{
+			"Content-Type": contentType,
+		},
+		ExpiresAt: expiresAt,
+	}
+}
+
+// parseFileContentURL mengambil file ID, variant, expires dan signature dari link yang dibuat
+// fileContentURL. Host tidak dicek supaya link tetap valid walaupun base URL API berganti.
+func parseFileContentURL(uri string) (fileID uint, variant, expires, signature string, ok bool) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return 0, "", "", "", false
+	}
+
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) < 4 {
+		return 0, "", "", "", false
+	}
+	// Ambil 4 segment terakhir: v1/file/:id/content (base URL boleh punya path prefix)
+	segments = segments[len(segments)-4:]
+	if segments[0] != "v1" || segments[1] != "file" || segments[3] != "content" {
+		return 0, "", "", "", false
+	}
+
+	id, err := strconv.ParseUint(segments[2], 10, 64)
+	if err != nil || id == 0 {
+		return 0, "", "", "", false
+	}
+
+	query := parsed.Query()
+	return uint(id), query.Get("variant"), query.Get("expires"), query.Get("signature"), true
+}
+
+// verifyFileSignature memverifikasi signature dari URL yang dibuat signedURLBuilder.
+// expires kosong berarti URL tidak kedaluwarsa, extra berisi nilai tambahan yang ikut di-sign.
+func verifyFileSignature(signer *urlsign.Signer, method, key, expires, signature string, extra ...string) error {
+	if expires != "" {
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return errors.New("invalid signature")
+		}
+		if time.Now().Unix() > unix {
+			return errors.New("signature expired")
+		}
+	}
+
+	parts := append([]string{method, key, expires}, extra...)
+	if !signer.Verify(signature, parts...) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// escapeKey meng-escape setiap segment key tanpa mengubah separator "/"
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
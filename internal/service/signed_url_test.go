@@ -0,0 +1,193 @@
+package service
+
+import (
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyFileSignature(t *testing.T) {
+	signer := urlsign.NewSigner("test-secret")
+	key := "user@example.com/report.pdf"
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		method    string
+		key       string
+		expires   string
+		signature string
+		extra     []string
+		wantErr   string
+	}{
+		{
+			name:      "valid",
+			method:    "GET",
+			key:       key,
+			expires:   future,
+			signature: signer.Sign("GET", key, future),
+		},
+		{
+			name:      "valid with extra parts",
+			method:    "PUT",
+			key:       key,
+			expires:   future,
+			signature: signer.Sign("PUT", key, future, "1024", "application/pdf"),
+			extra:     []string{"1024", "application/pdf"},
+		},
+		{
+			name:      "expired",
+			method:    "GET",
+			key:       key,
+			expires:   past,
+			signature: signer.Sign("GET", key, past),
+			wantErr:   "signature expired",
+		},
+		{
+			name:      "expires not a number",
+			method:    "GET",
+			key:       key,
+			expires:   "tomorrow",
+			signature: signer.Sign("GET", key, "tomorrow"),
+			wantErr:   "invalid signature",
+		},
+		{
+			name:      "expires extended by client",
+			method:    "GET",
+			key:       key,
+			expires:   strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10),
+			signature: signer.Sign("GET", key, future),
+			wantErr:   "invalid signature",
+		},
+		{
+			name:      "different key",
+			method:    "GET",
+			key:       "other@example.com/report.pdf",
+			expires:   future,
+			signature: signer.Sign("GET", key, future),
+			wantErr:   "invalid signature",
+		},
+		{
+			name:      "GET signature used for PUT",
+			method:    "PUT",
+			key:       key,
+			expires:   future,
+			signature: signer.Sign("GET", key, future),
+			wantErr:   "invalid signature",
+		},
+		{
+			name:      "tampered size",
+			method:    "PUT",
+			key:       key,
+			expires:   future,
+			signature: signer.Sign("PUT", key, future, "1024", "application/pdf"),
+			extra:     []string{"999999", "application/pdf"},
+			wantErr:   "invalid signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyFileSignature(signer, tt.method, tt.key, tt.expires, tt.signature, tt.extra...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifyFileSignature() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("verifyFileSignature() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignedURLBuilder(t *testing.T) {
+	signer := urlsign.NewSigner("test-secret")
+	builder := newSignedURLBuilder("http://localhost:8080/", signer)
+	key := "user@example.com/my report.pdf"
+
+	parsed, err := url.Parse(builder.URL(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.EscapedPath() != "/v1/files/user@example.com/my%20report.pdf" {
+		t.Errorf("path = %s, want escaped key under %s", parsed.EscapedPath(), signedFilesPath)
+	}
+
+	query := parsed.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if ttl := time.Until(time.Unix(expires, 0)); ttl <= 0 || ttl > signedDownloadTTL {
+		t.Errorf("expires in %s, want within %s", ttl, signedDownloadTTL)
+	}
+	if err := verifyFileSignature(signer, "GET", key, query.Get("expires"), query.Get("signature")); err != nil {
+		t.Errorf("verifyFileSignature() error = %v", err)
+	}
+}
+
+func TestFileContentURL(t *testing.T) {
+	signer := urlsign.NewSigner("test-secret")
+	uri := fileContentURL("http://localhost:8080/api", signer, 42, "thumb-64")
+
+	fileID, variant, ok := verifyFileContentURL(signer, uri)
+	if !ok || fileID != 42 || variant != "thumb-64" {
+		t.Fatalf("verifyFileContentURL() = %d, %q, %v, want 42, thumb-64, true", fileID, variant, ok)
+	}
+
+	// Link tetap valid walaupun base URL API berganti
+	moved := strings.Replace(uri, "http://localhost:8080/api", "https://api.example.com", 1)
+	if _, _, ok := verifyFileContentURL(signer, moved); !ok {
+		t.Errorf("verifyFileContentURL() rejected link with a different base URL")
+	}
+
+	tampered := []string{
+		strings.Replace(uri, "/file/42/", "/file/43/", 1),
+		strings.Replace(uri, "variant=thumb-64", "variant=thumb-256", 1),
+		strings.Replace(uri, "signature=", "signature=0", 1),
+	}
+	for _, link := range tampered {
+		if _, _, ok := verifyFileContentURL(urlsign.NewSigner("test-secret"), link); ok {
+			t.Errorf("verifyFileContentURL(%s) accepted a tampered link", link)
+		}
+	}
+	if _, _, ok := verifyFileContentURL(urlsign.NewSigner("other-secret"), uri); ok {
+		t.Errorf("verifyFileContentURL() accepted a link signed with another secret")
+	}
+}
+
+func TestResignFileContentURL(t *testing.T) {
+	signer := urlsign.NewSigner("test-secret")
+
+	// Link lama yang sudah kedaluwarsa tetap bisa di-sign ulang karena signature-nya valid
+	expired := strconv.FormatInt(time.Now().Add(-48*time.Hour).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expired)
+	query.Set("signature", signer.Sign("GET", "file/7", expired, ""))
+	old := "http://localhost:8080/v1/file/7/content?" + query.Encode()
+
+	resigned, err := url.Parse(resignFileContentURL("http://localhost:8080", signer, old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, _ := strconv.ParseInt(resigned.Query().Get("expires"), 10, 64)
+	if !time.Unix(expires, 0).After(time.Now()) {
+		t.Errorf("resigned link expires at %s, want a future time", time.Unix(expires, 0))
+	}
+	if err := verifyFileSignature(signer, "GET", "file/7", resigned.Query().Get("expires"), resigned.Query().Get("signature"), ""); err != nil {
+		t.Errorf("verifyFileSignature() error = %v", err)
+	}
+
+	// URI yang bukan link content atau signature-nya tidak valid dikembalikan apa adanya
+	for _, uri := range []string{
+		"https://cdn.example.com/photo.jpg",
+		"http://localhost:8080/v1/file/7/content?expires=" + expired + "&signature=deadbeef",
+	} {
+		if got := resignFileContentURL("http://localhost:8080", signer, uri); got != uri {
+			t.Errorf("resignFileContentURL(%s) = %s, want unchanged", uri, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/configs"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// NewStorageServiceFromConfig memilih backend storage berdasarkan storage.driver (s3, local, memory)
+// Jika storage.publicBaseURL diisi (CDN atau bucket publik), URL object memakai base URL tersebut.
+func NewStorageServiceFromConfig(ctx context.Context, cfg *configs.Config, signer *urlsign.Signer) (StorageService, error) {
+	storage, err := newStorageBackend(ctx, cfg, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Storage.PublicBaseURL != "" {
+		return NewPublicURLStorageService(storage, cfg.Storage.PublicBaseURL), nil
+	}
+	return storage, nil
+}
+
+func newStorageBackend(ctx context.Context, cfg *configs.Config, signer *urlsign.Signer) (StorageService, error) {
+	switch cfg.Storage.Driver {
+	case "", "s3":
+		s3Client, err := newS3Client(ctx, cfg.AWS)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3StorageService(s3Client, cfg.AWS.Bucket, cfg.Storage.SignedURLTTL), nil
+	case "local":
+		return NewLocalStorageService(cfg.Storage.Local.Directory, cfg.Storage.BaseURL, signer)
+	case "memory":
+		return NewMemoryStorageService(cfg.Storage.BaseURL, signer), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// newS3Client membuat client S3, mendukung endpoint custom (MinIO dan S3-compatible lain)
+func newS3Client(ctx context.Context, awsConfig configs.AWSConfig) (*s3.Client, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(awsConfig.Region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			awsConfig.AccessKeyID,
+			awsConfig.SecretAccessKey,
+			"",
+		)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS SDK config: %w", err)
+	}
+
+	return s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if awsConfig.Endpoint != "" {
+			o.BaseEndpoint = aws.String(awsConfig.Endpoint)
+		}
+		o.UsePathStyle = awsConfig.UsePathStyle
+	}), nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+)
+
+// QuotaService menghitung pemakaian storage per user dan menolak upload yang melewati kuota
+type QuotaService interface {
+	Usage(ctx context.Context, userID uint) (*models.StorageUsageResponse, error)
+	Reserve(ctx context.Context, userID uint, size int64, fn func(ctx context.Context) error) error
+	SetQuota(ctx context.Context, userID uint, req *models.UpdateStorageQuotaRequest) (*models.StorageUsageResponse, error)
+	ResetQuota(ctx context.Context, userID uint) (*models.StorageUsageResponse, error)
+}
+
+type quotaService struct {
+	quotaRepo    repository.StorageQuotaRepository
+	userRepo     repository.UserRepository
+	txManager    repository.TxManager
+	defaultQuota int64 // Dalam bytes, 0 = tanpa batas
+}
+
+func NewQuotaService(quotaRepo repository.StorageQuotaRepository, userRepo repository.UserRepository, txManager repository.TxManager, defaultQuota int64) QuotaService {
+	return &quotaService{
+		quotaRepo:    quotaRepo,
+		userRepo:     userRepo,
+		txManager:    txManager,
+		defaultQuota: defaultQuota,
+	}
+}
+
+func (s *quotaService) Usage(ctx context.Context, userID uint) (*models.StorageUsageResponse, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.usage(ctx, userID)
+}
+
+func (s *quotaService) usage(ctx context.Context, userID uint) (*models.StorageUsageResponse, error) {
+	usage, err := s.quotaRepo.Usage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	limit, override, err := s.limit(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.StorageUsageResponse{
+		UsedBytes:    usage.UsedBytes,
+		PendingBytes: usage.PendingBytes,
+		FileCount:    usage.FileCount,
+		QuotaBytes:   limit,
+		Override:     override,
+	}
+	if limit != nil {
+		remaining := max(*limit-usage.UsedBytes-usage.PendingBytes, 0)
+		response.RemainingBytes = &remaining
+	}
+
+	return response, nil
+}
+
+// Reserve mengecek sisa kuota lalu menjalankan fn (biasanya insert record file) dalam satu
+// transaksi SERIALIZABLE, sehingga upload paralel tidak bisa bersama-sama melewati kuota.
+func (s *quotaService) Reserve(ctx context.Context, userID uint, size int64, fn func(ctx context.Context) error) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		limit, _, err := s.limit(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if limit != nil {
+			// File lebih besar dari total kuota tidak akan pernah muat
+			if size > *limit {
+				return errors.New("file exceeds storage quota")
+			}
+
+			usage, err := s.quotaRepo.Usage(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if usage.UsedBytes+usage.PendingBytes+size > *limit {
+				return errors.New("storage quota exceeded")
+			}
+		}
+
+		return fn(ctx)
+	})
+}
+
+// SetQuota mengatur override kuota user (admin)
+func (s *quotaService) SetQuota(ctx context.Context, userID uint, req *models.UpdateStorageQuotaRequest) (*models.StorageUsageResponse, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	quota := &models.StorageQuota{UserID: userID}
+	if !req.Unlimited {
+		quota.QuotaBytes = req.QuotaBytes
+	}
+	if err := s.quotaRepo.Set(ctx, quota); err != nil {
+		return nil, err
+	}
+
+	return s.usage(ctx, userID)
+}
+
+// ResetQuota menghapus override sehingga user kembali memakai kuota default (admin)
+func (s *quotaService) ResetQuota(ctx context.Context, userID uint) (*models.StorageUsageResponse, error) {
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.quotaRepo.Delete(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.usage(ctx, userID)
+}
+
+// limit mengembalikan kuota user (nil = tanpa batas) dan apakah kuota berasal dari override admin
+func (s *quotaService) limit(ctx context.Context, userID uint) (*int64, bool, error) {
+	quota, err := s.quotaRepo.Find(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if quota != nil {
+		return quota.QuotaBytes, true, nil
+	}
+
+	if s.defaultQuota <= 0 {
+		return nil, false, nil
+	}
+	limit := s.defaultQuota
+	return &limit, false, nil
+}
+
+func (s *quotaService) ensureUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"testing"
+)
+
+// fakeStorageQuotaRepository menyimpan override kuota dan pemakaian satu user di memori
+type fakeStorageQuotaRepository struct {
+	repository.StorageQuotaRepository
+
+	quota *models.StorageQuota
+	usage models.StorageUsage
+}
+
+func (r *fakeStorageQuotaRepository) Find(ctx context.Context, userID uint) (*models.StorageQuota, error) {
+	return r.quota, nil
+}
+
+func (r *fakeStorageQuotaRepository) Set(ctx context.Context, quota *models.StorageQuota) error {
+	r.quota = quota
+	return nil
+}
+
+func (r *fakeStorageQuotaRepository) Delete(ctx context.Context, userID uint) error {
+	r.quota = nil
+	return nil
+}
+
+func (r *fakeStorageQuotaRepository) Usage(ctx context.Context, userID uint) (*models.StorageUsage, error) {
+	usage := r.usage
+	return &usage, nil
+}
+
+// fakeUserRepository hanya mengenal user dengan ID di users
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	users map[uint]bool
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	if !r.users[id] {
+		return nil, nil
+	}
+	return &models.User{ID: id}, nil
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestQuotaServiceReserve(t *testing.T) {
+	usage := models.StorageUsage{UsedBytes: 600, PendingBytes: 300, FileCount: 2}
+
+	tests := []struct {
+		name         string
+		defaultQuota int64
+		override     *models.StorageQuota
+		size         int64
+		wantErr      string
+	}{
+		{name: "no default quota", size: 1 << 30},
+		{name: "fits default quota", defaultQuota: 1000, size: 100},
+		{name: "pending uploads count towards quota", defaultQuota: 1000, size: 101, wantErr: "storage quota exceeded"},
+		{name: "file larger than quota", defaultQuota: 1000, size: 1001, wantErr: "file exceeds storage quota"},
+		{name: "override raises quota", defaultQuota: 1000, override: &models.StorageQuota{QuotaBytes: int64Ptr(2000)}, size: 1000},
+		{name: "override lowers quota", defaultQuota: 1000, override: &models.StorageQuota{QuotaBytes: int64Ptr(900)}, size: 1, wantErr: "storage quota exceeded"},
+		{name: "unlimited override", defaultQuota: 1000, override: &models.StorageQuota{}, size: 1 << 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStorageQuotaRepository{quota: tt.override, usage: usage}
+			svc := NewQuotaService(repo, nil, fakeTxManager{}, tt.defaultQuota)
+
+			reserved := false
+			err := svc.Reserve(context.Background(), 1, tt.size, func(ctx context.Context) error {
+				reserved = true
+				return nil
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Reserve() error = %v, want %s", err, tt.wantErr)
+				}
+				if reserved {
+					t.Error("fn ran although the quota was exceeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reserve() error = %v", err)
+			}
+			if !reserved {
+				t.Error("fn did not run")
+			}
+		})
+	}
+}
+
+func TestQuotaServiceOverride(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeStorageQuotaRepository{usage: models.StorageUsage{UsedBytes: 600, PendingBytes: 300, FileCount: 2}}
+	svc := NewQuotaService(repo, &fakeUserRepository{users: map[uint]bool{1: true}}, fakeTxManager{}, 1000)
+
+	assertUsage := func(t *testing.T, usage *models.StorageUsageResponse, quota, remaining *int64, override bool) {
+		t.Helper()
+		if !equalInt64Ptr(usage.QuotaBytes, quota) || !equalInt64Ptr(usage.RemainingBytes, remaining) || usage.Override != override {
+			t.Errorf("usage = quota %v remaining %v override %v, want %v %v %v",
+				formatInt64Ptr(usage.QuotaBytes), formatInt64Ptr(usage.RemainingBytes), usage.Override,
+				formatInt64Ptr(quota), formatInt64Ptr(remaining), override)
+		}
+	}
+
+	usage, err := svc.Usage(ctx, 1)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	assertUsage(t, usage, int64Ptr(1000), int64Ptr(100), false)
+
+	// Kuota di bawah pemakaian tidak membuat sisa kuota negatif
+	usage, err = svc.SetQuota(ctx, 1, &models.UpdateStorageQuotaRequest{QuotaBytes: int64Ptr(500)})
+	if err != nil {
+		t.Fatalf("SetQuota() error = %v", err)
+	}
+	assertUsage(t, usage, int64Ptr(500), int64Ptr(0), true)
+
+	usage, err = svc.SetQuota(ctx, 1, &models.UpdateStorageQuotaRequest{QuotaBytes: int64Ptr(500), Unlimited: true})
+	if err != nil {
+		t.Fatalf("SetQuota() error = %v", err)
+	}
+	assertUsage(t, usage, nil, nil, true)
+
+	usage, err = svc.ResetQuota(ctx, 1)
+	if err != nil {
+		t.Fatalf("ResetQuota() error = %v", err)
+	}
+	assertUsage(t, usage, int64Ptr(1000), int64Ptr(100), false)
+
+	if _, err := svc.SetQuota(ctx, 2, &models.UpdateStorageQuotaRequest{QuotaBytes: int64Ptr(500)}); err == nil || err.Error() != "user not found" {
+		t.Errorf("SetQuota() for unknown user error = %v, want user not found", err)
+	}
+}
+
+func equalInt64Ptr(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func formatInt64Ptr(v *int64) any {
+	if v == nil {
+		return "unlimited"
+	}
+	return *v
+}
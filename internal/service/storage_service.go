@@ -2,54 +2,282 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/google/uuid"
-	"mime/multipart"
-	"path/filepath"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"io"
+	"strings"
+	"time"
 )
 
+// StorageService adalah backend penyimpanan object (S3, local disk, in-memory)
 type StorageService interface {
-	UploadFile(ctx context.Context, file *multipart.FileHeader, userEmail string) (string, error)
+	// PutObject menyimpan body dengan key tertentu
+	PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// GetObject membuka object untuk dibaca, caller wajib menutup reader
+	GetObject(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// StatObject mengambil metadata object tanpa membaca isinya
+	StatObject(ctx context.Context, key string) (*ObjectInfo, error)
+	// DeleteObject menghapus object, tidak error jika object sudah tidak ada
+	DeleteObject(ctx context.Context, key string) error
+	// ListObjects memanggil fn untuk setiap object dengan prefix tertentu, berhenti jika fn return error
+	ListObjects(ctx context.Context, prefix string, fn func(info ObjectInfo) error) error
+	// PresignPut membuat request upload langsung ke storage, ukuran dan content type ikut di-sign
+	PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*PresignedRequest, error)
+	// CreateMultipartUpload memulai upload bertahap, part digabung menjadi object key saat complete
+	CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error)
+	// UploadPart menyimpan satu part, semua part kecuali yang terakhir minimal minMultipartPartSize
+	UploadPart(ctx context.Context, key string, uploadID string, partNumber int32, body io.Reader, size int64) (string, error)
+	// CompleteMultipartUpload menggabungkan part sesuai urutan menjadi object key
+	CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error
+	// AbortMultipartUpload membatalkan upload bertahap dan menghapus part yang sudah tersimpan
+	AbortMultipartUpload(ctx context.Context, key string, uploadID string) error
+	// URL mengembalikan URL yang saat ini bisa diakses client (publik atau signed sementara),
+	// jangan disimpan di database karena bisa kedaluwarsa
+	URL(ctx context.Context, key string) (string, error)
 }
 
+// ObjectInfo berisi metadata object di storage
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// PresignedRequest adalah request HTTP yang sudah di-sign untuk upload langsung ke storage
+type PresignedRequest struct {
+	URL       string
+	Method    string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// CompletedPart adalah part multipart upload yang sudah tersimpan
+type CompletedPart struct {
+	Number int32
+	ETag   string
+}
+
+// Ukuran minimal part multipart selain part terakhir (batasan S3)
+const minMultipartPartSize = 5 << 20
+
+var errObjectNotFound = errors.New("object not found")
+
 type s3StorageService struct {
-	s3Client   *s3.Client
-	bucketName string
+	s3Client      *s3.Client
+	presignClient *s3.PresignClient
+	bucketName    string
+	signedURLTTL  time.Duration // Masa berlaku signed GET URL untuk bucket private
 }
 
-func NewS3StorageService(s3Client *s3.Client, bucketName string) StorageService {
+func NewS3StorageService(s3Client *s3.Client, bucketName string, signedURLTTL time.Duration) StorageService {
 	return &s3StorageService{
-		s3Client:   s3Client,
-		bucketName: bucketName,
+		s3Client:      s3Client,
+		presignClient: s3.NewPresignClient(s3Client),
+		bucketName:    bucketName,
+		signedURLTTL:  signedURLTTL,
 	}
 }
 
-func (s *s3StorageService) UploadFile(ctx context.Context, file *multipart.FileHeader, userEmail string) (string, error) {
-	// Open file
-	src, err := file.Open()
+func (s *s3StorageService) PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	// Upload to S3
+	_, err := s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to upload file: %w", err)
 	}
-	defer src.Close()
 
-	// Generate unique filename
-	fileExt := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%s/%s%s", userEmail, uuid.New().String(), fileExt)
+	return nil
+}
 
-	// Upload to S3
-	_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+func (s *s3StorageService) GetObject(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	output, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil, errObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	return output.Body, &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		LastModified: aws.ToTime(output.LastModified),
+	}, nil
+}
+
+func (s *s3StorageService) StatObject(ctx context.Context, key string) (*ObjectInfo, error) {
+	output, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, errObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		LastModified: aws.ToTime(output.LastModified),
+	}, nil
+}
+
+func (s *s3StorageService) ListObjects(ctx context.Context, prefix string, fn func(info ObjectInfo) error) error {
+	paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			err := fn(ObjectInfo{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *s3StorageService) CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	output, err := s.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
 		Bucket:      aws.String(s.bucketName),
-		Key:         aws.String(filename),
-		Body:        src,
-		ContentType: aws.String(file.Header.Get("Content-Type")),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
 	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	return aws.ToString(output.UploadId), nil
+}
+
+func (s *s3StorageService) UploadPart(ctx context.Context, key string, uploadID string, partNumber int32, body io.Reader, size int64) (string, error) {
+	output, err := s.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(partNumber),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part: %w", err)
+	}
+	return aws.ToString(output.ETag), nil
+}
 
+func (s *s3StorageService) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, types.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int32(part.Number),
+		})
+	}
+
+	_, err := s.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
 	}
+	return nil
+}
 
-	// Return S3 URI
-	return fmt.Sprintf("%s/%s", s.bucketName, filename), nil
+func (s *s3StorageService) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	_, err := s.s3Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		var noSuchUpload *types.NoSuchUpload
+		if errors.As(err, &noSuchUpload) {
+			return nil
+		}
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *s3StorageService) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*PresignedRequest, error) {
+	// Content-Type dan Content-Length masuk ke signed headers, S3 menolak upload yang berbeda
+	request, err := s.presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	headers := map[string]string{}
+	for name, values := range request.SignedHeader {
+		// Host diisi otomatis oleh client HTTP
+		if len(values) > 0 && !strings.EqualFold(name, "Host") {
+			headers[name] = values[0]
+		}
+	}
+
+	return &PresignedRequest{
+		URL:       request.URL,
+		Method:    request.Method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(expires),
+	}, nil
+}
+
+func (s *s3StorageService) DeleteObject(ctx context.Context, key string) error {
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// URL membuat signed GET URL berumur pendek, bucket boleh private
+func (s *s3StorageService) URL(ctx context.Context, key string) (string, error) {
+	request, err := s.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(s.signedURLTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+	return request.URL, nil
 }
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"github.com/google/uuid"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localStorageService menyimpan object di disk lokal, file disajikan lewat route /v1/files/* yang di-sign
+type localStorageService struct {
+	directory string
+	urls      *signedURLBuilder
+}
+
+func NewLocalStorageService(directory string, baseURL string, signer *urlsign.Signer) (StorageService, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &localStorageService{
+		directory: directory,
+		urls:      newSignedURLBuilder(baseURL, signer),
+	}, nil
+}
+
+func (s *localStorageService) PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Tulis ke file sementara lalu rename, supaya tidak ada file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStorageService) GetObject(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	path, err := s.objectPath(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, errObjectNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return file, &ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  mime.TypeByExtension(filepath.Ext(key)),
+		LastModified: stat.ModTime(),
+	}, nil
+}
+
+func (s *localStorageService) StatObject(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  mime.TypeByExtension(filepath.Ext(key)),
+		LastModified: stat.ModTime(),
+	}, nil
+}
+
+func (s *localStorageService) ListObjects(ctx context.Context, prefix string, fn func(info ObjectInfo) error) error {
+	return filepath.WalkDir(s.directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Lewati part multipart dan file sementara yang sedang ditulis PutObject
+		if entry.IsDir() {
+			if path != s.directory && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.directory, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+
+		return fn(ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(filepath.Ext(key)),
+			LastModified: info.ModTime(),
+		})
+	})
+}
+
+// Part multipart disimpan sebagai file sementara di <directory>/.multipart/<uploadID>/<partNumber>
+func (s *localStorageService) CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	if _, err := s.objectPath(key); err != nil {
+		return "", err
+	}
+
+	uploadID := uuid.New().String()
+	if err := os.MkdirAll(s.multipartPath(uploadID), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+func (s *localStorageService) UploadPart(ctx context.Context, key string, uploadID string, partNumber int32, body io.Reader, size int64) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", fmt.Errorf("invalid upload id %q", uploadID)
+	}
+
+	part, err := os.Create(filepath.Join(s.multipartPath(uploadID), strconv.Itoa(int(partNumber))))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part: %w", err)
+	}
+	defer part.Close()
+
+	if _, err := io.Copy(part, body); err != nil {
+		return "", fmt.Errorf("failed to upload part: %w", err)
+	}
+	return strconv.Itoa(int(partNumber)), part.Close()
+}
+
+func (s *localStorageService) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fmt.Errorf("invalid upload id %q", uploadID)
+	}
+
+	// Gabungkan semua part jadi satu stream lalu tulis lewat PutObject (temp file + rename)
+	readers := make([]io.Reader, 0, len(parts))
+	var size int64
+	for _, part := range parts {
+		file, err := os.Open(filepath.Join(s.multipartPath(uploadID), strconv.Itoa(int(part.Number))))
+		if err != nil {
+			return fmt.Errorf("failed to complete multipart upload: %w", err)
+		}
+		defer file.Close()
+
+		stat, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to complete multipart upload: %w", err)
+		}
+		size += stat.Size()
+		readers = append(readers, file)
+	}
+
+	if err := s.PutObject(ctx, key, io.MultiReader(readers...), size, ""); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.multipartPath(uploadID))
+}
+
+func (s *localStorageService) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fmt.Errorf("invalid upload id %q", uploadID)
+	}
+	return os.RemoveAll(s.multipartPath(uploadID))
+}
+
+func (s *localStorageService) multipartPath(uploadID string) string {
+	return filepath.Join(s.directory, ".multipart", uploadID)
+}
+
+func (s *localStorageService) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*PresignedRequest, error) {
+	if _, err := s.objectPath(key); err != nil {
+		return nil, err
+	}
+	return s.urls.PresignPut(key, contentType, size, expires), nil
+}
+
+func (s *localStorageService) DeleteObject(ctx context.Context, key string) error {
+	path, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *localStorageService) URL(ctx context.Context, key string) (string, error) {
+	return s.urls.URL(key), nil
+}
+
+// objectPath memetakan key ke path di dalam directory dan menolak path traversal
+func (s *localStorageService) objectPath(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || strings.Contains(key, "..") || cleaned == "/" {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.directory, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"github.com/google/uuid"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStorageService menyimpan object di memory, dipakai untuk development dan test
+type memoryStorageService struct {
+	mu        sync.RWMutex
+	objects   map[string]memoryObject
+	multipart map[string]map[int32][]byte // uploadID -> part
+	urls      *signedURLBuilder
+}
+
+type memoryObject struct {
+	data         []byte
+	contentType  string
+	lastModified time.Time
+}
+
+func NewMemoryStorageService(baseURL string, signer *urlsign.Signer) StorageService {
+	return &memoryStorageService{
+		objects:   map[string]memoryObject{},
+		multipart: map[string]map[int32][]byte{},
+		urls:      newSignedURLBuilder(baseURL, signer),
+	}
+}
+
+func (s *memoryStorageService) PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[key] = memoryObject{
+		data:         data,
+		contentType:  contentType,
+		lastModified: time.Now(),
+	}
+	return nil
+}
+
+func (s *memoryStorageService) GetObject(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, nil, errObjectNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(object.data)), &ObjectInfo{
+		Key:          key,
+		Size:         int64(len(object.data)),
+		ContentType:  object.contentType,
+		LastModified: object.lastModified,
+	}, nil
+}
+
+func (s *memoryStorageService) StatObject(ctx context.Context, key string) (*ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, errObjectNotFound
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         int64(len(object.data)),
+		ContentType:  object.contentType,
+		LastModified: object.lastModified,
+	}, nil
+}
+
+func (s *memoryStorageService) ListObjects(ctx context.Context, prefix string, fn func(info ObjectInfo) error) error {
+	// Salin dulu supaya fn boleh memanggil DeleteObject tanpa deadlock
+	s.mu.RLock()
+	infos := make([]ObjectInfo, 0, len(s.objects))
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, ObjectInfo{
+				Key:          key,
+				Size:         int64(len(object.data)),
+				ContentType:  object.contentType,
+				LastModified: object.lastModified,
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorageService) CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uploadID := uuid.New().String()
+	s.multipart[uploadID] = map[int32][]byte{}
+	return uploadID, nil
+}
+
+func (s *memoryStorageService) UploadPart(ctx context.Context, key string, uploadID string, partNumber int32, body io.Reader, size int64) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts, ok := s.multipart[uploadID]
+	if !ok {
+		return "", fmt.Errorf("multipart upload %s not found", uploadID)
+	}
+	parts[partNumber] = data
+	return strconv.Itoa(int(partNumber)), nil
+}
+
+func (s *memoryStorageService) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []CompletedPart) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.multipart[uploadID]
+	if !ok {
+		return fmt.Errorf("multipart upload %s not found", uploadID)
+	}
+
+	var data []byte
+	for _, part := range parts {
+		data = append(data, stored[part.Number]...)
+	}
+
+	s.objects[key] = memoryObject{
+		data:         data,
+		lastModified: time.Now(),
+	}
+	delete(s.multipart, uploadID)
+	return nil
+}
+
+func (s *memoryStorageService) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.multipart, uploadID)
+	return nil
+}
+
+func (s *memoryStorageService) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*PresignedRequest, error) {
+	return s.urls.PresignPut(key, contentType, size, expires), nil
+}
+
+func (s *memoryStorageService) DeleteObject(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryStorageService) URL(ctx context.Context, key string) (string, error) {
+	return s.urls.URL(key), nil
+}
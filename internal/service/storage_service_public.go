@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"strings"
+)
+
+// publicURLStorageService membungkus StorageService lain dan mengganti URL object
+// dengan URL publik dari CDN atau bucket publik, operasi lain diteruskan apa adanya
+type publicURLStorageService struct {
+	StorageService
+	baseURL string
+}
+
+func NewPublicURLStorageService(storage StorageService, baseURL string) StorageService {
+	return &publicURLStorageService{
+		StorageService: storage,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (s *publicURLStorageService) URL(ctx context.Context, key string) (string, error) {
+	return s.baseURL + "/" + escapeKey(key), nil
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Masa berlaku upload resumable sejak dibuat
+const tusUploadTTL = 24 * time.Hour
+
+// TusService mengimplementasikan protokol upload resumable tus 1.0 (creation, termination,
+// expiration) di atas StorageService. Data disimpan sebagai multipart upload, sisa data yang
+// belum cukup untuk satu part disimpan sementara di staging object.
+type TusService interface {
+	CreateUpload(ctx context.Context, userID uint, userEmail string, req *models.TusCreateRequest) (*models.TusUpload, error)
+	GetUpload(ctx context.Context, userID uint, id string) (*models.TusUpload, error)
+	WriteChunk(ctx context.Context, userID uint, id string, offset int64, body io.Reader) (*models.TusUpload, error)
+	TerminateUpload(ctx context.Context, userID uint, id string) error
+	CleanupExpired(ctx context.Context) (int, error)
+	MaxSize() int64
+}
+
+type tusService struct {
+	storageService StorageService
+	fileService    FileService
+	fileRepo       repository.FileRepository
+	tusRepo        repository.TusUploadRepository
+	txManager      repository.TxManager
+	quotaService   QuotaService
+	policies       map[string]models.UploadPolicy
+}
+
+func NewTusService(storageService StorageService, fileService FileService, fileRepo repository.FileRepository, tusRepo repository.TusUploadRepository, txManager repository.TxManager, quotaService QuotaService, policies map[string]models.UploadPolicy) TusService {
+	return &tusService{
+		storageService: storageService,
+		fileService:    fileService,
+		fileRepo:       fileRepo,
+		tusRepo:        tusRepo,
+		txManager:      txManager,
+		quotaService:   quotaService,
+		policies:       policies,
+	}
+}
+
+func (s *tusService) CreateUpload(ctx context.Context, userID uint, userEmail string, req *models.TusCreateRequest) (*models.TusUpload, error) {
+	// Upload resumable umumnya untuk dokumen besar
+	purpose := req.Purpose
+	if purpose == "" {
+		purpose = models.UploadPurposeDocument
+	}
+	policy, ok := s.policies[purpose]
+	if !ok {
+		return nil, models.FileValidationErrors{{
+			Field:   "purpose",
+			Message: fmt.Sprintf("Purpose must be one of: %s", strings.Join(models.UploadPurposes, ", ")),
+		}}
+	}
+
+	if req.Length > policy.MaxSize {
+		return nil, errors.New("upload too large")
+	}
+	// Tipe file divalidasi ulang dari isinya saat upload selesai
+	if !policy.Allows(req.FileType) {
+		return nil, models.FileValidationErrors{{
+			Field:   "filetype",
+			Message: fmt.Sprintf("File type must be one of: %s", strings.Join(policy.AllowedTypes, ", ")),
+		}}
+	}
+
+	fileID, err := s.fileRepo.NextID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s/%s%s", userEmail, uuid.New().String(), models.UploadExtension(req.FileType))
+	multipartID, err := s.storageService.CreateMultipartUpload(ctx, key, req.FileType)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.File{
+		ID:         fileID,
+		UserID:     userID,
+		Filename:   filepath.Base(req.Filename),
+		StorageKey: key,
+		FileType:   req.FileType,
+		FileSize:   req.Length,
+		Status:     models.FileStatusPending,
+		Purpose:    purpose,
+	}
+	upload := &models.TusUpload{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		FileID:      fileID,
+		Length:      req.Length,
+		MultipartID: multipartID,
+		Parts:       []models.UploadPart{},
+		StagingKey:  key + ".tus-staging",
+		ExpiresAt:   time.Now().Add(tusUploadTTL),
+	}
+
+	// Upload-Length langsung dipesan dari kuota sampai upload selesai atau kadaluarsa
+	err = s.quotaService.Reserve(ctx, userID, req.Length, func(ctx context.Context) error {
+		if err := s.fileRepo.Create(ctx, record); err != nil {
+			return err
+		}
+		return s.tusRepo.Create(ctx, upload)
+	})
+	if err != nil {
+		s.abortMultipart(ctx, key, multipartID)
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (s *tusService) GetUpload(ctx context.Context, userID uint, id string) (*models.TusUpload, error) {
+	upload, err := s.tusRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ownedUpload(upload, userID)
+}
+
+// ownedUpload memastikan upload ada, milik userID dan belum kadaluarsa
+func ownedUpload(upload *models.TusUpload, userID uint) (*models.TusUpload, error) {
+	if upload == nil || upload.UserID != userID {
+		return nil, errors.New("upload not found")
+	}
+	if upload.Expired() {
+		return nil, errors.New("upload expired")
+	}
+	return upload, nil
+}
+
+// WriteChunk menambahkan body ke upload mulai dari offset. Data dipotong menjadi part
+// minMultipartPartSize, sisanya disimpan di staging object sampai chunk berikutnya datang.
+// Saat semua byte diterima multipart upload digabung dan file divalidasi seperti presigned upload.
+func (s *tusService) WriteChunk(ctx context.Context, userID uint, id string, offset int64, body io.Reader) (*models.TusUpload, error) {
+	var (
+		upload   *models.TusUpload
+		file     *models.File
+		chunkErr error // Body terputus atau part gagal disimpan, part sebelumnya tetap di-commit
+	)
+
+	// Row tus_uploads dikunci selama part diupload supaya PATCH lain dengan offset yang sama menunggu
+	// lalu ditolak, bukan ikut mengupload nomor part yang sama. READ COMMITTED karena body yang sudah
+	// dibaca tidak bisa diulang jika transaksi di-retry.
+	err := s.txManager.WithinReadCommittedTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		upload, err = s.tusRepo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if upload, err = ownedUpload(upload, userID); err != nil {
+			return err
+		}
+		if offset != upload.Offset {
+			return errors.New("upload offset mismatch")
+		}
+		if upload.Completed() {
+			return errors.New("upload already completed")
+		}
+
+		file, err = s.fileRepo.FindByID(ctx, upload.FileID)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			return errors.New("upload not found")
+		}
+
+		chunkErr, err = s.writeParts(ctx, upload, file, body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chunkErr != nil {
+		return nil, chunkErr
+	}
+
+	// Validasi dan scan dijalankan setelah lock dilepas
+	if upload.Completed() {
+		if err := s.finish(ctx, upload, file); err != nil {
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// writeParts mengupload body sebagai part dan menyimpan offset setiap satu part tersimpan, sehingga
+// PATCH yang terputus di tengah bisa dilanjutkan dari part terakhir. chunkErr adalah error body atau
+// storage yang tidak membatalkan progress, err adalah error database yang membatalkan transaksi.
+func (s *tusService) writeParts(ctx context.Context, upload *models.TusUpload, file *models.File, body io.Reader) (chunkErr, err error) {
+	// Gabungkan sisa data chunk sebelumnya dengan body baru, byte melebihi Upload-Length diabaikan
+	staged := io.Reader(bytes.NewReader(nil))
+	if upload.StagedSize > 0 {
+		stagingBody, _, err := s.storageService.GetObject(ctx, upload.StagingKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staged data: %w", err)
+		}
+		defer stagingBody.Close()
+		staged = stagingBody
+	}
+	start := upload.Offset
+	counted := &countingReader{reader: io.LimitReader(body, upload.Length-upload.Offset)}
+	reader := io.MultiReader(staged, counted)
+
+	// Offset selalu mengikuti byte body yang sudah masuk ke part atau staging object
+	saveProgress := func() error {
+		previousOffset := upload.Offset
+		upload.Offset = start + counted.n
+		return s.tusRepo.UpdateProgress(ctx, upload, previousOffset)
+	}
+
+	buf := make([]byte, minMultipartPartSize)
+	var remainder []byte
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read chunk: %w", err), nil
+		}
+		if n < minMultipartPartSize {
+			remainder = buf[:n]
+			break
+		}
+
+		part, err := s.uploadPart(ctx, file.StorageKey, upload, buf[:n])
+		if err != nil {
+			return err, nil
+		}
+		upload.Parts = append(upload.Parts, *part)
+		upload.StagedSize = 0
+		if err := saveProgress(); err != nil {
+			return nil, err
+		}
+	}
+
+	if start+counted.n == upload.Length {
+		// Part terakhir boleh lebih kecil dari minMultipartPartSize
+		if len(remainder) > 0 || len(upload.Parts) == 0 {
+			part, err := s.uploadPart(ctx, file.StorageKey, upload, remainder)
+			if err != nil {
+				return err, nil
+			}
+			upload.Parts = append(upload.Parts, *part)
+		}
+		upload.StagedSize = 0
+		return nil, saveProgress()
+	}
+
+	if len(remainder) > 0 {
+		if err := s.storageService.PutObject(ctx, upload.StagingKey, bytes.NewReader(remainder), int64(len(remainder)), "application/octet-stream"); err != nil {
+			return err, nil
+		}
+	}
+	upload.StagedSize = int64(len(remainder))
+	return nil, saveProgress()
+}
+
+// finish menggabungkan part lalu memvalidasi file lewat FileService
+func (s *tusService) finish(ctx context.Context, upload *models.TusUpload, file *models.File) error {
+	parts := make([]CompletedPart, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		parts = append(parts, CompletedPart{Number: part.Number, ETag: part.ETag})
+	}
+	if err := s.storageService.CompleteMultipartUpload(ctx, file.StorageKey, upload.MultipartID, parts); err != nil {
+		return err
+	}
+	s.deleteStaging(ctx, upload)
+
+	_, err := s.fileService.FinishUpload(ctx, upload.UserID, upload.FileID)
+	if _, invalid := err.(models.FileValidationErrors); invalid {
+		s.discardRejected(ctx, upload, file)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.tusRepo.Delete(ctx, upload.ID)
+}
+
+// discardRejected membersihkan upload yang ditolak saat finish supaya tidak tertinggal sebagai pending.
+// File yang dikarantina scanner tetap disimpan, hanya state tus yang dihapus.
+func (s *tusService) discardRejected(ctx context.Context, upload *models.TusUpload, file *models.File) {
+	current, err := s.fileRepo.FindByID(ctx, upload.FileID)
+	if err != nil {
+		log.Printf("failed to load rejected upload %s: %v\n", upload.ID, err)
+		return
+	}
+
+	if current != nil && current.Status == models.FileStatusQuarantined {
+		if err := s.tusRepo.Delete(ctx, upload.ID); err != nil {
+			log.Printf("failed to remove rejected upload %s: %v\n", upload.ID, err)
+		}
+		return
+	}
+
+	// Row tus_uploads ikut terhapus lewat ON DELETE CASCADE
+	if err := s.fileRepo.Delete(ctx, upload.FileID); err != nil {
+		log.Printf("failed to remove rejected upload %s: %v\n", upload.ID, err)
+	}
+	if err := s.storageService.DeleteObject(ctx, file.StorageKey); err != nil {
+		log.Printf("failed to remove rejected object %s: %v\n", file.StorageKey, err)
+	}
+}
+
+func (s *tusService) TerminateUpload(ctx context.Context, userID uint, id string) error {
+	upload, err := s.tusRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if upload == nil || upload.UserID != userID {
+		return errors.New("upload not found")
+	}
+
+	return s.remove(ctx, upload)
+}
+
+// CleanupExpired menghapus upload yang melewati Upload-Expires beserta part dan record file pending-nya
+func (s *tusService) CleanupExpired(ctx context.Context) (int, error) {
+	removed := 0
+	for {
+		uploads, err := s.tusRepo.ListExpired(ctx, time.Now(), sweepBatchSize)
+		if err != nil {
+			return removed, err
+		}
+		if len(uploads) == 0 {
+			return removed, nil
+		}
+
+		for _, upload := range uploads {
+			if err := s.remove(ctx, upload); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+}
+
+// MaxSize adalah ukuran upload terbesar yang diizinkan (header Tus-Max-Size)
+func (s *tusService) MaxSize() int64 {
+	var maxSize int64
+	for _, policy := range s.policies {
+		maxSize = max(maxSize, policy.MaxSize)
+	}
+	return maxSize
+}
+
+// remove membatalkan multipart upload dan menghapus record file pending,
+// row tus_uploads ikut terhapus lewat ON DELETE CASCADE
+func (s *tusService) remove(ctx context.Context, upload *models.TusUpload) error {
+	file, err := s.fileRepo.FindByID(ctx, upload.FileID)
+	if err != nil {
+		return err
+	}
+
+	if file != nil {
+		if err := s.storageService.AbortMultipartUpload(ctx, file.StorageKey, upload.MultipartID); err != nil {
+			return err
+		}
+		s.deleteStaging(ctx, upload)
+
+		if file.Status == models.FileStatusPending {
+			return s.fileRepo.Delete(ctx, file.ID)
+		}
+	}
+
+	return s.tusRepo.Delete(ctx, upload.ID)
+}
+
+func (s *tusService) uploadPart(ctx context.Context, key string, upload *models.TusUpload, data []byte) (*models.UploadPart, error) {
+	number := int32(len(upload.Parts) + 1)
+	etag, err := s.storageService.UploadPart(ctx, key, upload.MultipartID, number, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UploadPart{
+		Number: number,
+		ETag:   etag,
+		Size:   int64(len(data)),
+	}, nil
+}
+
+func (s *tusService) deleteStaging(ctx context.Context, upload *models.TusUpload) {
+	if err := s.storageService.DeleteObject(ctx, upload.StagingKey); err != nil {
+		log.Printf("failed to remove staging object %s: %v\n", upload.StagingKey, err)
+	}
+}
+
+func (s *tusService) abortMultipart(ctx context.Context, key, multipartID string) {
+	if err := s.storageService.AbortMultipartUpload(ctx, key, multipartID); err != nil {
+		log.Printf("failed to abort multipart upload %s: %v\n", key, err)
+	}
+}
+
+// countingReader menghitung jumlah byte yang dibaca dari body request
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
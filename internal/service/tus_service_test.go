@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/models"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/internal/repository"
+	"github.com/Project-Sprint-LDH-Team/GoGoManager/pkg/urlsign"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTusRepository menyimpan upload di memori, FindByIDForUpdate mengunci row sampai transaksi selesai
+type fakeTusRepository struct {
+	repository.TusUploadRepository
+
+	mu      sync.Mutex
+	uploads map[string]*models.TusUpload
+	rows    map[string]*sync.Mutex
+}
+
+func (r *fakeTusRepository) FindByID(ctx context.Context, id string) (*models.TusUpload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *upload
+	copied.Parts = append([]models.UploadPart{}, upload.Parts...)
+	return &copied, nil
+}
+
+func (r *fakeTusRepository) FindByIDForUpdate(ctx context.Context, id string) (*models.TusUpload, error) {
+	tx, ok := ctx.Value(fakeTxKey{}).(*fakeTx)
+	if !ok {
+		return nil, errors.New("FindByIDForUpdate called outside a transaction")
+	}
+
+	r.mu.Lock()
+	row, ok := r.rows[id]
+	if !ok {
+		row = &sync.Mutex{}
+		r.rows[id] = row
+	}
+	r.mu.Unlock()
+
+	row.Lock()
+	tx.locked = append(tx.locked, row)
+	return r.FindByID(ctx, id)
+}
+
+func (r *fakeTusRepository) UpdateProgress(ctx context.Context, upload *models.TusUpload, previousOffset int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.uploads[upload.ID].Offset != previousOffset {
+		return errors.New("upload offset mismatch")
+	}
+	copied := *upload
+	copied.Parts = append([]models.UploadPart{}, upload.Parts...)
+	r.uploads[upload.ID] = &copied
+	return nil
+}
+
+func (r *fakeTusRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.uploads, id)
+	return nil
+}
+
+// fakeUploadFinisher mencatat file yang diselesaikan tanpa memvalidasi isinya
+type fakeUploadFinisher struct {
+	FileService
+
+	finished []uint
+}
+
+func (f *fakeUploadFinisher) FinishUpload(ctx context.Context, userID uint, fileID uint) (*models.FileUploadResponse, error) {
+	f.finished = append(f.finished, fileID)
+	return &models.FileUploadResponse{}, nil
+}
+
+// brokenReader mengembalikan data lalu error, seperti koneksi client yang terputus di tengah PATCH
+type brokenReader struct {
+	data *bytes.Reader
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	if r.data.Len() == 0 {
+		return 0, errors.New("connection reset")
+	}
+	return r.data.Read(p)
+}
+
+const testTusKey = "user@example.com/upload.pdf"
+
+// newTestTusService membuat tusService di atas storage memory dengan satu upload "upload-1"
+// sepanjang length milik user 1
+func newTestTusService(t *testing.T, length int64) (*tusService, *fakeTusRepository, *fakeUploadFinisher) {
+	t.Helper()
+	ctx := context.Background()
+
+	storage := NewMemoryStorageService("http://localhost:8080", urlsign.NewSigner("test-secret"))
+	multipartID, err := storage.CreateMultipartUpload(ctx, testTusKey, "application/pdf")
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload() error = %v", err)
+	}
+
+	fileRepo := &fakeFileRepository{files: map[uint]*models.File{
+		1: {ID: 1, UserID: 1, StorageKey: testTusKey, FileSize: length, Status: models.FileStatusPending},
+	}}
+	tusRepo := &fakeTusRepository{
+		uploads: map[string]*models.TusUpload{
+			"upload-1": {
+				ID:          "upload-1",
+				UserID:      1,
+				FileID:      1,
+				Length:      length,
+				MultipartID: multipartID,
+				Parts:       []models.UploadPart{},
+				StagingKey:  testTusKey + ".tus-staging",
+				ExpiresAt:   time.Now().Add(time.Hour),
+			},
+		},
+		rows: make(map[string]*sync.Mutex),
+	}
+	finisher := &fakeUploadFinisher{}
+
+	s := &tusService{
+		storageService: storage,
+		fileService:    finisher,
+		fileRepo:       fileRepo,
+		tusRepo:        tusRepo,
+		txManager:      fakeTxManager{},
+	}
+	return s, tusRepo, finisher
+}
+
+// testChunk membuat size byte berisi fill
+func testChunk(size int, fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, size)
+}
+
+// assertUploadedObject memastikan upload selesai dan object hasil gabungan part sama dengan want
+func assertUploadedObject(t *testing.T, s *tusService, finisher *fakeUploadFinisher, want []byte) {
+	t.Helper()
+
+	if len(finisher.finished) != 1 {
+		t.Fatalf("FinishUpload called %d times, want 1", len(finisher.finished))
+	}
+	body, _, err := s.storageService.GetObject(context.Background(), testTusKey)
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if !bytes.Equal(data, want) {
+		t.Errorf("uploaded object = %d bytes, want %d bytes in PATCH order", len(data), len(want))
+	}
+}
+
+func TestWriteChunkKeepsPartsOfBrokenPatch(t *testing.T) {
+	ctx := context.Background()
+	s, repo, finisher := newTestTusService(t, 3*minMultipartPartSize)
+
+	data := append(testChunk(2*minMultipartPartSize, 'a'), testChunk(minMultipartPartSize, 'b')...)
+
+	// Body terputus setelah satu setengah part, part pertama sudah tersimpan di storage
+	body := &brokenReader{data: bytes.NewReader(data[:minMultipartPartSize+100])}
+	if _, err := s.WriteChunk(ctx, 1, "upload-1", 0, body); err == nil {
+		t.Fatal("WriteChunk() error = nil, want read error")
+	}
+
+	upload := repo.uploads["upload-1"]
+	if upload.Offset != minMultipartPartSize || len(upload.Parts) != 1 {
+		t.Fatalf("after broken PATCH offset = %d with %d parts, want %d with 1 part", upload.Offset, len(upload.Parts), minMultipartPartSize)
+	}
+
+	// Client melanjutkan dari offset yang dikembalikan HEAD
+	upload, err := s.WriteChunk(ctx, 1, "upload-1", upload.Offset, bytes.NewReader(data[minMultipartPartSize:]))
+	if err != nil {
+		t.Fatalf("WriteChunk() error = %v", err)
+	}
+	if !upload.Completed() {
+		t.Errorf("offset = %d, want upload completed at %d", upload.Offset, upload.Length)
+	}
+	assertUploadedObject(t, s, finisher, data)
+}
+
+func TestWriteChunkConcurrentPatchAtSameOffset(t *testing.T) {
+	ctx := context.Background()
+	s, _, finisher := newTestTusService(t, 2*minMultipartPartSize)
+
+	chunks := [][]byte{testChunk(minMultipartPartSize, 'a'), testChunk(minMultipartPartSize, 'b')}
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk []byte) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = s.WriteChunk(ctx, 1, "upload-1", 0, bytes.NewReader(chunk))
+		}(i, chunk)
+	}
+	close(start)
+	wg.Wait()
+
+	// Hanya satu PATCH yang diterima, yang lain ditolak tanpa menimpa part yang sudah disimpan
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil && winner == -1:
+			winner = i
+		case err == nil:
+			t.Fatal("both PATCH requests at offset 0 succeeded")
+		case err.Error() != "upload offset mismatch":
+			t.Fatalf("WriteChunk() error = %v, want upload offset mismatch", err)
+		}
+	}
+	if winner == -1 {
+		t.Fatal("both PATCH requests at offset 0 failed")
+	}
+
+	last := testChunk(minMultipartPartSize, 'c')
+	if _, err := s.WriteChunk(ctx, 1, "upload-1", minMultipartPartSize, bytes.NewReader(last)); err != nil {
+		t.Fatalf("WriteChunk() error = %v", err)
+	}
+	assertUploadedObject(t, s, finisher, append(chunks[winner], last...))
+}
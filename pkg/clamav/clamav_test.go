@@ -0,0 +1,181 @@
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    *Result
+		wantErr string
+	}{
+		{
+			name:  "clean",
+			reply: "stream: OK",
+			want:  &Result{},
+		},
+		{
+			name:  "infected",
+			reply: "stream: Eicar-Test-Signature FOUND",
+			want:  &Result{Infected: true, Signature: "Eicar-Test-Signature"},
+		},
+		{
+			name:    "size limit exceeded",
+			reply:   "INSTREAM size limit exceeded. ERROR",
+			wantErr: "clamav: INSTREAM size limit exceeded.",
+		},
+		{
+			name:    "unknown status",
+			reply:   "stream: MAYBE",
+			wantErr: ErrUnexpectedResponse.Error(),
+		},
+		{
+			name:    "no status separator",
+			reply:   "PONG",
+			wantErr: ErrUnexpectedResponse.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReply(tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseReply() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseReply() error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("parseReply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanStream(t *testing.T) {
+	// Data lebih besar dari chunkSize supaya dikirim dalam beberapa chunk
+	data := append(bytes.Repeat([]byte("a"), chunkSize+10), EICAR...)
+
+	address := fakeClamd(t, func(command string, body []byte) string {
+		if command != "zINSTREAM\x00" {
+			return "UNKNOWN COMMAND"
+		}
+		if !bytes.Equal(body, data) {
+			return "body does not match. ERROR"
+		}
+		return "stream: Eicar-Test-Signature FOUND"
+	})
+
+	client := NewClient("tcp", address, time.Second)
+	result, err := client.ScanStream(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ScanStream() error = %v", err)
+	}
+	if !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Errorf("ScanStream() = %+v, want infected with Eicar-Test-Signature", result)
+	}
+}
+
+func TestPing(t *testing.T) {
+	address := fakeClamd(t, func(command string, body []byte) string {
+		if command != "zPING\x00" {
+			return "UNKNOWN COMMAND"
+		}
+		return "PONG"
+	})
+
+	if err := NewClient("tcp", address, time.Second).Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+}
+
+func TestScanStreamConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = NewClient("tcp", address, time.Second).ScanStream(context.Background(), strings.NewReader("data"))
+	if err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Fatalf("ScanStream() error = %v, want connect error", err)
+	}
+}
+
+// fakeClamd menjalankan server yang membaca satu perintah (beserta data INSTREAM) per koneksi
+// lalu membalas dengan hasil handle
+func fakeClamd(t *testing.T, handle func(command string, body []byte) string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on loopback: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				reader := bufio.NewReader(conn)
+				command, err := reader.ReadString('\x00')
+				if err != nil {
+					return
+				}
+
+				var body []byte
+				if command == "zINSTREAM\x00" {
+					if body, err = readChunks(reader); err != nil {
+						return
+					}
+				}
+				conn.Write([]byte(handle(command, body) + "\x00"))
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// readChunks membaca chunk [panjang uint32 big-endian][data] sampai chunk kosong
+func readChunks(r io.Reader) ([]byte, error) {
+	var body []byte
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, size); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			return body, nil
+		}
+		if n > chunkSize {
+			return nil, errors.New("chunk too large")
+		}
+		chunk := make([]byte, n)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, err
+		}
+		body = append(body, chunk...)
+	}
+}
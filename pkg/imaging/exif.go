@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Nilai EXIF orientation (1 = normal, 2-8 = flip/rotate)
+const (
+	OrientationNormal     = 1
+	OrientationFlipH      = 2
+	OrientationRotate180  = 3
+	OrientationFlipV      = 4
+	OrientationTranspose  = 5
+	OrientationRotate90   = 6
+	OrientationTransverse = 7
+	OrientationRotate270  = 8
+)
+
+// JPEGOrientation membaca tag EXIF Orientation (0x0112) dari data JPEG.
+// Mengembalikan OrientationNormal jika tidak ada EXIF atau datanya tidak valid.
+func JPEGOrientation(data []byte) int {
+	// JPEG dimulai dengan SOI (FFD8)
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return OrientationNormal
+	}
+
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return OrientationNormal
+		}
+		marker := data[offset+1]
+		// SOS atau EOI: tidak ada lagi segment metadata
+		if marker == 0xDA || marker == 0xD9 {
+			return OrientationNormal
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+		if length < 2 || offset+2+length > len(data) {
+			return OrientationNormal
+		}
+		segment := data[offset+4 : offset+2+length]
+
+		// APP1 berisi EXIF
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+
+		offset += 2 + length
+	}
+
+	return OrientationNormal
+}
+
+// tiffOrientation mencari tag Orientation di IFD0 dari header TIFF EXIF
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return OrientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+
+	ifdOffset := int(order.Uint32(tiff[4:8]))
+	if ifdOffset+2 > len(tiff) {
+		return OrientationNormal
+	}
+
+	entries := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifdOffset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return OrientationNormal
+		}
+		if order.Uint16(tiff[entry:entry+2]) != 0x0112 {
+			continue
+		}
+
+		value := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if value < OrientationNormal || value > OrientationRotate270 {
+			return OrientationNormal
+		}
+		return value
+	}
+
+	return OrientationNormal
+}
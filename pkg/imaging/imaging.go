@@ -0,0 +1,108 @@
+// Package imaging berisi operasi gambar untuk upload: decode, auto-orient dari
+// EXIF, resize, dan encode ulang (yang sekaligus membuang semua metadata).
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// Format output yang didukung
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+const jpegQuality = 85
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Decode membaca gambar JPEG/PNG dan memutarnya sesuai EXIF orientation,
+// hasilnya sudah dalam orientasi tampilan yang benar
+func Decode(data []byte) (image.Image, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if format != FormatJPEG && format != FormatPNG {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	if format == FormatJPEG {
+		img = Orient(img, JPEGOrientation(data))
+	}
+
+	return img, format, nil
+}
+
+// Fit mengecilkan gambar supaya sisi terpanjangnya maksimal size px dengan
+// menjaga aspect ratio. Gambar yang sudah lebih kecil dikembalikan apa adanya.
+func Fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// Encode menulis gambar dalam format yang diminta
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// ContentType mengembalikan MIME type untuk format output
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatPNG:
+		return "image/png"
+	case FormatWebP:
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Extension mengembalikan ekstensi file untuk format output
+func Extension(format string) string {
+	switch format {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatPNG:
+		return ".png"
+	case FormatWebP:
+		return ".webp"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,59 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Orient memutar/membalik gambar sesuai nilai EXIF orientation
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= OrientationNormal || orientation > OrientationRotate270 {
+		return img
+	}
+
+	src := toRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	// Orientation 5-8 menukar lebar dan tinggi
+	dw, dh := w, h
+	if orientation >= OrientationTranspose {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case OrientationFlipH:
+				dx, dy = w-1-x, y
+			case OrientationRotate180:
+				dx, dy = w-1-x, h-1-y
+			case OrientationFlipV:
+				dx, dy = x, h-1-y
+			case OrientationTranspose:
+				dx, dy = y, x
+			case OrientationRotate90:
+				dx, dy = h-1-y, x
+			case OrientationTransverse:
+				dx, dy = h-1-y, w-1-x
+			case OrientationRotate270:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+
+	return dst
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
@@ -54,7 +54,7 @@ func Connect(dataSourceName string) (*gorm.DB, error) {
 	if err := db.Exec(`
         CREATE TABLE IF NOT EXISTS departments (
             id SERIAL PRIMARY KEY,
-            department_id VARCHAR(10) NOT NULL,
+            department_id VARCHAR(32) NOT NULL,
             user_id INTEGER NOT NULL,
             name VARCHAR(33) NOT NULL,
             created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
@@ -66,33 +66,147 @@ func Connect(dataSourceName string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to create departments table: %w", err)
 	}
 
-	// Buat partial unique index dalam query terpisah
-	if err := db.Exec(`
-        CREATE UNIQUE INDEX IF NOT EXISTS idx_departments_department_id 
-        ON departments (department_id) 
-        WHERE deleted_at IS NULL
-    `).Error; err != nil {
-		return nil, fmt.Errorf("failed to create department unique index: %w", err)
-	}
-
 	// Create Employees table
 	if err := db.Exec(`
         CREATE TABLE IF NOT EXISTS employees (
             id SERIAL PRIMARY KEY,
-            department_id VARCHAR(10) NOT NULL,
+            department_id VARCHAR(32) NOT NULL,
             identity_number VARCHAR(33) UNIQUE NOT NULL,
             name VARCHAR(33) NOT NULL,
             employee_image_uri VARCHAR(255),
             gender VARCHAR(6) NOT NULL,
             created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
             updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-            deleted_at TIMESTAMP WITH TIME ZONE,
-            FOREIGN KEY (department_id) REFERENCES departments(department_id)
+            deleted_at TIMESTAMP WITH TIME ZONE
         )
     `).Error; err != nil {
 		return nil, fmt.Errorf("failed to create employees table: %w", err)
 	}
 
+	// Department yang diarsipkan disembunyikan dari pilihan tapi tetap menyimpan employee-nya
+	if err := db.Exec("ALTER TABLE departments ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE").Error; err != nil {
+		return nil, fmt.Errorf("failed to add archived_at column to departments: %w", err)
+	}
+
+	// Department induk untuk struktur bertingkat
+	if err := db.Exec("ALTER TABLE departments ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES departments(id) ON DELETE SET NULL").Error; err != nil {
+		return nil, fmt.Errorf("failed to add parent_id column to departments: %w", err)
+	}
+
+	// Data kontak dan kepegawaian employee, employee lama mendapat nilai default
+	// (string kosong, tanpa tanggal, full_time, tanpa kontak darurat)
+	employeeColumns := []string{
+		"work_email VARCHAR(255) NOT NULL DEFAULT ''",
+		"phone VARCHAR(20) NOT NULL DEFAULT ''",
+		"job_title VARCHAR(64) NOT NULL DEFAULT ''",
+		"employment_type VARCHAR(16) NOT NULL DEFAULT 'full_time'",
+		"hire_date DATE",
+		"probation_end_date DATE",
+		"date_of_birth DATE",
+		"address VARCHAR(255) NOT NULL DEFAULT ''",
+		"emergency_contacts JSONB NOT NULL DEFAULT '[]'",
+	}
+	for _, column := range employeeColumns {
+		if err := db.Exec("ALTER TABLE employees ADD COLUMN IF NOT EXISTS " + column).Error; err != nil {
+			return nil, fmt.Errorf("failed to add column to employees: %w", err)
+		}
+	}
+
+	// Atasan langsung, bawahan otomatis tanpa atasan jika atasannya dipurge
+	if err := db.Exec("ALTER TABLE employees ADD COLUMN IF NOT EXISTS manager_id INTEGER REFERENCES employees(id) ON DELETE SET NULL").Error; err != nil {
+		return nil, fmt.Errorf("failed to add manager_id column to employees: %w", err)
+	}
+
+	// Work email unik (case insensitive) di antara employee aktif yang mengisinya
+	if err := db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_work_email
+        ON employees (LOWER(work_email))
+        WHERE work_email <> '' AND deleted_at IS NULL
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create employee work email index: %w", err)
+	}
+
+	// Akun user milik employee, satu akun hanya untuk satu employee aktif
+	if err := db.Exec("ALTER TABLE employees ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE SET NULL").Error; err != nil {
+		return nil, fmt.Errorf("failed to add user_id column to employees: %w", err)
+	}
+	if err := db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_user_id
+        ON employees (user_id)
+        WHERE user_id IS NOT NULL AND deleted_at IS NULL
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create employee user index: %w", err)
+	}
+
+	// Kepala department, ikut terhapus jika department atau employee-nya dipurge
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS department_heads (
+            id SERIAL PRIMARY KEY,
+            department_id INTEGER NOT NULL,
+            employee_id INTEGER NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (department_id) REFERENCES departments(id) ON DELETE CASCADE,
+            FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE CASCADE,
+            UNIQUE (department_id, employee_id)
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create department_heads table: %w", err)
+	}
+
+	// Riwayat department, jabatan dan atasan employee, ikut terhapus saat employee dipurge
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS employee_histories (
+            id SERIAL PRIMARY KEY,
+            employee_id INTEGER NOT NULL,
+            department_id VARCHAR(32) NOT NULL,
+            job_title VARCHAR(64) NOT NULL DEFAULT '',
+            manager_id INTEGER,
+            effective_from DATE NOT NULL,
+            effective_to DATE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE CASCADE,
+            FOREIGN KEY (manager_id) REFERENCES employees(id) ON DELETE SET NULL
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create employee_histories table: %w", err)
+	}
+
+	// Transfer employee terjadwal, diterapkan oleh job employee-transfer
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS employee_transfers (
+            id SERIAL PRIMARY KEY,
+            employee_id INTEGER NOT NULL,
+            department_id VARCHAR(32) NOT NULL,
+            job_title VARCHAR(64) NOT NULL DEFAULT '',
+            manager_id INTEGER,
+            effective_date DATE NOT NULL,
+            status VARCHAR(16) NOT NULL DEFAULT 'pending',
+            failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            applied_at TIMESTAMP WITH TIME ZONE,
+            FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE CASCADE,
+            FOREIGN KEY (manager_id) REFERENCES employees(id) ON DELETE SET NULL
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create employee_transfers table: %w", err)
+	}
+	if err := db.Exec("ALTER TABLE employee_transfers ADD COLUMN IF NOT EXISTS clear_manager BOOLEAN NOT NULL DEFAULT FALSE").Error; err != nil {
+		return nil, fmt.Errorf("failed to add clear_manager column to employee_transfers: %w", err)
+	}
+
+	// Identity number hanya unik di antara employee aktif, employee di trash tidak menghalangi
+	if err := dropConstraint(db, "employees", "employees_identity_number_key"); err != nil {
+		return nil, fmt.Errorf("failed to drop employee identity number constraint: %w", err)
+	}
+	if err := db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_identity_number
+        ON employees (identity_number)
+        WHERE deleted_at IS NULL
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create employee identity number index: %w", err)
+	}
+
 	// Create Files table
 	if err := db.Exec(`
         CREATE TABLE IF NOT EXISTS files (
@@ -110,12 +224,189 @@ func Connect(dataSourceName string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to create files table: %w", err)
 	}
 
+	// Metadata tambahan untuk file yang diupload
+	if err := db.Exec("ALTER TABLE files ADD COLUMN IF NOT EXISTS storage_key VARCHAR(512) NOT NULL DEFAULT ''").Error; err != nil {
+		return nil, fmt.Errorf("failed to add storage_key column to files: %w", err)
+	}
+	if err := db.Exec("ALTER TABLE files ADD COLUMN IF NOT EXISTS checksum VARCHAR(64) NOT NULL DEFAULT ''").Error; err != nil {
+		return nil, fmt.Errorf("failed to add checksum column to files: %w", err)
+	}
+	if err := db.Exec("ALTER TABLE files ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ready'").Error; err != nil {
+		return nil, fmt.Errorf("failed to add status column to files: %w", err)
+	}
+	if err := db.Exec("ALTER TABLE files ADD COLUMN IF NOT EXISTS purpose VARCHAR(32) NOT NULL DEFAULT 'employee_photo'").Error; err != nil {
+		return nil, fmt.Errorf("failed to add purpose column to files: %w", err)
+	}
+	if err := db.Exec("ALTER TABLE files ADD COLUMN IF NOT EXISTS scan_result VARCHAR(255) NOT NULL DEFAULT ''").Error; err != nil {
+		return nil, fmt.Errorf("failed to add scan_result column to files: %w", err)
+	}
+	if err := db.Exec("ALTER TABLE files ADD COLUMN IF NOT EXISTS scanned_at TIMESTAMP WITH TIME ZONE").Error; err != nil {
+		return nil, fmt.Errorf("failed to add scanned_at column to files: %w", err)
+	}
+	if err := widenColumn(db, "files", "file_uri", 1024); err != nil {
+		return nil, fmt.Errorf("failed to widen file_uri column: %w", err)
+	}
+
+	// Variant gambar (resize/WebP) yang dibuat saat upload
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS file_variants (
+            id SERIAL PRIMARY KEY,
+            file_id INTEGER NOT NULL,
+            width INTEGER NOT NULL,
+            height INTEGER NOT NULL,
+            format VARCHAR(10) NOT NULL,
+            file_uri VARCHAR(1024) NOT NULL,
+            storage_key VARCHAR(512) NOT NULL,
+            file_type VARCHAR(50) NOT NULL,
+            file_size BIGINT NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create file_variants table: %w", err)
+	}
+
+	// Referensi entity ke file, FK mencegah file yang masih dipakai terhapus
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS file_references (
+            id SERIAL PRIMARY KEY,
+            file_id INTEGER NOT NULL,
+            entity_type VARCHAR(32) NOT NULL,
+            entity_id INTEGER NOT NULL,
+            field VARCHAR(32) NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (file_id) REFERENCES files(id),
+            UNIQUE (entity_type, entity_id, field)
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create file_references table: %w", err)
+	}
+
+	// State upload resumable (tus), ikut terhapus bersama record file pending-nya
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS tus_uploads (
+            id VARCHAR(36) PRIMARY KEY,
+            user_id INTEGER NOT NULL,
+            file_id INTEGER NOT NULL,
+            upload_offset BIGINT NOT NULL DEFAULT 0,
+            upload_length BIGINT NOT NULL,
+            multipart_id VARCHAR(1024) NOT NULL,
+            parts JSONB NOT NULL DEFAULT '[]',
+            staging_key VARCHAR(512) NOT NULL,
+            staged_size BIGINT NOT NULL DEFAULT 0,
+            expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (user_id) REFERENCES users(id),
+            FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tus_uploads table: %w", err)
+	}
+
+	// Override kuota storage per user dari admin, quota_bytes NULL = tanpa batas
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS storage_quotas (
+            user_id INTEGER PRIMARY KEY,
+            quota_bytes BIGINT CHECK (quota_bytes >= 0),
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create storage_quotas table: %w", err)
+	}
+
+	// Tambah kolom version untuk optimistic locking (ETag / If-Match)
+	for _, table := range []string{"users", "departments", "employees"} {
+		if err := db.Exec("ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1").Error; err != nil {
+			return nil, fmt.Errorf("failed to add version column to %s: %w", table, err)
+		}
+	}
+
+	// Perlebar department_id agar muat format ID yang bisa dikonfigurasi (contoh: ENG-0001, DEP-100)
+	for _, table := range []string{"departments", "employees"} {
+		if err := widenColumn(db, table, "department_id", 32); err != nil {
+			return nil, fmt.Errorf("failed to widen department_id column of %s: %w", table, err)
+		}
+	}
+
+	// DepartmentID hanya unik per tenant, employee menyimpan tenant department-nya di owner_id.
+	// Kolom diisi sekali saat ditambahkan, selama department_id masih unik di semua tenant.
+	hasOwner, err := columnExists(db, "employees", "owner_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check owner_id column of employees: %w", err)
+	}
+	if !hasOwner {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("ALTER TABLE employees ADD COLUMN owner_id INTEGER NOT NULL DEFAULT 0").Error; err != nil {
+				return err
+			}
+			// Department di trash ikut dihitung (employee-nya bisa direstore), yang aktif diutamakan
+			return tx.Exec(`
+                UPDATE employees e SET owner_id = d.user_id
+                FROM (
+                    SELECT DISTINCT ON (department_id) department_id, user_id FROM departments
+                    ORDER BY department_id, deleted_at IS NOT NULL, deleted_at DESC
+                ) d
+                WHERE d.department_id = e.department_id
+            `).Error
+		}); err != nil {
+			return nil, fmt.Errorf("failed to add owner_id column to employees: %w", err)
+		}
+	}
+	if err := dropConstraint(db, "employees", "employees_department_id_fkey"); err != nil {
+		return nil, fmt.Errorf("failed to drop employee department constraint: %w", err)
+	}
+	if err := db.Exec(`
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_departments_user_department_id
+        ON departments (user_id, department_id)
+        WHERE deleted_at IS NULL
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create department unique index: %w", err)
+	}
+	if err := db.Exec("DROP INDEX IF EXISTS idx_departments_department_id").Error; err != nil {
+		return nil, fmt.Errorf("failed to drop global department unique index: %w", err)
+	}
+
+	// Counter nomor urut DepartmentID per tenant dan prefix, menggantikan department_id_seq global.
+	// Row counter dibuat saat pertama dipakai dan dimulai dari angka di belakang department_id yang sudah ada.
+	if err := db.Exec(`
+        CREATE TABLE IF NOT EXISTS department_id_counters (
+            user_id INTEGER NOT NULL,
+            prefix VARCHAR(32) NOT NULL,
+            last_value BIGINT NOT NULL,
+            PRIMARY KEY (user_id, prefix),
+            FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+        )
+    `).Error; err != nil {
+		return nil, fmt.Errorf("failed to create department_id_counters table: %w", err)
+	}
+	if err := db.Exec("DROP SEQUENCE IF EXISTS department_id_seq").Error; err != nil {
+		return nil, fmt.Errorf("failed to drop department id sequence: %w", err)
+	}
+
 	// Create indexes
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_departments_user_id ON departments(user_id)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_departments_name ON departments(name)")
-	db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_department_id ON employees(department_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_departments_archived_at ON departments(archived_at)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_departments_parent_id ON departments(parent_id)")
+	db.Exec("DROP INDEX IF EXISTS idx_employees_department_id")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_owner_department_id ON employees(owner_id, department_id)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_name ON employees(name)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_deleted_at ON employees(deleted_at)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_hire_date ON employees(hire_date)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_manager_id ON employees(manager_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_department_heads_employee_id ON department_heads(employee_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employee_histories_employee_id ON employee_histories(employee_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employee_histories_department_id ON employee_histories(department_id, effective_from)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employee_transfers_employee_id ON employee_transfers(employee_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_employee_transfers_pending ON employee_transfers(effective_date) WHERE status = 'pending'")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_files_user_id ON files(user_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_files_storage_key ON files(storage_key)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_file_variants_file_id ON file_variants(file_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_file_references_file_id ON file_references(file_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_tus_uploads_expires_at ON tus_uploads(expires_at)")
 
 	// Verify connection
 	if err := sqlDB.Ping(); err != nil {
@@ -125,6 +416,47 @@ func Connect(dataSourceName string) (*gorm.DB, error) {
 	return db, nil
 }
 
+// columnExists mengecek kolom lewat information_schema, dipakai untuk migrasi yang cukup jalan sekali
+func columnExists(db *gorm.DB, table, column string) (bool, error) {
+	var count int64
+	err := db.Raw(`
+        SELECT COUNT(*) FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?
+    `, table, column).Scan(&count).Error
+	return count > 0, err
+}
+
+// widenColumn mengubah kolom menjadi VARCHAR(length) hanya jika masih lebih pendek. ALTER TABLE
+// mengunci tabel (ACCESS EXCLUSIVE), jadi tidak dijalankan ulang setiap startup.
+func widenColumn(db *gorm.DB, table, column string, length int) error {
+	var current int
+	if err := db.Raw(`
+        SELECT COALESCE(MAX(character_maximum_length), 0) FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?
+    `, table, column).Scan(&current).Error; err != nil {
+		return err
+	}
+	if current >= length {
+		return nil
+	}
+	return db.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE VARCHAR(%d)", table, column, length)).Error
+}
+
+// dropConstraint menghapus constraint hanya jika masih ada, dengan alasan yang sama seperti widenColumn
+func dropConstraint(db *gorm.DB, table, constraint string) error {
+	var count int64
+	if err := db.Raw(`
+        SELECT COUNT(*) FROM information_schema.table_constraints
+        WHERE table_schema = current_schema() AND table_name = ? AND constraint_name = ?
+    `, table, constraint).Scan(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	return db.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, constraint)).Error
+}
+
 // Utility function untuk close database connection dengan graceful
 func CloseDatabaseConnection(db *gorm.DB) error {
 	sqlDB, err := db.DB()
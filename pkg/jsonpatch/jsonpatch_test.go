@@ -0,0 +1,239 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	original := `{"name":"Budi","tags":["a","b"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "add member",
+			patch: `[{"op":"add","path":"/phone","value":"+628123"}]`,
+			want:  `{"name":"Budi","tags":["a","b"],"address":{"city":"Bandung"},"a/b":1,"m~n":2,"phone":"+628123"}`,
+		},
+		{
+			name:  "add array element by index",
+			patch: `[{"op":"add","path":"/tags/1","value":"x"}]`,
+			want:  `{"name":"Budi","tags":["a","x","b"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "add array element with dash appends",
+			patch: `[{"op":"add","path":"/tags/-","value":"c"}]`,
+			want:  `{"name":"Budi","tags":["a","b","c"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "add at array length appends",
+			patch: `[{"op":"add","path":"/tags/2","value":"c"}]`,
+			want:  `{"name":"Budi","tags":["a","b","c"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "remove member",
+			patch: `[{"op":"remove","path":"/address/city"}]`,
+			want:  `{"name":"Budi","tags":["a","b"],"address":{},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "remove array element",
+			patch: `[{"op":"remove","path":"/tags/0"}]`,
+			want:  `{"name":"Budi","tags":["b"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "replace member",
+			patch: `[{"op":"replace","path":"/name","value":"Andi"}]`,
+			want:  `{"name":"Andi","tags":["a","b"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "replace array element",
+			patch: `[{"op":"replace","path":"/tags/1","value":"z"}]`,
+			want:  `{"name":"Budi","tags":["a","z"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "move member",
+			patch: `[{"op":"move","from":"/address/city","path":"/city"}]`,
+			want:  `{"name":"Budi","tags":["a","b"],"address":{},"city":"Bandung","a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "move array element",
+			patch: `[{"op":"move","from":"/tags/0","path":"/tags/-"}]`,
+			want:  `{"name":"Budi","tags":["b","a"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "copy member is independent of source",
+			patch: `[{"op":"copy","from":"/address","path":"/office"},{"op":"replace","path":"/office/city","value":"Jakarta"}]`,
+			want:  `{"name":"Budi","tags":["a","b"],"address":{"city":"Bandung"},"office":{"city":"Jakarta"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "test passes",
+			patch: `[{"op":"test","path":"/tags","value":["a","b"]},{"op":"replace","path":"/name","value":"Andi"}]`,
+			want:  `{"name":"Andi","tags":["a","b"],"address":{"city":"Bandung"},"a/b":1,"m~n":2}`,
+		},
+		{
+			name:  "escaped slash in pointer",
+			patch: `[{"op":"replace","path":"/a~1b","value":10}]`,
+			want:  `{"name":"Budi","tags":["a","b"],"address":{"city":"Bandung"},"a/b":10,"m~n":2}`,
+		},
+		{
+			name:  "escaped tilde in pointer",
+			patch: `[{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"name":"Budi","tags":["a","b"],"address":{"city":"Bandung"},"a/b":1}`,
+		},
+		{
+			name:    "failed test aborts the whole patch",
+			patch:   `[{"op":"replace","path":"/name","value":"Andi"},{"op":"test","path":"/name","value":"Budi"}]`,
+			wantErr: "test failed",
+		},
+		{
+			name:    "add index past array length",
+			patch:   `[{"op":"add","path":"/tags/3","value":"c"}]`,
+			wantErr: "out of range",
+		},
+		{
+			name:    "remove index out of range",
+			patch:   `[{"op":"remove","path":"/tags/2"}]`,
+			wantErr: "out of range",
+		},
+		{
+			name:    "replace with dash index",
+			patch:   `[{"op":"replace","path":"/tags/-","value":"c"}]`,
+			wantErr: "out of range",
+		},
+		{
+			name:    "array index with leading zero",
+			patch:   `[{"op":"remove","path":"/tags/01"}]`,
+			wantErr: "invalid array index",
+		},
+		{
+			name:    "remove missing member",
+			patch:   `[{"op":"remove","path":"/phone"}]`,
+			wantErr: "not found",
+		},
+		{
+			name:    "move into own child",
+			patch:   `[{"op":"move","from":"/address","path":"/address/old"}]`,
+			wantErr: "cannot move a value into one of its children",
+		},
+		{
+			name:    "add without value",
+			patch:   `[{"op":"add","path":"/phone"}]`,
+			wantErr: "missing value",
+		},
+		{
+			name:    "unsupported operation",
+			patch:   `[{"op":"merge","path":"/name","value":"Andi"}]`,
+			wantErr: "unsupported operation",
+		},
+		{
+			name:    "pointer without leading slash",
+			patch:   `[{"op":"remove","path":"name"}]`,
+			wantErr: "invalid JSON pointer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := DecodePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("DecodePatch() error = %v", err)
+			}
+
+			got, err := Apply([]byte(original), ops)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if normalize(t, string(got)) != normalize(t, tt.want) {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchFields(t *testing.T) {
+	ops, err := DecodePatch([]byte(`[
+		{"op":"replace","path":"/name","value":"Andi"},
+		{"op":"move","from":"/address/city","path":"/city"},
+		{"op":"add","path":"/name/x","value":1},
+		{"op":"test","path":"","value":{}}
+	]`))
+	if err != nil {
+		t.Fatalf("DecodePatch() error = %v", err)
+	}
+
+	got := strings.Join(PatchFields(ops), ",")
+	if want := "name,city,address"; got != want {
+		t.Errorf("PatchFields() = %s, want %s", got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		patch    string
+		want     string
+	}{
+		{
+			name:     "replace and add members",
+			original: `{"name":"Budi","phone":"+628123"}`,
+			patch:    `{"name":"Andi","jobTitle":"Engineer"}`,
+			want:     `{"name":"Andi","phone":"+628123","jobTitle":"Engineer"}`,
+		},
+		{
+			name:     "null removes member",
+			original: `{"name":"Budi","phone":"+628123"}`,
+			patch:    `{"phone":null}`,
+			want:     `{"name":"Budi"}`,
+		},
+		{
+			name:     "nested objects are merged",
+			original: `{"address":{"city":"Bandung","zip":"40111"}}`,
+			patch:    `{"address":{"city":"Jakarta"}}`,
+			want:     `{"address":{"city":"Jakarta","zip":"40111"}}`,
+		},
+		{
+			name:     "arrays are replaced",
+			original: `{"tags":["a","b"]}`,
+			patch:    `{"tags":["c"]}`,
+			want:     `{"tags":["c"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.original), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if normalize(t, string(got)) != normalize(t, tt.want) {
+				t.Errorf("MergePatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// normalize menulis ulang JSON supaya urutan key tidak mempengaruhi perbandingan
+func normalize(t *testing.T, doc string) string {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", doc, err)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
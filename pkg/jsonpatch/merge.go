@@ -0,0 +1,59 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch menerapkan JSON Merge Patch (RFC 7396) ke dokumen original.
+// Field yang tidak ada di patch tidak berubah, field bernilai null dihapus.
+func MergePatch(original, patch []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("invalid original document: %w", err)
+	}
+
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	return json.Marshal(mergeValue(doc, patchDoc))
+}
+
+// MergePatchFields mengembalikan nama field top-level yang disebut di merge patch
+func MergePatchFields(patch []byte) ([]string, error) {
+	var patchDoc map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("merge patch must be a JSON object: %w", err)
+	}
+
+	fields := make([]string, 0, len(patchDoc))
+	for field := range patchDoc {
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		// Patch bukan object, ganti seluruh nilai target
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+
+	return targetObj
+}
@@ -0,0 +1,37 @@
+package urlsign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Signer membuat dan memverifikasi signature HMAC-SHA256 untuk URL file
+type Signer struct {
+	secretKey []byte
+}
+
+func NewSigner(secretKey string) *Signer {
+	return &Signer{secretKey: []byte(secretKey)}
+}
+
+// Sign menghasilkan signature hex dari gabungan parts (contoh: method, key, expires)
+func (s *Signer) Sign(parts ...string) string {
+	mac := hmac.New(sha256.New, s.secretKey)
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify membandingkan signature dengan hasil Sign secara constant time
+func (s *Signer) Verify(signature string, parts ...string) bool {
+	expected, err := hex.DecodeString(s.Sign(parts...))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, actual)
+}
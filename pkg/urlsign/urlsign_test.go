@@ -0,0 +1,82 @@
+package urlsign
+
+import (
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	signature := signer.Sign("GET", "user@example.com/file.pdf", "1700000000")
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		signature string
+		parts     []string
+		want      bool
+	}{
+		{
+			name:      "valid",
+			signer:    signer,
+			signature: signature,
+			parts:     []string{"GET", "user@example.com/file.pdf", "1700000000"},
+			want:      true,
+		},
+		{
+			name:      "tampered key",
+			signer:    signer,
+			signature: signature,
+			parts:     []string{"GET", "other@example.com/file.pdf", "1700000000"},
+		},
+		{
+			name:      "tampered expires",
+			signer:    signer,
+			signature: signature,
+			parts:     []string{"GET", "user@example.com/file.pdf", "1800000000"},
+		},
+		{
+			name:      "tampered method",
+			signer:    signer,
+			signature: signature,
+			parts:     []string{"PUT", "user@example.com/file.pdf", "1700000000"},
+		},
+		{
+			name:      "extra part",
+			signer:    signer,
+			signature: signature,
+			parts:     []string{"GET", "user@example.com/file.pdf", "1700000000", "thumb"},
+		},
+		{
+			name:      "tampered signature",
+			signer:    signer,
+			signature: "0" + signature[1:],
+			parts:     []string{"GET", "user@example.com/file.pdf", "1700000000"},
+		},
+		{
+			name:      "signature not hex",
+			signer:    signer,
+			signature: "not-a-signature",
+			parts:     []string{"GET", "user@example.com/file.pdf", "1700000000"},
+		},
+		{
+			name:      "empty signature",
+			signer:    signer,
+			signature: "",
+			parts:     []string{"GET", "user@example.com/file.pdf", "1700000000"},
+		},
+		{
+			name:      "different secret",
+			signer:    NewSigner("other-secret"),
+			signature: signature,
+			parts:     []string{"GET", "user@example.com/file.pdf", "1700000000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.signature, tt.parts...); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package xlsx membaca dan menulis file Office Open XML spreadsheet (.xlsx) tanpa dependency
+// eksternal. Yang didukung hanya isi cell sebagai teks di satu sheet, cukup untuk import dan
+// export data tabel.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFile dikembalikan jika file bukan xlsx yang valid
+var ErrInvalidFile = errors.New("xlsx: invalid file")
+
+const (
+	// Ukuran maksimal satu bagian XML setelah didekompresi, mencegah zip bomb
+	maxPartSize = 64 << 20
+	// Jumlah baris maksimal satu sheet di Excel
+	maxRows = 1 << 20
+)
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// richText dipakai untuk shared string dan inline string, teks bisa dipecah per run (<r>)
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type sharedStrings struct {
+	Items []richText `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline richText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadFirstSheet membaca semua baris sheet pertama. Cell kosong di tengah baris diisi string
+// kosong, baris kosong di tengah sheet dipertahankan supaya nomor baris sesuai dengan Excel.
+func ReadFirstSheet(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared sharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidFile, sheetPath)
+	}
+	var sheet worksheet
+	if err := decodeXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		// Atribut r opsional, jika tidak ada baris dianggap berurutan
+		index := row.Index
+		if index == 0 {
+			index = len(rows) + 1
+		}
+		if index > maxRows {
+			return nil, fmt.Errorf("%w: invalid row number %d", ErrInvalidFile, index)
+		}
+		for len(rows) < index-1 {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for j, cell := range row.Cells {
+			col := j
+			if cell.Ref != "" {
+				if col, err = columnIndex(cell.Ref); err != nil {
+					return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidFile, i+1, err)
+				}
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			value, err := cellValue(cell.Type, cell.Value, cell.Inline, shared)
+			if err != nil {
+				return nil, fmt.Errorf("%w: cell %s: %v", ErrInvalidFile, cell.Ref, err)
+			}
+			values[col] = value
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// firstSheetPath mencari lokasi file sheet pertama lewat workbook.xml dan relasinya
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	f, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: missing xl/workbook.xml", ErrInvalidFile)
+	}
+	var wb workbook
+	if err := decodeXML(f, &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", fmt.Errorf("%w: workbook has no sheets", ErrInvalidFile)
+	}
+
+	f, ok = files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		// Tanpa relasi, pakai lokasi default yang dibuat Excel
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	var rels relationships
+	if err := decodeXML(f, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != wb.Sheets[0].ID {
+			continue
+		}
+		// Target relatif terhadap folder xl/, kecuali diawali "/"
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("%w: sheet %q not found", ErrInvalidFile, wb.Sheets[0].Name)
+}
+
+// decodeXML men-decode satu bagian file, bagian yang lebih besar dari maxPartSize ditolak.
+// Ukuran di header zip bisa dipalsukan, jadi pembacaan tetap dibatasi LimitReader.
+func decodeXML(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > maxPartSize {
+		return fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidFile, f.Name, maxPartSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+	return nil
+}
+
+func cellValue(cellType, value string, inline richText, shared sharedStrings) (string, error) {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(shared.Items) {
+			return "", fmt.Errorf("invalid shared string index %q", value)
+		}
+		return shared.Items[i].String(), nil
+	case "inlineStr":
+		return inline.String(), nil
+	case "b":
+		if value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "", "n":
+		// Angka besar (misalnya nomor identitas) bisa tersimpan dalam notasi eksponen
+		if strings.ContainsAny(value, "eE") {
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return strconv.FormatFloat(f, 'f', -1, 64), nil
+			}
+		}
+		return value, nil
+	default:
+		// str (hasil formula), e (error) dan d (tanggal ISO 8601) dipakai apa adanya
+		return value, nil
+	}
+}
+
+// columnIndex mengubah referensi cell seperti "AB12" menjadi index kolom 0-based (27)
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 || n > 3 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
@@ -0,0 +1,106 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadFirstSheetRejectsOversizedPart(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// Header zip mengklaim workbook.xml lebih besar dari maxPartSize, isinya tidak perlu dibaca
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "xl/workbook.xml",
+		Method:             zip.Store,
+		CompressedSize64:   1,
+		UncompressedSize64: maxPartSize + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, ErrInvalidFile) || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("ReadFirstSheet() error = %v, want oversized part error", err)
+	}
+}
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"identityNumber", "name", "address"},
+		{"12345", "Budi & <Andi>", "Jl. \"Merdeka\" 1\nBandung"},
+		{"67890", "", "  spasi di awal"},
+		{"11111", "Siti", "", "", "kolom ke-5"},
+		{"22222", "Rina", ""},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Employees & Co")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadFirstSheet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadFirstSheet() error = %v", err)
+	}
+
+	// Cell kosong di akhir baris tidak ditulis, jadi baris hasil baca bisa lebih pendek
+	want := [][]string{
+		{"identityNumber", "name", "address"},
+		{"12345", "Budi & <Andi>", "Jl. \"Merdeka\" 1\nBandung"},
+		{"67890", "", "  spasi di awal"},
+		{"11111", "Siti", "", "", "kolom ke-5"},
+		{"22222", "Rina"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadFirstSheet() returned %d rows, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if strings.Join(got[i], "|") != strings.Join(want[i], "|") || len(got[i]) != len(want[i]) {
+			t.Errorf("row %d = %q, want %q", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestColumnNameAndIndex(t *testing.T) {
+	tests := []struct {
+		index int
+		name  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.name {
+			t.Errorf("columnName(%d) = %s, want %s", tt.index, got, tt.name)
+		}
+		got, err := columnIndex(tt.name + "12")
+		if err != nil || got != tt.index {
+			t.Errorf("columnIndex(%s12) = %d, %v, want %d", tt.name, got, err, tt.index)
+		}
+	}
+}
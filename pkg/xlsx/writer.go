@@ -0,0 +1,135 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// MaxRows adalah batas jumlah baris satu sheet di Excel
+const MaxRows = 1048576
+
+// ErrTooManyRows dikembalikan jika baris melebihi MaxRows
+var ErrTooManyRows = errors.New("xlsx: too many rows")
+
+const contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+// Writer menulis file xlsx satu sheet secara streaming: setiap baris langsung ditulis ke
+// output, jadi memori tetap konstan berapa pun jumlah barisnya. Semua cell ditulis sebagai
+// inline string supaya tidak perlu menyimpan shared string table.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	err   error
+}
+
+// NewWriter menulis bagian awal file xlsx ke w, lalu baris ditulis dengan WriteRow.
+// Close wajib dipanggil untuk menutup file.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	var name bytesWriter
+	xml.EscapeText(&name, []byte(sheetName))
+	workbookXML := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + string(name) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sheet ditulis terakhir karena isinya baru selesai saat Close
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	return &Writer{
+		zw:    zw,
+		sheet: sheet,
+	}, nil
+}
+
+// WriteRow menulis satu baris, cell kosong tidak ditulis
+func (w *Writer) WriteRow(values []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.rows >= MaxRows {
+		return ErrTooManyRows
+	}
+	w.rows++
+
+	row := strconv.Itoa(w.rows)
+	w.sheet.WriteString(`<row r="` + row + `">`)
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+		w.sheet.WriteString(`<c r="` + columnName(i) + row + `" t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(w.sheet, []byte(value))
+		w.sheet.WriteString(`</t></is></c>`)
+	}
+	_, w.err = w.sheet.WriteString(`</row>`)
+	return w.err
+}
+
+// Close menutup sheet dan file zip, tidak menutup writer tujuan
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// columnName mengubah index kolom 0-based menjadi nama kolom Excel (0 -> A, 27 -> AB)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// bytesWriter menampung hasil xml.EscapeText untuk string pendek
+type bytesWriter []byte
+
+func (b *bytesWriter) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}